@@ -2,38 +2,184 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/events"
+	"github.com/justinbarrick/flux-operator/pkg/health"
+	"github.com/justinbarrick/flux-operator/pkg/leader"
 	stub "github.com/justinbarrick/flux-operator/pkg/stub"
+	"github.com/justinbarrick/flux-operator/pkg/webhook"
+	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
 	sdk "github.com/operator-framework/operator-sdk/pkg/sdk"
 	sdkVersion "github.com/operator-framework/operator-sdk/version"
 
 	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// How long to wait for in-flight reconciles to finish after receiving SIGTERM,
+// this must be shorter than the pod's terminationGracePeriodSeconds.
+const drainTimeout = 25 * time.Second
+
 func printVersion() {
 	logrus.Infof("Go Version: %s", runtime.Version())
 	logrus.Infof("Go OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH)
 	logrus.Infof("operator-sdk Version: %v", sdkVersion.Version)
 }
 
+// Return the number of Fluxes in namespaces.
+func countFluxes(namespaces []string) (int, error) {
+	count := 0
+
+	for _, namespace := range namespaces {
+		fluxes := &v1alpha1.FluxList{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Flux",
+				APIVersion: "flux.codesink.net/v1alpha1",
+			},
+		}
+
+		err := sdk.List(namespace, fluxes)
+		if err != nil {
+			return 0, err
+		}
+
+		count += len(fluxes.Items)
+	}
+
+	return count, nil
+}
+
+// The informer only dispatches events after its cache syncs, so if there are
+// no Fluxes to dispatch mark the operator ready once the API is reachable.
+func markReadyIfNoFluxes(ctx context.Context, checker *health.Checker, namespaces []string) {
+	for {
+		count, err := countFluxes(namespaces)
+		if err == nil {
+			if count == 0 {
+				checker.SetReady(true)
+			}
+			return
+		}
+
+		logrus.Errorf("Failed to list Fluxes: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
 func main() {
 	printVersion()
 
 	resource := "flux.codesink.net/v1alpha1"
 	kind := "Flux"
 
-	namespace := os.Getenv("FLUX_NAMESPACE")
 	resyncPeriod := 5
 
-	if namespace == "" {
+	err := config.ValidateNamespaces()
+	if err != nil {
+		logrus.Fatalf("Invalid namespace settings: %v", err)
+	}
+
+	namespaces := config.WatchNamespaces()
+	if len(namespaces) == 0 {
 		logrus.Infof("Watching for Fluxes at cluster scope.")
+		namespaces = []string{""}
 	} else {
-		logrus.Infof("Watching for Fluxes in %s.", namespace)
+		logrus.Infof("Watching for Fluxes in %s.", strings.Join(namespaces, ", "))
+	}
+
+	if config.Namespaced() {
+		logrus.Infof("Running in namespaced mode, cluster roles are disabled.")
 	}
 
-	sdk.Watch(resource, kind, "", resyncPeriod)
-	sdk.Handle(stub.NewHandler())
-	sdk.Run(context.TODO())
+	leaderConfig, err := leader.ConfigFromEnv()
+	if err != nil {
+		logrus.Fatalf("Invalid leader election settings: %v", err)
+	}
+
+	err = stub.LoadConfig()
+	if err != nil {
+		logrus.Errorf("Failed to load operator config, using the environment: %v", err)
+	}
+
+	events.SetRecorder(events.NewRecorder(events.NewKubernetesRecorder(k8sclient.GetKubeClient())))
+
+	checker := health.NewChecker()
+
+	// The health server outlives the watch so that the liveness probe keeps
+	// passing while in-flight reconciles drain.
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+
+	go func() {
+		err := checker.ListenAndServe(healthCtx, fmt.Sprintf(":%d", health.Port))
+		if err != nil {
+			logrus.Fatalf("Health check server failed: %v", err)
+		}
+	}()
+
+	// Every replica serves the conversion webhook, not only the leader.
+	go func() {
+		err := webhook.Run(healthCtx, k8sclient.GetKubeClient(), webhook.ConfigFromEnv())
+		if err != nil {
+			logrus.Errorf("Conversion webhook failed, v1alpha2 Fluxes can not be served: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-signals
+		logrus.Infof("Received %s, shutting down.", sig)
+		checker.SetReady(false)
+		cancel()
+	}()
+
+	// Standby replicas report ready so that they do not block rollouts, the
+	// leader is only ready once its cache has synced.
+	checker.SetReady(leaderConfig.Enabled)
+
+	err = leader.Run(ctx, k8sclient.GetKubeClient(), leaderConfig, func(ctx context.Context) {
+		checker.SetReady(false)
+		go markReadyIfNoFluxes(ctx, checker, namespaces)
+
+		for _, namespace := range namespaces {
+			sdk.Watch(resource, kind, namespace, resyncPeriod)
+		}
+		if config.ConfigMapName() != "" {
+			sdk.Watch("v1", "ConfigMap", config.ConfigMapNamespace(), resyncPeriod)
+		}
+		sdk.Handle(stub.NewHandler(checker))
+
+		// operator-sdk's informers panic if their context is cancelled before
+		// their caches sync, which a SIGTERM early in a rollout would do. They
+		// are never stopped and exit with the process instead, the checker stops
+		// new reconciles from starting once shutdown begins.
+		go sdk.Run(context.Background())
+		<-ctx.Done()
+	})
+
+	logrus.Infof("Waiting up to %s for in-flight reconciles to finish.", drainTimeout)
+	if !checker.Drain(drainTimeout) {
+		logrus.Warnf("Timed out waiting for in-flight reconciles to finish.")
+	}
+
+	if err != nil {
+		logrus.Fatalf("Leader election failed: %v", err)
+	}
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"github.com/justinbarrick/flux-operator/pkg/installer"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"log"
+	"strings"
+	"time"
+)
+
+// A flag that may be repeated, collecting every value.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// Register the flags that configure a flux-operator installation on flags and
+// return a function building the config once they have been parsed. The latest
+// release is only looked up if resolveVersion is set, since it is only needed to
+// render the flux-operator Deployment's image.
+func installerFlags(flags *flag.FlagSet) func(resolveVersion bool) installer.FluxOperatorConfig {
+	name := flags.String("name", "flux-operator", "Prefix to use for any resources created.")
+	namespace := flags.String("namespace", "default", "Namespace to deploy flux-operator into.")
+	watchNamespace := flags.String("watch-namespace", "", "If set, specifies the comma separated namespaces to watch for Flux CRDs, if not set flux-operator watches all namespaces.")
+	cluster := flags.Bool("cluster", false, "If set, creates a Cluster scoped CRD for Flux instead of Namespaced.")
+	namespaced := flags.Bool("namespaced", false, "If set, only grants flux-operator roles in the watched namespaces (default: its own namespace) instead of a cluster role and disables cluster roles for Fluxes. The CRDs are not included.")
+	serviceAccount := flags.String("service-account", "", "Service account to use.")
+	clusterRole := flags.String("cluster-role", "", "Cluster role to assign.")
+	disableRbac := flags.Bool("disable-rbac", false, "Disable setting any RBAC settings.")
+	gitSecret := flags.String("git-secret", "", "Default git secret name to use.")
+	fluxOperatorImage := flags.String("flux-operator-image", utils.FluxOperatorImage, "Flux operator image name.")
+	fluxOperatorVersion := flags.String("flux-operator-version", "", "Flux operator version name (default: the latest release, looked up at most once a day).")
+	offline := flags.Bool("offline", false, "Do not look up the latest release, default -flux-operator-version to the version fluxopctl was built with.")
+	fluxImage := flags.String("flux-image", utils.FluxImage, "Flux image name.")
+	fluxVersion := flags.String("flux-version", utils.FluxVersion, "Flux version name.")
+	helmOperatorImage := flags.String("helm-operator-image", utils.HelmOperatorImage, "Helm-operator image name.")
+	helmOperatorVersion := flags.String("helm-operator-version", utils.HelmOperatorVersion, "Helm-operator image version.")
+	memcachedImage := flags.String("memcached-image", utils.MemcachedImage, "Memcached image name.")
+	memcachedVersion := flags.String("memcached-version", utils.MemcachedVersion, "Memcached image version.")
+	tillerImage := flags.String("tiller-image", utils.TillerImage, "Tiller image name.")
+	tillerVersion := flags.String("tiller-version", utils.TillerVersion, "Tiller image version.")
+	disableRoles := flags.Bool("disable-roles", false, "Do not allow flux-operator to assign roles.")
+	disableClusterRoles := flags.Bool("disable-cluster-roles", false, "Do not allow flux-operator to assign cluster roles.")
+	planMode := flags.Bool("plan-mode", false, "Only report the changes flux-operator would make to each Flux's status instead of applying them.")
+	replicas := flags.Int("replicas", 1, "Number of flux-operator replicas to run, only the elected leader reconciles.")
+	leaseDuration := flags.String("leader-election-lease-duration", "", "How long standby replicas wait before taking over the leader lock (default: 15s).")
+	renewDeadline := flags.String("leader-election-renew-deadline", "", "How long the leader retries renewing the leader lock before giving it up (default: 10s).")
+	retryPeriod := flags.String("leader-election-retry-period", "", "How long replicas wait between attempts to acquire or renew the leader lock (default: 2s).")
+
+	return func(resolveVersion bool) installer.FluxOperatorConfig {
+		// The latest release is only looked up if no version was given.
+		if resolveVersion && *fluxOperatorVersion == "" {
+			resolver := utils.NewReleaseResolver(utils.FluxOperatorImage, *offline)
+			resolver.Warn = log.Printf
+
+			version, err := resolver.Version()
+			if err != nil {
+				log.Fatal(err)
+			}
+			*fluxOperatorVersion = version
+		}
+
+		config := installer.FluxOperatorConfig{
+			Name:                *name,
+			Namespace:           *namespace,
+			Cluster:             *cluster,
+			Namespaced:          *namespaced,
+			ServiceAccount:      *serviceAccount,
+			ClusterRole:         *clusterRole,
+			DisableRBAC:         *disableRbac,
+			FluxNamespace:       *watchNamespace,
+			GitSecret:           *gitSecret,
+			FluxOperatorImage:   *fluxOperatorImage,
+			FluxOperatorVersion: *fluxOperatorVersion,
+			FluxImage:           *fluxImage,
+			FluxVersion:         *fluxVersion,
+			HelmOperatorImage:   *helmOperatorImage,
+			HelmOperatorVersion: *helmOperatorVersion,
+			TillerImage:         *tillerImage,
+			TillerVersion:       *tillerVersion,
+			MemcachedImage:      *memcachedImage,
+			MemcachedVersion:    *memcachedVersion,
+			DisableRoles:        *disableRoles,
+			DisableClusterRoles: *disableClusterRoles,
+			PlanMode:            *planMode,
+			Replicas:            int32(*replicas),
+			LeaseDuration:       *leaseDuration,
+			RenewDeadline:       *renewDeadline,
+			RetryPeriod:         *retryPeriod,
+		}
+
+		if err := installer.Validate(config); err != nil {
+			log.Fatal(err)
+		}
+
+		return config
+	}
+}
+
+// Register the flags that select a cluster on flags, and how long to wait for
+// it if wait is set, and return a function creating a client for it once they
+// have been parsed.
+func clusterFlags(flags *flag.FlagSet, wait bool) func() *installer.Client {
+	kubeconfig := flags.String("kubeconfig", "", "Path to the kubeconfig to use (default: $KUBECONFIG or ~/.kube/config).")
+	context := flags.String("context", "", "The kubeconfig context to use (default: the current context).")
+	timeout := new(time.Duration)
+	if wait {
+		flags.DurationVar(timeout, "timeout", 0, "How long to wait for the CRDs and flux-operator to become ready (default: 5m).")
+	}
+
+	return func() *installer.Client {
+		client, err := installer.NewClientFromKubeconfig(*kubeconfig, *context)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if *timeout > 0 {
+			client.Timeout = *timeout
+		}
+
+		return client
+	}
+}
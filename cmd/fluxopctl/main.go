@@ -3,62 +3,376 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
 	"github.com/justinbarrick/flux-operator/pkg/installer"
-	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/justinbarrick/flux-operator/pkg/migrate"
+	"github.com/justinbarrick/flux-operator/pkg/render"
+	"github.com/justinbarrick/flux-operator/pkg/validation"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
 	"log"
+	"os"
+	"strings"
 )
 
+const usage = `Usage: fluxopctl [command] [flags]
+
+Commands:
+  (none)     Print the flux-operator manifests.
+  install    Install flux-operator into the cluster.
+  upgrade    Upgrade flux-operator in the cluster.
+  uninstall  Remove flux-operator and its CRDs from the cluster.
+  render     Print the objects flux-operator would create for Fluxes.
+  validate   Check files of Fluxes for errors.
+  status     Summarize the Fluxes in the cluster.
+  migrate    Convert an existing flux install into a Flux.
+
+Run 'fluxopctl <command> -help' for the flags of a command.
+`
+
 func main() {
-	fluxOperatorImageDefault := utils.FluxOperatorImage
-	fluxOperatorVersionDefault, err := utils.LatestRelease(fluxOperatorImageDefault)
+	command := ""
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command = args[0]
+		args = args[1:]
+	}
+
+	switch command {
+	case "":
+		printCommand(args)
+	case "install", "upgrade", "uninstall":
+		clusterCommand(command, args)
+	case "render":
+		renderCommand(args)
+	case "validate":
+		validateCommand(args)
+	case "status":
+		statusCommand(args)
+	case "migrate":
+		migrateCommand(args)
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		log.Fatalf("unknown command %q", command)
+	}
+}
+
+// Print the flux-operator manifests.
+func printCommand(args []string) {
+	flags := flag.NewFlagSet("fluxopctl", flag.ExitOnError)
+	getConfig := installerFlags(flags)
+	crds := flags.Bool("crds", false, "Only output the CRDs, for installing them separately from a namespaced flux-operator.")
+	format := flags.String("format", "yaml", "Output format: yaml, helm (a chart) or kustomize (a base).")
+	outputDir := flags.String("output-dir", "", "The empty or missing directory to write the helm chart or kustomize base to.")
+	flags.Parse(args)
+
+	config := getConfig(!*crds)
+
+	var files []installer.File
+	var err error
+
+	switch *format {
+	case "yaml":
+		if *crds {
+			installer.PrintObjects(installer.NewCRDs(config))
+		} else {
+			installer.DryRun(config)
+		}
+		return
+	case "helm":
+		files, err = installer.HelmChartFiles(config)
+	case "kustomize":
+		files, err = installer.KustomizeFiles(config)
+	default:
+		log.Fatalf("unknown output format %q", *format)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *crds {
+		log.Fatalf("-crds is only supported with -format yaml")
+	}
+
+	if *outputDir == "" {
+		log.Fatalf("-output-dir is required with -format %s", *format)
+	}
+
+	if err := installer.WriteFiles(*outputDir, files); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Install, upgrade or uninstall flux-operator in the cluster.
+func clusterCommand(command string, args []string) {
+	flags := flag.NewFlagSet(fmt.Sprintf("fluxopctl %s", command), flag.ExitOnError)
+	getConfig := installerFlags(flags)
+	getClient := clusterFlags(flags, true)
+	force := false
+	if command == "uninstall" {
+		flags.BoolVar(&force, "force", false, "Uninstall even if Fluxes exist, deleting them along with the CRDs.")
+	}
+	flags.Parse(args)
+
+	// Uninstalling only deletes objects by name, so it does not need the image.
+	config := getConfig(command != "uninstall")
+	client := getClient()
+
+	var err error
+	switch command {
+	case "install":
+		err = installer.Install(client, config)
+	case "upgrade":
+		err = installer.Upgrade(client, config)
+	case "uninstall":
+		err = installer.Uninstall(client, config, force)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Print the objects flux-operator would create for the Fluxes in files.
+func renderCommand(args []string) {
+	flags := flag.NewFlagSet("fluxopctl render", flag.ExitOnError)
+	files := stringsFlag{}
+	flags.Var(&files, "f", "File containing Fluxes to render, - for stdin. May be repeated.")
+	overrides := stringsFlag{}
+	flags.Var(&overrides, "set", "Override an operator default as KEY=VALUE, e.g. FLUX_VERSION=1.8.1. May be repeated.")
+	configFile := flags.String("config", "", "File containing the operator config ConfigMap to take operator defaults from.")
+	flags.Parse(args)
+
+	if len(files) == 0 {
+		log.Fatal("-f is required")
+	}
+
+	data := map[string]string{}
+	if *configFile != "" {
+		configMap := corev1.ConfigMap{}
+		contents, err := ioutil.ReadFile(*configFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := yaml.Unmarshal(contents, &configMap); err != nil {
+			log.Fatalf("%s: %v", *configFile, err)
+		}
+
+		if configMap.Data != nil {
+			data = configMap.Data
+		}
+	}
+
+	for _, override := range overrides {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 {
+			log.Fatalf("-set %s: expected KEY=VALUE", override)
+		}
+		data[parts[0]] = parts[1]
+	}
+
+	operatorConfig, err := config.Defaults().Override(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.Set(&operatorConfig)
+
+	fluxes := []*v1alpha1.Flux{}
+	for _, file := range files {
+		reader := os.Stdin
+		if file != "-" {
+			reader, err = os.Open(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		parsed, err := render.ParseFluxes(reader)
+		reader.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", file, err)
+		}
+
+		fluxes = append(fluxes, parsed...)
+	}
+
+	objects, err := render.Objects(fluxes)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	name := flag.String("name", "flux-operator", "Prefix to use for any resources created.")
-	namespace := flag.String("namespace", "default", "Namespace to deploy flux-operator into.")
-	watchNamespace := flag.String("watch-namespace", "", "If set, specifies the namespace to watch for Flux CRDs, if not set flux-operator watches all namespaces.")
-	cluster := flag.Bool("cluster", false, "If set, creates a Cluster scoped CRD for Flux instead of Namespaced.")
-	serviceAccount := flag.String("service-account", "", "Service account to use.")
-	clusterRole := flag.String("cluster-role", "", "Cluster role to assign.")
-	disableRbac := flag.Bool("disable-rbac", false, "Disable setting any RBAC settings.")
-	gitSecret := flag.String("git-secret", "", "Default git secret name to use.")
-	fluxOperatorImage := flag.String("flux-operator-image", fluxOperatorImageDefault, "Flux operator image name.")
-	fluxOperatorVersion := flag.String("flux-operator-version", fluxOperatorVersionDefault, "Flux operator version name.")
-	fluxImage := flag.String("flux-image", utils.FluxImage, "Flux image name.")
-	fluxVersion := flag.String("flux-version", utils.FluxVersion, "Flux version name.")
-	helmOperatorImage := flag.String("helm-operator-image", utils.HelmOperatorImage, "Helm-operator image name.")
-	helmOperatorVersion := flag.String("helm-operator-version", utils.HelmOperatorVersion, "Helm-operator image version.")
-	memcachedImage := flag.String("memcached-image", utils.MemcachedImage, "Memcached image name.")
-	memcachedVersion := flag.String("memcached-version", utils.MemcachedVersion, "Memcached image version.")
-	tillerImage := flag.String("tiller-image", utils.TillerImage, "Tiller image name.")
-	tillerVersion := flag.String("tiller-version", utils.TillerVersion, "Tiller image version.")
-	disableRoles := flag.Bool("disable-roles", false, "Do not allow flux-operator to assign roles.")
-	disableClusterRoles := flag.Bool("disable-cluster-roles", false, "Do not allow flux-operator to assign cluster roles.")
-
-	flag.Parse()
-
-	installer.DryRun(installer.FluxOperatorConfig{
-		Name:                *name,
-		Namespace:           *namespace,
-		Cluster:             *cluster,
-		ServiceAccount:      *serviceAccount,
-		ClusterRole:         *clusterRole,
-		DisableRBAC:         *disableRbac,
-		FluxNamespace:       *watchNamespace,
-		GitSecret:           *gitSecret,
-		FluxOperatorImage:   *fluxOperatorImage,
-		FluxOperatorVersion: *fluxOperatorVersion,
-		FluxImage:           *fluxImage,
-		FluxVersion:         *fluxVersion,
-		HelmOperatorImage:   *helmOperatorImage,
-		HelmOperatorVersion: *helmOperatorVersion,
-		TillerImage:         *tillerImage,
-		TillerVersion:       *tillerVersion,
-		MemcachedImage:      *memcachedImage,
-		MemcachedVersion:    *memcachedVersion,
-		DisableRoles:        *disableRoles,
-		DisableClusterRoles: *disableClusterRoles,
+	if err := render.Write(os.Stdout, objects); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Validate the Fluxes in files, exiting non-zero if any are invalid.
+func validateCommand(args []string) {
+	flags := flag.NewFlagSet("fluxopctl validate", flag.ExitOnError)
+	output := flags.String("o", "text", "Output format, text or json.")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: fluxopctl validate [flags] FILE... (- for stdin)")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *output != "text" && *output != "json" {
+		log.Fatalf("unknown output format %q", *output)
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	errors := []validation.Error{}
+	for _, file := range flags.Args() {
+		reader := os.Stdin
+		if file != "-" {
+			var err error
+			reader, err = os.Open(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		errors = append(errors, validation.Validate(file, reader)...)
+		reader.Close()
+	}
+
+	if *output == "json" {
+		encoded, err := json.MarshalIndent(errors, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(encoded))
+	} else {
+		for _, err := range errors {
+			fmt.Println(err)
+		}
+	}
+
+	if len(errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+// Summarize the Fluxes in the cluster, or only the Flux named by the argument.
+func statusCommand(args []string) {
+	flags := flag.NewFlagSet("fluxopctl status", flag.ExitOnError)
+	namespace := flags.String("n", "", "Only show the Fluxes in this namespace (default: all namespaces).")
+	output := flags.String("o", "text", "Output format, text or json.")
+	getClient := clusterFlags(flags, false)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: fluxopctl status [flags] [NAME]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *output != "text" && *output != "json" {
+		log.Fatalf("unknown output format %q", *output)
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	summaries, err := getClient().FluxStatuses(*namespace, flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if flags.NArg() == 1 && len(summaries) == 0 {
+		log.Fatalf("Flux %s not found", flags.Arg(0))
+	}
+
+	if *output == "json" {
+		encoded, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(encoded))
+	} else if err := installer.PrintFluxStatuses(os.Stdout, summaries); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Print a Flux equivalent to an existing flux install read from files or the
+// cluster, optionally labeling the install's objects so the operator replaces them.
+func migrateCommand(args []string) {
+	flags := flag.NewFlagSet("fluxopctl migrate", flag.ExitOnError)
+	files := stringsFlag{}
+	flags.Var(&files, "f", "File containing the install's Deployments, Services and ConfigMaps, - for stdin. May be repeated (default: read them from the cluster).")
+	namespace := flags.String("n", "", "The namespace of the install (default: the flux Deployment's namespace in files, default in the cluster).")
+	deployment := flags.String("deployment", "", "The name of the flux Deployment, required if there is more than one.")
+	name := flags.String("name", "", "The name of the Flux (default: the name of the flux Deployment).")
+	cluster := flags.Bool("cluster", false, "Create a Flux for the cluster scoped CRD.")
+	adopt := flags.Bool("adopt", false, "Label the install's Deployments and Services in the cluster as owned by the Flux, so that the operator replaces them once the Flux is applied.")
+	getClient := clusterFlags(flags, false)
+	flags.Parse(args)
+
+	install := &migrate.Install{}
+
+	if len(files) == 0 {
+		if *namespace == "" {
+			*namespace = "default"
+		}
+
+		var err error
+		install, err = getClient().ReadInstall(*namespace)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, file := range files {
+		reader := os.Stdin
+		if file != "-" {
+			var err error
+			reader, err = os.Open(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		err := install.Parse(reader)
+		reader.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", file, err)
+		}
+	}
+
+	result, err := migrate.Migrate(install, migrate.Options{
+		Name:       *name,
+		Namespace:  *namespace,
+		Deployment: *deployment,
+		Cluster:    *cluster,
 	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, warning := range result.Warnings {
+		log.Printf("warning: %s", warning)
+	}
+
+	if *adopt {
+		if err := getClient().Adopt(result.Flux, result.Replaced); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := migrate.Write(os.Stdout, result.Flux); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -52,6 +52,105 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 			Dependencies: []string{
 				"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxSpec", "github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
 		},
+		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxCloud": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Description: "The settings of the fluxcloud instance that delivers flux notifications.",
+					Properties: map[string]spec.Schema{
+						"enabled": {
+							SchemaProps: spec.SchemaProps{
+								Description: "If enabled, a fluxcloud instance will be deployed to deliver slack notifications to a slack channel.",
+								Type:        []string{"boolean"},
+								Format:      "",
+							},
+						},
+						"fluxCloudImage": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Fluxcloud image to use.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"fluxCloudVersion": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Fluxcloud image version to use.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"githubUrl": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Github URL to link commits to in Slack notifications.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"slackUrl": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Slack webhook URL to use (required).",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"slackChannel": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Channel to send slack notifications to (required).",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"slackUser": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Slack username to use when sending slack messages (default: `Flux Deployer`)",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"slackIconEmoji": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Icon emoji to use when sending slack messages (default: `:star-struck:`)",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"matrixUrl": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Slack webhook URL to use (required).",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"matrixRoomId": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Channel to send slack notifications to (required).",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"matrixToken": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Slack username to use when sending slack messages (default: `Flux Deployer`)",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"bodyTemplate": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+						"titleTemplate": {
+							SchemaProps: spec.SchemaProps{
+								Type:   []string{"string"},
+								Format: "",
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{},
+		},
 		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxList": {
 			Schema: spec.Schema{
 				SchemaProps: spec.SchemaProps{
@@ -94,6 +193,156 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 			Dependencies: []string{
 				"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.Flux", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
 		},
+		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxPolicy": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Description: "Restricts what the Fluxes in a set of namespaces may request.",
+					Properties: map[string]spec.Schema{
+						"kind": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"apiVersion": {
+							SchemaProps: spec.SchemaProps{
+								Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"metadata": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+							},
+						},
+						"spec": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxPolicySpec"),
+							},
+						},
+					},
+					Required: []string{"metadata", "spec"},
+				},
+			},
+			Dependencies: []string{
+				"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxPolicySpec", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+		},
+		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxPolicyList": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Properties: map[string]spec.Schema{
+						"kind": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"apiVersion": {
+							SchemaProps: spec.SchemaProps{
+								Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"metadata": {
+							SchemaProps: spec.SchemaProps{
+								Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"),
+							},
+						},
+						"items": {
+							SchemaProps: spec.SchemaProps{
+								Type: []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxPolicy"),
+										},
+									},
+								},
+							},
+						},
+					},
+					Required: []string{"metadata", "items"},
+				},
+			},
+			Dependencies: []string{
+				"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxPolicy", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
+		},
+		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxPolicySpec": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Description: "The restrictions placed on Fluxes by a FluxPolicy.",
+					Properties: map[string]spec.Schema{
+						"namespaceSelector": {
+							SchemaProps: spec.SchemaProps{
+								Description: "The namespaces whose Fluxes the policy applies to (default: all namespaces).",
+								Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+							},
+						},
+						"allowedGitUrls": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Regular expressions that the git URLs of a Flux must match (default: any URL).",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Type:   []string{"string"},
+											Format: "",
+										},
+									},
+								},
+							},
+						},
+						"maxRoleRules": {
+							SchemaProps: spec.SchemaProps{
+								Description: "The maximum number of rules in a Flux's role or cluster role, roles without rules grant full access and are not allowed (default: unlimited).",
+								Type:        []string{"integer"},
+								Format:      "int32",
+							},
+						},
+						"allowClusterRoles": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Whether or not Fluxes may be assigned cluster roles (default: false).",
+								Type:        []string{"boolean"},
+								Format:      "",
+							},
+						},
+						"allowedImages": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Regular expressions that the images of a Flux's containers must match (default: any image).",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Type:   []string{"string"},
+											Format: "",
+										},
+									},
+								},
+							},
+						},
+						"maxFluxes": {
+							SchemaProps: spec.SchemaProps{
+								Description: "The maximum number of Fluxes in each namespace, the oldest Fluxes are allowed (default: unlimited).",
+								Type:        []string{"integer"},
+								Format:      "int32",
+							},
+						},
+						"allowedTargetNamespaces": {
+							SchemaProps: spec.SchemaProps{
+								Description: "The namespaces other than their own that namespaced Fluxes may target or bind roles in (default: none).",
+								Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+							},
+						},
+					},
+				},
+			},
+			Dependencies: []string{
+				"k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+		},
 		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxRole": {
 			Schema: spec.Schema{
 				SchemaProps: spec.SchemaProps{
@@ -125,6 +374,38 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 			Dependencies: []string{
 				"k8s.io/api/rbac/v1.PolicyRule"},
 		},
+		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxRoleRef": {
+			Schema: spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Description: "A reference to an existing Role or ClusterRole to bind to the Flux service account user.",
+					Properties: map[string]spec.Schema{
+						"kind": {
+							SchemaProps: spec.SchemaProps{
+								Description: "The kind of role, `Role` or `ClusterRole` (default: `ClusterRole`).",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"name": {
+							SchemaProps: spec.SchemaProps{
+								Description: "The name of the role (required).",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"namespace": {
+							SchemaProps: spec.SchemaProps{
+								Description: "The namespace to bind the role in, a ClusterRole is bound in every namespace if empty and a Role in the Flux's namespace (default: none).",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+					},
+					Required: []string{"name"},
+				},
+			},
+			Dependencies: []string{},
+		},
 		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxSpec": {
 			Schema: spec.Schema{
 				SchemaProps: spec.SchemaProps{
@@ -179,6 +460,13 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								Format:      "",
 							},
 						},
+						"knownHosts": {
+							SchemaProps: spec.SchemaProps{
+								Description: "The contents of the known_hosts file to mount into Flux and helm-operator.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
 						"fluxImage": {
 							SchemaProps: spec.SchemaProps{
 								Description: "The image to use for flux (default: `quay.io/weaveworks/flux` or `$FLUX_IMAGE`).",
@@ -213,6 +501,33 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								},
 							},
 						},
+						"roleRefs": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Existing roles and cluster roles to bind to the service account (default: none)",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Ref: ref("github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxRoleRef"),
+										},
+									},
+								},
+							},
+						},
+						"targetNamespaces": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Namespaces that flux is restricted to with `--k8s-namespace-whitelist` in addition to its own, the role is also created in each of them (default: none)",
+								Type:        []string{"array"},
+								Items: &spec.SchemaOrArray{
+									Schema: &spec.Schema{
+										SchemaProps: spec.SchemaProps{
+											Type:   []string{"string"},
+											Format: "",
+										},
+									},
+								},
+							},
+						},
 						"role": {
 							SchemaProps: spec.SchemaProps{
 								Description: "A role to add to the service account (default: none)",
@@ -243,12 +558,26 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 								Ref:         ref("github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxCloud"),
 							},
 						},
+						"jaegerEndpoint": {
+							SchemaProps: spec.SchemaProps{
+								Description: "Endpoint that the flux/fluxcloud instance should be configured to send traces to.",
+								Type:        []string{"string"},
+								Format:      "",
+							},
+						},
+						"suspend": {
+							SchemaProps: spec.SchemaProps{
+								Description: "If true, the operator stops reconciling this Flux and leaves its resources untouched (default: false).",
+								Type:        []string{"boolean"},
+								Format:      "",
+							},
+						},
 					},
 					Required: []string{"gitUrl"},
 				},
 			},
 			Dependencies: []string{
-				"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxCloud", "github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxRole", "github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.HelmOperator", "github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.Tiller", "k8s.io/api/core/v1.ResourceRequirements"},
+				"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxCloud", "github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxRole", "github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxRoleRef", "github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.HelmOperator", "github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.Tiller", "k8s.io/api/core/v1.ResourceRequirements"},
 		},
 		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.HelmOperator": {
 			Schema: spec.Schema{
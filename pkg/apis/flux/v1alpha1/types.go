@@ -52,6 +52,11 @@ type FluxSpec struct {
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 	// A map of args to pass to flux without `--` prepended.
 	Args map[string]string `json:"args,omitempty"`
+	// Existing roles and cluster roles to bind to the service account (default: none)
+	RoleRefs []FluxRoleRef `json:"roleRefs,omitempty"`
+	// Namespaces that flux is restricted to with `--k8s-namespace-whitelist` in
+	// addition to its own, the role is also created in each of them (default: none)
+	TargetNamespaces []string `json:"targetNamespaces,omitempty"`
 	// A role to add to the service account (default: none)
 	Role FluxRole `json:"role,omitempty"`
 	// A cluster role to add to the service account (default: none)
@@ -64,8 +69,13 @@ type FluxSpec struct {
 	FluxCloud FluxCloud `json:"fluxCloud,omitempty"`
 	// Endpoint that the flux/fluxcloud instance should be configured to send traces to.
 	JaegerEndpoint string `json:"jaegerEndpoint,omitempty"`
+	// If true, the operator stops reconciling this Flux and leaves its resources
+	// untouched (default: false).
+	Suspend bool `json:"suspend,omitempty"`
 }
 
+// The settings of the fluxcloud instance that delivers flux notifications.
+// +k8s:openapi-gen=true
 type FluxCloud struct {
 	// If enabled, a fluxcloud instance will be deployed to deliver slack notifications
 	// to a slack channel.
@@ -74,8 +84,8 @@ type FluxCloud struct {
 	FluxCloudImage string `json:"fluxCloudImage,omitempty"`
 	// Fluxcloud image version to use.
 	FluxCloudVersion string `json:"fluxCloudVersion,omitempty"`
-	// Github URL to use in Slack notifications (required).
-	GithubURL string `json:"githubUrl"`
+	// Github URL to link commits to in Slack notifications.
+	GithubURL string `json:"githubUrl,omitempty"`
 	// Slack webhook URL to use (required).
 	SlackURL string `json:"slackUrl,omitempty"`
 	// Channel to send slack notifications to (required).
@@ -104,6 +114,19 @@ type FluxRole struct {
 	Rules []rbacv1.PolicyRule `json:"rules,omitempty"`
 }
 
+// A reference to an existing Role or ClusterRole to bind to the Flux service account user.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+type FluxRoleRef struct {
+	// The kind of role, `Role` or `ClusterRole` (default: `ClusterRole`).
+	Kind string `json:"kind,omitempty"`
+	// The name of the role (required).
+	Name string `json:"name"`
+	// The namespace to bind the role in, a ClusterRole is bound in every namespace
+	// if empty and a Role in the Flux's namespace (default: none).
+	Namespace string `json:"namespace,omitempty"`
+}
+
 // Settings for operating Tiller alongside Flux.
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +k8s:openapi-gen=true
@@ -141,5 +164,87 @@ type HelmOperator struct {
 }
 
 type FluxStatus struct {
-	// Fill me
+	// True if the operator is not reconciling this Flux.
+	Suspended bool `json:"suspended,omitempty"`
+	// The time at which reconciling this Flux was suspended.
+	SuspendedSince *metav1.Time `json:"suspendedSince,omitempty"`
+	// In plan mode, the changes a reconcile would make to the Flux's resources.
+	Plan string `json:"plan,omitempty"`
+	// The reasons the Flux is not allowed by the FluxPolicies that apply to it, the
+	// Flux is not reconciled until they are resolved.
+	PolicyViolations []string `json:"policyViolations,omitempty"`
+	// The roles referenced in `roleRefs` that do not exist.
+	MissingRoles []string `json:"missingRoles,omitempty"`
+	// The error that stopped the last reconcile, cleared once a reconcile succeeds.
+	LastError string `json:"lastError,omitempty"`
+	// The public SSH key flux uses to access git, add it to the git repository as
+	// a deploy key.
+	DeployKey string `json:"deployKey,omitempty"`
+	// True if the last reconcile succeeded and every Deployment of the Flux has
+	// all of its replicas available.
+	Ready bool `json:"ready"`
+	// The flux version the Flux runs.
+	FluxVersion string `json:"fluxVersion,omitempty"`
+	// The outcome of the last reconcile, with the objects that failed and why.
+	Conditions []FluxCondition `json:"conditions,omitempty"`
+}
+
+// The condition types of a Flux.
+const (
+	// Whether the last reconcile created or updated every object of the Flux.
+	FluxApplied = "Applied"
+	// Whether the last reconcile deleted every object the Flux no longer needs.
+	FluxGarbageCollected = "GarbageCollected"
+)
+
+// The state of one aspect of a Flux.
+type FluxCondition struct {
+	// The type of the condition, `Applied` or `GarbageCollected`.
+	Type string `json:"type"`
+	// `True`, `False` or `Unknown`.
+	Status corev1.ConditionStatus `json:"status"`
+	// Why the condition has its status, in CamelCase.
+	Reason string `json:"reason,omitempty"`
+	// A human readable explanation, listing the objects that failed and why.
+	Message string `json:"message,omitempty"`
+	// When the condition last changed status.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+type FluxPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []FluxPolicy `json:"items"`
+}
+
+// Restricts what the Fluxes in a set of namespaces may request.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+type FluxPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              FluxPolicySpec `json:"spec"`
+}
+
+// The restrictions placed on Fluxes by a FluxPolicy.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+type FluxPolicySpec struct {
+	// The namespaces whose Fluxes the policy applies to (default: all namespaces).
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Regular expressions that the git URLs of a Flux must match (default: any URL).
+	AllowedGitUrls []string `json:"allowedGitUrls,omitempty"`
+	// The maximum number of rules in a Flux's role or cluster role, roles without
+	// rules grant full access and are not allowed (default: unlimited).
+	MaxRoleRules *int32 `json:"maxRoleRules,omitempty"`
+	// Whether or not Fluxes may be assigned cluster roles (default: false).
+	AllowClusterRoles bool `json:"allowClusterRoles,omitempty"`
+	// Regular expressions that the images of a Flux's containers must match (default: any image).
+	AllowedImages []string `json:"allowedImages,omitempty"`
+	// The maximum number of Fluxes in each namespace, the oldest Fluxes are allowed (default: unlimited).
+	MaxFluxes *int32 `json:"maxFluxes,omitempty"`
+	// The namespaces other than their own that namespaced Fluxes may target or bind roles in (default: none).
+	AllowedTargetNamespaces *metav1.LabelSelector `json:"allowedTargetNamespaces,omitempty"`
 }
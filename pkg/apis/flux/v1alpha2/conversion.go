@@ -0,0 +1,211 @@
+package v1alpha2
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Every v1alpha1 field has exactly one v1alpha2 field, so converting in either
+// direction and back returns the original Flux.
+
+// Convert a v1alpha1 Flux to v1alpha2.
+func ConvertFromV1alpha1(in *v1alpha1.Flux) *Flux {
+	in = in.DeepCopy()
+	spec := in.Spec
+
+	out := &Flux{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Flux",
+			APIVersion: SchemeGroupVersion.String(),
+		},
+		ObjectMeta: in.ObjectMeta,
+		Spec: FluxSpec{
+			Namespace: spec.Namespace,
+			Git: Git{
+				URL:          spec.GitUrl,
+				Branch:       spec.GitBranch,
+				Path:         spec.GitPath,
+				PollInterval: spec.GitPollInterval,
+				Secret:       spec.GitSecret,
+				KnownHosts:   spec.KnownHosts,
+			},
+			SyncInterval:     spec.SyncInterval,
+			Image:            spec.FluxImage,
+			Version:          spec.FluxVersion,
+			Resources:        spec.Resources,
+			Args:             spec.Args,
+			TargetNamespaces: spec.TargetNamespaces,
+			Role:             FluxRole(spec.Role),
+			ClusterRole:      FluxRole(spec.ClusterRole),
+			Tiller: Tiller{
+				Enabled: spec.Tiller.Enabled,
+				Image:   spec.Tiller.TillerImage,
+				Version: spec.Tiller.TillerVersion,
+			},
+			HelmOperator: HelmOperator{
+				Enabled:   spec.HelmOperator.Enabled,
+				Image:     spec.HelmOperator.HelmOperatorImage,
+				Version:   spec.HelmOperator.HelmOperatorVersion,
+				Resources: spec.HelmOperator.Resources,
+				Git: HelmOperatorGit{
+					URL:          spec.HelmOperator.GitUrl,
+					Path:         spec.HelmOperator.ChartPath,
+					PollInterval: spec.HelmOperator.GitPollInterval,
+				},
+				ChartsSyncInterval: spec.HelmOperator.ChartsSyncInterval,
+			},
+			FluxCloud: FluxCloud{
+				Enabled:   spec.FluxCloud.Enabled,
+				Image:     spec.FluxCloud.FluxCloudImage,
+				Version:   spec.FluxCloud.FluxCloudVersion,
+				GithubURL: spec.FluxCloud.GithubURL,
+				Slack: Slack{
+					URL:       spec.FluxCloud.SlackURL,
+					Channel:   spec.FluxCloud.SlackChannel,
+					Username:  spec.FluxCloud.SlackUsername,
+					IconEmoji: spec.FluxCloud.SlackIconEmoji,
+				},
+				Matrix: Matrix{
+					URL:    spec.FluxCloud.MatrixURL,
+					RoomID: spec.FluxCloud.MatrixRoomId,
+					Token:  spec.FluxCloud.MatrixToken,
+				},
+				BodyTemplate:  spec.FluxCloud.BodyTemplate,
+				TitleTemplate: spec.FluxCloud.TitleTemplate,
+			},
+			JaegerEndpoint: spec.JaegerEndpoint,
+			Suspend:        spec.Suspend,
+		},
+		Status: convertStatusFromV1alpha1(in.Status),
+	}
+
+	if spec.RoleRefs != nil {
+		out.Spec.RoleRefs = []FluxRoleRef{}
+		for _, roleRef := range spec.RoleRefs {
+			out.Spec.RoleRefs = append(out.Spec.RoleRefs, FluxRoleRef(roleRef))
+		}
+	}
+
+	return out
+}
+
+// Convert a v1alpha2 Flux to v1alpha1.
+func ConvertToV1alpha1(in *Flux) *v1alpha1.Flux {
+	in = in.DeepCopy()
+	spec := in.Spec
+
+	out := &v1alpha1.Flux{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Flux",
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: in.ObjectMeta,
+		Spec: v1alpha1.FluxSpec{
+			Namespace:        spec.Namespace,
+			GitUrl:           spec.Git.URL,
+			GitBranch:        spec.Git.Branch,
+			GitPath:          spec.Git.Path,
+			GitPollInterval:  spec.Git.PollInterval,
+			GitSecret:        spec.Git.Secret,
+			KnownHosts:       spec.Git.KnownHosts,
+			SyncInterval:     spec.SyncInterval,
+			FluxImage:        spec.Image,
+			FluxVersion:      spec.Version,
+			Resources:        spec.Resources,
+			Args:             spec.Args,
+			TargetNamespaces: spec.TargetNamespaces,
+			Role:             v1alpha1.FluxRole(spec.Role),
+			ClusterRole:      v1alpha1.FluxRole(spec.ClusterRole),
+			Tiller: v1alpha1.Tiller{
+				Enabled:       spec.Tiller.Enabled,
+				TillerImage:   spec.Tiller.Image,
+				TillerVersion: spec.Tiller.Version,
+			},
+			HelmOperator: v1alpha1.HelmOperator{
+				Enabled:             spec.HelmOperator.Enabled,
+				HelmOperatorImage:   spec.HelmOperator.Image,
+				HelmOperatorVersion: spec.HelmOperator.Version,
+				Resources:           spec.HelmOperator.Resources,
+				ChartPath:           spec.HelmOperator.Git.Path,
+				GitPollInterval:     spec.HelmOperator.Git.PollInterval,
+				ChartsSyncInterval:  spec.HelmOperator.ChartsSyncInterval,
+				GitUrl:              spec.HelmOperator.Git.URL,
+			},
+			FluxCloud: v1alpha1.FluxCloud{
+				Enabled:          spec.FluxCloud.Enabled,
+				FluxCloudImage:   spec.FluxCloud.Image,
+				FluxCloudVersion: spec.FluxCloud.Version,
+				GithubURL:        spec.FluxCloud.GithubURL,
+				SlackURL:         spec.FluxCloud.Slack.URL,
+				SlackChannel:     spec.FluxCloud.Slack.Channel,
+				SlackUsername:    spec.FluxCloud.Slack.Username,
+				SlackIconEmoji:   spec.FluxCloud.Slack.IconEmoji,
+				MatrixURL:        spec.FluxCloud.Matrix.URL,
+				MatrixRoomId:     spec.FluxCloud.Matrix.RoomID,
+				MatrixToken:      spec.FluxCloud.Matrix.Token,
+				BodyTemplate:     spec.FluxCloud.BodyTemplate,
+				TitleTemplate:    spec.FluxCloud.TitleTemplate,
+			},
+			JaegerEndpoint: spec.JaegerEndpoint,
+			Suspend:        spec.Suspend,
+		},
+		Status: convertStatusToV1alpha1(in.Status),
+	}
+
+	if spec.RoleRefs != nil {
+		out.Spec.RoleRefs = []v1alpha1.FluxRoleRef{}
+		for _, roleRef := range spec.RoleRefs {
+			out.Spec.RoleRefs = append(out.Spec.RoleRefs, v1alpha1.FluxRoleRef(roleRef))
+		}
+	}
+
+	return out
+}
+
+// Convert a v1alpha1 FluxStatus to v1alpha2.
+func convertStatusFromV1alpha1(in v1alpha1.FluxStatus) FluxStatus {
+	out := FluxStatus{
+		Suspended:        in.Suspended,
+		SuspendedSince:   in.SuspendedSince,
+		Plan:             in.Plan,
+		PolicyViolations: in.PolicyViolations,
+		MissingRoles:     in.MissingRoles,
+		LastError:        in.LastError,
+		DeployKey:        in.DeployKey,
+		Ready:            in.Ready,
+		FluxVersion:      in.FluxVersion,
+	}
+
+	if in.Conditions != nil {
+		out.Conditions = []FluxCondition{}
+		for _, condition := range in.Conditions {
+			out.Conditions = append(out.Conditions, FluxCondition(condition))
+		}
+	}
+
+	return out
+}
+
+// Convert a v1alpha2 FluxStatus to v1alpha1.
+func convertStatusToV1alpha1(in FluxStatus) v1alpha1.FluxStatus {
+	out := v1alpha1.FluxStatus{
+		Suspended:        in.Suspended,
+		SuspendedSince:   in.SuspendedSince,
+		Plan:             in.Plan,
+		PolicyViolations: in.PolicyViolations,
+		MissingRoles:     in.MissingRoles,
+		LastError:        in.LastError,
+		DeployKey:        in.DeployKey,
+		Ready:            in.Ready,
+		FluxVersion:      in.FluxVersion,
+	}
+
+	if in.Conditions != nil {
+		out.Conditions = []v1alpha1.FluxCondition{}
+		for _, condition := range in.Conditions {
+			out.Conditions = append(out.Conditions, v1alpha1.FluxCondition(condition))
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,129 @@
+package v1alpha2
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Set every string, bool, slice and map of value whose type is declared in
+// pkgPath to a distinct non-zero value, so that a field that is dropped or
+// swapped by a conversion fails the round trip.
+func fill(value reflect.Value, pkgPath string, counter *int) {
+	switch value.Kind() {
+	case reflect.String:
+		*counter++
+		value.SetString(fmt.Sprintf("value-%d", *counter))
+	case reflect.Bool:
+		value.SetBool(true)
+	case reflect.Slice:
+		value.Set(reflect.MakeSlice(value.Type(), 1, 1))
+		fill(value.Index(0), pkgPath, counter)
+	case reflect.Map:
+		key := reflect.New(value.Type().Key()).Elem()
+		element := reflect.New(value.Type().Elem()).Elem()
+		fill(key, pkgPath, counter)
+		fill(element, pkgPath, counter)
+		value.Set(reflect.MakeMap(value.Type()))
+		value.SetMapIndex(key, element)
+	case reflect.Struct:
+		if value.Type().PkgPath() != pkgPath {
+			return
+		}
+
+		for index := 0; index < value.NumField(); index++ {
+			if value.Type().Field(index).Anonymous {
+				continue
+			}
+			fill(value.Field(index), pkgPath, counter)
+		}
+	}
+}
+
+var (
+	testResources = &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+	}
+	testRules = []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+	}
+	testTime = metav1.NewTime(time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC))
+)
+
+func filledV1alpha1() *v1alpha1.Flux {
+	cr := &v1alpha1.Flux{}
+	counter := 0
+	fill(reflect.ValueOf(cr).Elem(), reflect.TypeOf(*cr).PkgPath(), &counter)
+
+	cr.TypeMeta = metav1.TypeMeta{Kind: "Flux", APIVersion: "flux.codesink.net/v1alpha1"}
+	cr.ObjectMeta = metav1.ObjectMeta{Name: "example", Namespace: "default", Labels: map[string]string{"team": "a"}}
+	cr.Spec.Resources = testResources.DeepCopy()
+	cr.Spec.HelmOperator.Resources = testResources.DeepCopy()
+	cr.Spec.Role.Rules = testRules
+	cr.Spec.ClusterRole.Rules = testRules
+	cr.Status.SuspendedSince = &testTime
+	cr.Status.Conditions[0].LastTransitionTime = testTime
+	return cr
+}
+
+func filledV1alpha2() *Flux {
+	cr := &Flux{}
+	counter := 0
+	fill(reflect.ValueOf(cr).Elem(), reflect.TypeOf(*cr).PkgPath(), &counter)
+
+	cr.TypeMeta = metav1.TypeMeta{Kind: "Flux", APIVersion: "flux.codesink.net/v1alpha2"}
+	cr.ObjectMeta = metav1.ObjectMeta{Name: "example", Namespace: "default", Labels: map[string]string{"team": "a"}}
+	cr.Spec.Resources = testResources.DeepCopy()
+	cr.Spec.HelmOperator.Resources = testResources.DeepCopy()
+	cr.Spec.Role.Rules = testRules
+	cr.Spec.ClusterRole.Rules = testRules
+	cr.Status.SuspendedSince = &testTime
+	cr.Status.Conditions[0].LastTransitionTime = testTime
+	return cr
+}
+
+func TestConvertV1alpha1RoundTrip(t *testing.T) {
+	original := filledV1alpha1()
+	converted := ConvertFromV1alpha1(original)
+	assert.Equal(t, "flux.codesink.net/v1alpha2", converted.APIVersion)
+	assert.Equal(t, original.Spec.GitUrl, converted.Spec.Git.URL)
+	assert.Equal(t, original.Spec.FluxCloud.SlackUsername, converted.Spec.FluxCloud.Slack.Username)
+	assert.Equal(t, original.Spec.HelmOperator.GitUrl, converted.Spec.HelmOperator.Git.URL)
+
+	assert.Equal(t, original, ConvertToV1alpha1(converted))
+}
+
+func TestConvertV1alpha2RoundTrip(t *testing.T) {
+	original := filledV1alpha2()
+	converted := ConvertToV1alpha1(original)
+	assert.Equal(t, "flux.codesink.net/v1alpha1", converted.APIVersion)
+	assert.Equal(t, original.Spec.Git.Branch, converted.Spec.GitBranch)
+	assert.Equal(t, original.Spec.FluxCloud.Matrix.RoomID, converted.Spec.FluxCloud.MatrixRoomId)
+
+	assert.Equal(t, original, ConvertFromV1alpha1(converted))
+}
+
+func TestConvertEmpty(t *testing.T) {
+	original := &v1alpha1.Flux{
+		TypeMeta: metav1.TypeMeta{Kind: "Flux", APIVersion: "flux.codesink.net/v1alpha1"},
+		Spec:     v1alpha1.FluxSpec{GitUrl: "git@github.com:example/repo"},
+	}
+
+	converted := ConvertFromV1alpha1(original)
+	assert.Nil(t, converted.Spec.RoleRefs)
+	assert.Equal(t, original, ConvertToV1alpha1(converted))
+
+	encoded, err := json.Marshal(converted)
+	assert.Nil(t, err)
+	assert.Contains(t, string(encoded), `"git":{"url":"git@github.com:example/repo"}`)
+}
@@ -0,0 +1,3 @@
+// +k8s:deepcopy-gen=package
+// +groupName=flux.codesink.net
+package v1alpha2
@@ -0,0 +1,237 @@
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+type FluxList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []Flux `json:"items"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+type Flux struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              FluxSpec   `json:"spec"`
+	Status            FluxStatus `json:"status,omitempty"`
+}
+
+// Settings for operating Flux
+// +k8s:openapi-gen=true
+type FluxSpec struct {
+	// Namespace to deploy Flux and Tiller into.
+	Namespace string `json:"namespace,omitempty"`
+	// The git repository to sync.
+	Git Git `json:"git"`
+	// The frequency with which to apply the git repository (default: `5m0s`).
+	SyncInterval string `json:"syncInterval,omitempty"`
+	// The image to use for flux (default: `quay.io/weaveworks/flux` or `$FLUX_IMAGE`).
+	Image string `json:"image,omitempty"`
+	// The version to use for flux (default: `1.4.0` or `$FLUX_VERSION`).
+	Version string `json:"version,omitempty"`
+	// Resource limits to apply to Flux.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// A map of args to pass to flux without `--` prepended.
+	Args map[string]string `json:"args,omitempty"`
+	// Existing roles and cluster roles to bind to the service account (default: none)
+	RoleRefs []FluxRoleRef `json:"roleRefs,omitempty"`
+	// Namespaces that flux is restricted to with `--k8s-namespace-whitelist` in
+	// addition to its own, the role is also created in each of them (default: none)
+	TargetNamespaces []string `json:"targetNamespaces,omitempty"`
+	// A role to add to the service account (default: none)
+	Role FluxRole `json:"role,omitempty"`
+	// A cluster role to add to the service account (default: none)
+	ClusterRole FluxRole `json:"clusterRole,omitempty"`
+	// The tiller settings.
+	Tiller Tiller `json:"tiller,omitempty"`
+	// The Helm Operator settings.
+	HelmOperator HelmOperator `json:"helmOperator,omitempty"`
+	// The Fluxcloud settings
+	FluxCloud FluxCloud `json:"fluxCloud,omitempty"`
+	// Endpoint that the flux/fluxcloud instance should be configured to send traces to.
+	JaegerEndpoint string `json:"jaegerEndpoint,omitempty"`
+	// If true, the operator stops reconciling this Flux and leaves its resources
+	// untouched (default: false).
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// The git repository that Flux syncs.
+// +k8s:openapi-gen=true
+type Git struct {
+	// The URL to the Git repository (required).
+	URL string `json:"url"`
+	// The git branch to use (default: `master`).
+	Branch string `json:"branch,omitempty"`
+	// The path with in the git repository to look for YAML in (default: `.`)
+	Path string `json:"path,omitempty"`
+	// The frequency with which to fetch the git repository (default: `5m0s`).
+	PollInterval string `json:"pollInterval,omitempty"`
+	// The Kubernetes secret to use for cloning, if it does not exist it will
+	// be generated (default: `flux-$name-git-deploy` or `$GIT_SECRET_NAME`).
+	Secret string `json:"secret,omitempty"`
+	// The contents of the known_hosts file to mount into Flux and helm-operator.
+	KnownHosts string `json:"knownHosts,omitempty"`
+}
+
+// Settings for operating Tiller alongside Flux.
+// +k8s:openapi-gen=true
+type Tiller struct {
+	// Whether or not to deploy a tiller instance in the same namespace (default: false).
+	Enabled bool `json:"enabled,omitempty"`
+	// The image to use with tiller (default: `gcr.io/kubernetes-helm/tiller` or `$TILLER_IMAGE`).
+	Image string `json:"image,omitempty"`
+	// The image version to use with tiller (default: `v2.9.1` or `$TILLER_VERSION`).
+	Version string `json:"version,omitempty"`
+}
+
+// Settings for operating Helm Operator alongside Flux.
+// +k8s:openapi-gen=true
+type HelmOperator struct {
+	// Whether or not to deploy a helm-operator instance in the same namespace (default: false).
+	Enabled bool `json:"enabled,omitempty"`
+	// The image to use with helm-operator (default: `quay.io/weaveworks/helm-operator` or `$HELM_OPERATOR_IMAGE`).
+	Image string `json:"image,omitempty"`
+	// The image version to use with helm-operator (default: `master-a61c1d5` or `$HELM_OPERATOR_VERSION`).
+	Version string `json:"version,omitempty"`
+	// Resource limits to apply to helm-operator.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// The git repository to sync charts from, unset settings use the Flux's `git`.
+	Git HelmOperatorGit `json:"git,omitempty"`
+	// The frequency with which to sync the charts (default: the Flux's `syncInterval`, or, if not set, `3m0s`).
+	ChartsSyncInterval string `json:"chartsSyncInterval,omitempty"`
+}
+
+// The git repository that helm-operator syncs charts from.
+// +k8s:openapi-gen=true
+type HelmOperatorGit struct {
+	// The URL to the Git repository (default: the Flux's `git.url`).
+	URL string `json:"url,omitempty"`
+	// The path with in the git repository to look for charts in (default: `.`).
+	Path string `json:"path,omitempty"`
+	// The frequency with which to fetch the git repository (default: the Flux's `git.pollInterval` or, if not set, `5m0s`).
+	PollInterval string `json:"pollInterval,omitempty"`
+}
+
+// Settings for deploying fluxcloud to send notifications of Flux's changes.
+// +k8s:openapi-gen=true
+type FluxCloud struct {
+	// If enabled, a fluxcloud instance will be deployed to deliver notifications
+	// to Slack or Matrix.
+	Enabled bool `json:"enabled,omitempty"`
+	// Fluxcloud image to use.
+	Image string `json:"image,omitempty"`
+	// Fluxcloud image version to use.
+	Version string `json:"version,omitempty"`
+	// Github URL to link commits to in notifications.
+	GithubURL string `json:"githubUrl,omitempty"`
+	// Send notifications to Slack.
+	Slack Slack `json:"slack,omitempty"`
+	// Send notifications to Matrix instead of Slack.
+	Matrix Matrix `json:"matrix,omitempty"`
+	// The Go template of notification bodies.
+	BodyTemplate string `json:"bodyTemplate,omitempty"`
+	// The Go template of notification titles.
+	TitleTemplate string `json:"titleTemplate,omitempty"`
+}
+
+// Fluxcloud's Slack settings.
+// +k8s:openapi-gen=true
+type Slack struct {
+	// Slack webhook URL to use (required for Slack).
+	URL string `json:"url,omitempty"`
+	// Channel to send slack notifications to (required for Slack).
+	Channel string `json:"channel,omitempty"`
+	// Slack username to use when sending slack messages (default: `Flux Deployer`)
+	Username string `json:"username,omitempty"`
+	// Icon emoji to use when sending slack messages (default: `:star-struck:`)
+	IconEmoji string `json:"iconEmoji,omitempty"`
+}
+
+// Fluxcloud's Matrix settings.
+// +k8s:openapi-gen=true
+type Matrix struct {
+	// Matrix homeserver URL to use, if set notifications are sent to Matrix.
+	URL string `json:"url,omitempty"`
+	// Room to send matrix notifications to (required for Matrix).
+	RoomID string `json:"roomId,omitempty"`
+	// Access token to send matrix notifications with (required for Matrix).
+	Token string `json:"token,omitempty"`
+}
+
+// Represents a Role or ClusterRole for the Flux service account user.
+// +k8s:openapi-gen=true
+type FluxRole struct {
+	// If enabled, a role will be assigned to the service account (default: false)
+	Enabled bool `json:"enabled,omitempty"`
+	// the list of rbac rules to use (default: full access).
+	Rules []rbacv1.PolicyRule `json:"rules,omitempty"`
+}
+
+// A reference to an existing Role or ClusterRole to bind to the Flux service account user.
+// +k8s:openapi-gen=true
+type FluxRoleRef struct {
+	// The kind of role, `Role` or `ClusterRole` (default: `ClusterRole`).
+	Kind string `json:"kind,omitempty"`
+	// The name of the role (required).
+	Name string `json:"name"`
+	// The namespace to bind the role in, a ClusterRole is bound in every namespace
+	// if empty and a Role in the Flux's namespace (default: none).
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// The observed state of a Flux.
+// +k8s:openapi-gen=true
+type FluxStatus struct {
+	// True if the operator is not reconciling this Flux.
+	Suspended bool `json:"suspended,omitempty"`
+	// The time at which reconciling this Flux was suspended.
+	SuspendedSince *metav1.Time `json:"suspendedSince,omitempty"`
+	// In plan mode, the changes a reconcile would make to the Flux's resources.
+	Plan string `json:"plan,omitempty"`
+	// The reasons the Flux is not allowed by the FluxPolicies that apply to it, the
+	// Flux is not reconciled until they are resolved.
+	PolicyViolations []string `json:"policyViolations,omitempty"`
+	// The roles referenced in `roleRefs` that do not exist.
+	MissingRoles []string `json:"missingRoles,omitempty"`
+	// The error that stopped the last reconcile, cleared once a reconcile succeeds.
+	LastError string `json:"lastError,omitempty"`
+	// The public SSH key flux uses to access git, add it to the git repository as
+	// a deploy key.
+	DeployKey string `json:"deployKey,omitempty"`
+	// True if the last reconcile succeeded and every Deployment of the Flux has
+	// all of its replicas available.
+	Ready bool `json:"ready"`
+	// The flux version the Flux runs.
+	FluxVersion string `json:"fluxVersion,omitempty"`
+	// The outcome of the last reconcile, with the objects that failed and why.
+	Conditions []FluxCondition `json:"conditions,omitempty"`
+}
+
+// The condition types of a Flux.
+const (
+	// Whether the last reconcile created or updated every object of the Flux.
+	FluxApplied = "Applied"
+	// Whether the last reconcile deleted every object the Flux no longer needs.
+	FluxGarbageCollected = "GarbageCollected"
+)
+
+// The state of one aspect of a Flux.
+type FluxCondition struct {
+	// The type of the condition, `Applied` or `GarbageCollected`.
+	Type string `json:"type"`
+	// `True`, `False` or `Unknown`.
+	Status corev1.ConditionStatus `json:"status"`
+	// Why the condition has its status, in CamelCase.
+	Reason string `json:"reason,omitempty"`
+	// A human readable explanation, listing the objects that failed and why.
+	Message string `json:"message,omitempty"`
+	// When the condition last changed status.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
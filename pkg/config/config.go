@@ -0,0 +1,354 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+)
+
+// Operator wide defaults for Fluxes. Each setting is read from the environment
+// variable of the same name as its key and can be overridden at runtime by the
+// operator config ConfigMap.
+type Config struct {
+	// GIT_SECRET_NAME: the default git secret name (default: `flux-git-<name>-deploy`).
+	GitSecretName string
+	// KNOWN_HOSTS_CONFIGMAP: the default ConfigMap containing SSH known hosts.
+	KnownHostsConfigMap string
+	// FLUX_IMAGE: the default flux image.
+	FluxImage string
+	// FLUX_VERSION: the default flux version.
+	FluxVersion string
+	// HELM_OPERATOR_IMAGE: the default helm-operator image.
+	HelmOperatorImage string
+	// HELM_OPERATOR_VERSION: the default helm-operator version.
+	HelmOperatorVersion string
+	// MEMCACHED_IMAGE: the default memcached image.
+	MemcachedImage string
+	// MEMCACHED_VERSION: the default memcached version.
+	MemcachedVersion string
+	// TILLER_IMAGE: the default tiller image.
+	TillerImage string
+	// TILLER_VERSION: the default tiller version.
+	TillerVersion string
+	// FLUXCLOUD_IMAGE: if set, the fluxcloud image to use for every Flux.
+	FluxcloudImage string
+	// FLUXCLOUD_VERSION: if set, the fluxcloud version to use for every Flux.
+	FluxcloudVersion string
+	// DISABLE_ROLES: prevent Fluxes from being assigned roles.
+	DisableRoles bool
+	// DISABLE_CLUSTER_ROLES: prevent Fluxes from being assigned cluster roles.
+	DisableClusterRoles bool
+	// PLAN_MODE: only report the changes to each Flux instead of applying them.
+	PlanMode bool
+	// PROPAGATE_LABELS: a comma separated list of the labels of a Flux to copy to
+	// its objects and pods, a trailing `*` matches every label with that prefix.
+	PropagateLabels string
+	// PROPAGATE_ANNOTATIONS: a comma separated list of the annotations of a Flux to
+	// copy to its objects and pods, a trailing `*` matches every annotation with
+	// that prefix.
+	PropagateAnnotations string
+}
+
+// The string settings by key.
+func (c *Config) strings() map[string]*string {
+	return map[string]*string{
+		"GIT_SECRET_NAME":       &c.GitSecretName,
+		"KNOWN_HOSTS_CONFIGMAP": &c.KnownHostsConfigMap,
+		"FLUX_IMAGE":            &c.FluxImage,
+		"FLUX_VERSION":          &c.FluxVersion,
+		"HELM_OPERATOR_IMAGE":   &c.HelmOperatorImage,
+		"HELM_OPERATOR_VERSION": &c.HelmOperatorVersion,
+		"MEMCACHED_IMAGE":       &c.MemcachedImage,
+		"MEMCACHED_VERSION":     &c.MemcachedVersion,
+		"TILLER_IMAGE":          &c.TillerImage,
+		"TILLER_VERSION":        &c.TillerVersion,
+		"FLUXCLOUD_IMAGE":       &c.FluxcloudImage,
+		"FLUXCLOUD_VERSION":     &c.FluxcloudVersion,
+		"PROPAGATE_LABELS":      &c.PropagateLabels,
+		"PROPAGATE_ANNOTATIONS": &c.PropagateAnnotations,
+	}
+}
+
+// The boolean settings by key.
+func (c *Config) bools() map[string]*bool {
+	return map[string]*bool{
+		"DISABLE_ROLES":         &c.DisableRoles,
+		"DISABLE_CLUSTER_ROLES": &c.DisableClusterRoles,
+		"PLAN_MODE":             &c.PlanMode,
+	}
+}
+
+// Return the keys of every setting, sorted.
+func Keys() []string {
+	config := &Config{}
+
+	keys := []string{}
+	for key := range config.strings() {
+		keys = append(keys, key)
+	}
+	for key := range config.bools() {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Return the settings as a map of key to value, the inverse of Load.
+func (c Config) Data() map[string]string {
+	data := map[string]string{}
+
+	for key, value := range c.strings() {
+		data[key] = *value
+	}
+
+	for key, value := range c.bools() {
+		data[key] = strconv.FormatBool(*value)
+	}
+
+	return data
+}
+
+// Load the config from the environment, using the built in defaults for unset
+// variables.
+func FromEnv() Config {
+	return Config{
+		GitSecretName:        os.Getenv("GIT_SECRET_NAME"),
+		KnownHostsConfigMap:  os.Getenv("KNOWN_HOSTS_CONFIGMAP"),
+		FluxImage:            utils.Getenv("FLUX_IMAGE", utils.FluxImage),
+		FluxVersion:          utils.Getenv("FLUX_VERSION", utils.FluxVersion),
+		HelmOperatorImage:    utils.Getenv("HELM_OPERATOR_IMAGE", utils.HelmOperatorImage),
+		HelmOperatorVersion:  utils.Getenv("HELM_OPERATOR_VERSION", utils.HelmOperatorVersion),
+		MemcachedImage:       utils.Getenv("MEMCACHED_IMAGE", utils.MemcachedImage),
+		MemcachedVersion:     utils.Getenv("MEMCACHED_VERSION", utils.MemcachedVersion),
+		TillerImage:          utils.Getenv("TILLER_IMAGE", utils.TillerImage),
+		TillerVersion:        utils.Getenv("TILLER_VERSION", utils.TillerVersion),
+		FluxcloudImage:       os.Getenv("FLUXCLOUD_IMAGE"),
+		FluxcloudVersion:     os.Getenv("FLUXCLOUD_VERSION"),
+		DisableRoles:         utils.BoolEnv("DISABLE_ROLES"),
+		DisableClusterRoles:  utils.BoolEnv("DISABLE_CLUSTER_ROLES") || Namespaced(),
+		PlanMode:             utils.BoolEnv("PLAN_MODE"),
+		PropagateLabels:      os.Getenv("PROPAGATE_LABELS"),
+		PropagateAnnotations: os.Getenv("PROPAGATE_ANNOTATIONS"),
+	}
+}
+
+// Return the patterns of the labels to propagate from a Flux to its objects.
+func (c Config) PropagatedLabels() []string {
+	return splitList(c.PropagateLabels)
+}
+
+// Return the patterns of the annotations to propagate from a Flux to its objects.
+func (c Config) PropagatedAnnotations() []string {
+	return splitList(c.PropagateAnnotations)
+}
+
+// Return the built in defaults, ignoring the environment.
+func Defaults() Config {
+	return Config{
+		FluxImage:           utils.FluxImage,
+		FluxVersion:         utils.FluxVersion,
+		HelmOperatorImage:   utils.HelmOperatorImage,
+		HelmOperatorVersion: utils.HelmOperatorVersion,
+		MemcachedImage:      utils.MemcachedImage,
+		MemcachedVersion:    utils.MemcachedVersion,
+		TillerImage:         utils.TillerImage,
+		TillerVersion:       utils.TillerVersion,
+	}
+}
+
+// Load the config from the data of the operator config ConfigMap on top of the
+// environment. Empty values are ignored so that the environment or built in
+// default is used.
+func Load(data map[string]string) (Config, error) {
+	config, err := FromEnv().Override(data)
+
+	// Cluster roles can not be granted without cluster wide permissions.
+	if Namespaced() {
+		config.DisableClusterRoles = true
+	}
+
+	return config, err
+}
+
+// Return a copy of the config with the settings in data applied on top of it.
+// Empty values are ignored.
+func (c Config) Override(data map[string]string) (Config, error) {
+	config := c
+	errors := []string{}
+
+	stringSettings := config.strings()
+	boolSettings := config.bools()
+
+	keys := []string{}
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := data[key]
+
+		if setting, ok := stringSettings[key]; ok {
+			if value != "" {
+				*setting = value
+			}
+		} else if setting, ok := boolSettings[key]; ok {
+			if value == "" {
+				continue
+			}
+
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("%s: invalid boolean %q", key, value))
+				continue
+			}
+
+			*setting = parsed
+		} else {
+			errors = append(errors, fmt.Sprintf("%s: unknown setting", key))
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		errors = append(errors, err.Error())
+	}
+
+	if len(errors) > 0 {
+		return config, fmt.Errorf("invalid operator config: %s", strings.Join(errors, ", "))
+	}
+
+	return config, nil
+}
+
+// Return an error if any image or version is malformed.
+func (c Config) Validate() error {
+	errors := []string{}
+
+	settings := c.strings()
+	for _, key := range Keys() {
+		setting, ok := settings[key]
+		if !ok {
+			continue
+		}
+
+		value := *setting
+		if strings.ContainsAny(value, " \t\n") {
+			errors = append(errors, fmt.Sprintf("%s: must not contain whitespace", key))
+		} else if strings.HasSuffix(key, "_IMAGE") && strings.Contains(value[strings.LastIndex(value, "/")+1:], ":") {
+			errors = append(errors, fmt.Sprintf("%s: must not include a tag, set the version instead", key))
+		} else if strings.HasSuffix(key, "_VERSION") && strings.Contains(value, ":") {
+			errors = append(errors, fmt.Sprintf("%s: must not contain ':'", key))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%s", strings.Join(errors, ", "))
+	}
+
+	return nil
+}
+
+// The name of the operator config ConfigMap, set with `OPERATOR_CONFIG`. If it
+// is not set the config is only read from the environment.
+func ConfigMapName() string {
+	return os.Getenv("OPERATOR_CONFIG")
+}
+
+// The namespace of the operator config ConfigMap, the namespace the operator runs in.
+func ConfigMapNamespace() string {
+	return utils.Getenv("POD_NAMESPACE", "default")
+}
+
+// The namespaces that flux-operator watches for Fluxes, set with a comma
+// separated `FLUX_NAMESPACE`. Empty if it watches every namespace.
+func WatchNamespaces() []string {
+	return splitList(os.Getenv("FLUX_NAMESPACE"))
+}
+
+// Split a comma separated list, dropping whitespace and empty items.
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Return true if flux-operator watches Fluxes in namespace.
+func Watches(namespace string) bool {
+	namespaces := WatchNamespaces()
+	if len(namespaces) == 0 {
+		return true
+	}
+
+	for _, watched := range namespaces {
+		if watched == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Return true if flux-operator is installed in namespaced mode, set with
+// `NAMESPACED`. In namespaced mode flux-operator only has roles in the namespaces
+// it watches and cluster roles are always disabled.
+func Namespaced() bool {
+	return utils.BoolEnv("NAMESPACED")
+}
+
+// Return an error if the namespace settings can not be used together.
+func ValidateNamespaces() error {
+	if Namespaced() && len(WatchNamespaces()) == 0 {
+		return fmt.Errorf("NAMESPACED requires FLUX_NAMESPACE to be set to the namespaces to watch")
+	}
+	return nil
+}
+
+var (
+	lock    sync.RWMutex
+	current *Config
+	// Held for reading by each reconcile so that the config can not change part
+	// way through one.
+	held sync.RWMutex
+)
+
+// Keep the config from changing until the returned function is called, so that
+// a reconcile sees a single config. Set blocks until every hold is released, so
+// Hold must not be called again before releasing.
+func Hold() (release func()) {
+	held.RLock()
+	return held.RUnlock
+}
+
+// Return the current config, read from the environment if none has been loaded.
+func Get() Config {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	if current == nil {
+		return FromEnv()
+	}
+
+	return *current
+}
+
+// Set the current config, if nil the config is read from the environment. Waits
+// for reconciles holding the config to finish.
+func Set(config *Config) {
+	held.Lock()
+	defer held.Unlock()
+
+	lock.Lock()
+	defer lock.Unlock()
+	current = config
+}
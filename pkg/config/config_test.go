@@ -0,0 +1,170 @@
+package config
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFromEnvDefaults(t *testing.T) {
+	config := FromEnv()
+	assert.Equal(t, utils.FluxImage, config.FluxImage)
+	assert.Equal(t, utils.TillerVersion, config.TillerVersion)
+	assert.Equal(t, "", config.GitSecretName)
+	assert.False(t, config.DisableRoles)
+}
+
+func TestFromEnv(t *testing.T) {
+	os.Setenv("FLUX_VERSION", "1.9.0")
+	os.Setenv("DISABLE_CLUSTER_ROLES", "true")
+	defer os.Setenv("FLUX_VERSION", "")
+	defer os.Setenv("DISABLE_CLUSTER_ROLES", "")
+
+	config := FromEnv()
+	assert.Equal(t, "1.9.0", config.FluxVersion)
+	assert.True(t, config.DisableClusterRoles)
+}
+
+func TestLoad(t *testing.T) {
+	os.Setenv("FLUX_VERSION", "1.9.0")
+	defer os.Setenv("FLUX_VERSION", "")
+
+	config, err := Load(map[string]string{
+		"FLUX_IMAGE":    "myregistry/flux",
+		"FLUX_VERSION":  "",
+		"DISABLE_ROLES": "true",
+		"PLAN_MODE":     "",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "myregistry/flux", config.FluxImage)
+	assert.Equal(t, "1.9.0", config.FluxVersion)
+	assert.True(t, config.DisableRoles)
+	assert.False(t, config.PlanMode)
+}
+
+func TestOverrideDefaults(t *testing.T) {
+	os.Setenv("FLUX_VERSION", "1.9.0")
+	defer os.Setenv("FLUX_VERSION", "")
+
+	config, err := Defaults().Override(map[string]string{
+		"TILLER_VERSION": "v2.10.0",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, utils.FluxVersion, config.FluxVersion)
+	assert.Equal(t, "v2.10.0", config.TillerVersion)
+	assert.Equal(t, utils.TillerVersion, Defaults().TillerVersion)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	_, err := Load(map[string]string{
+		"DISABLE_ROLES":  "yes please",
+		"FLUX_IMAGES":    "flux",
+		"FLUX_IMAGE":     "quay.io/weaveworks/flux:1.8.1",
+		"TILLER_VERSION": "v2 9",
+	})
+	assert.Equal(t, `invalid operator config: DISABLE_ROLES: invalid boolean "yes please", `+
+		`FLUX_IMAGES: unknown setting, FLUX_IMAGE: must not include a tag, set the version instead, `+
+		`TILLER_VERSION: must not contain whitespace`, err.Error())
+}
+
+func TestValidateRegistryPort(t *testing.T) {
+	config := FromEnv()
+	config.FluxImage = "localhost:5000/flux"
+	assert.Nil(t, config.Validate())
+}
+
+func TestDataRoundTrip(t *testing.T) {
+	config := FromEnv()
+	config.GitSecretName = "my-secret"
+	config.PlanMode = true
+
+	data := config.Data()
+	assert.Equal(t, len(Keys()), len(data))
+
+	loaded, err := Load(data)
+	assert.Nil(t, err)
+	assert.Equal(t, config, loaded)
+}
+
+func TestGetSet(t *testing.T) {
+	defer Set(nil)
+
+	os.Setenv("FLUX_VERSION", "1.9.0")
+	defer os.Setenv("FLUX_VERSION", "")
+	assert.Equal(t, "1.9.0", Get().FluxVersion)
+
+	config := FromEnv()
+	config.FluxVersion = "1.10.0"
+	Set(&config)
+	assert.Equal(t, "1.10.0", Get().FluxVersion)
+
+	Set(nil)
+	assert.Equal(t, "1.9.0", Get().FluxVersion)
+}
+
+func TestWatchNamespaces(t *testing.T) {
+	assert.Equal(t, []string{}, WatchNamespaces())
+	assert.True(t, Watches("team-a"))
+
+	os.Setenv("FLUX_NAMESPACE", "team-a, team-b")
+	defer os.Setenv("FLUX_NAMESPACE", "")
+
+	assert.Equal(t, []string{"team-a", "team-b"}, WatchNamespaces())
+	assert.True(t, Watches("team-b"))
+	assert.False(t, Watches("default"))
+}
+
+func TestNamespaced(t *testing.T) {
+	assert.Nil(t, ValidateNamespaces())
+
+	os.Setenv("NAMESPACED", "true")
+	defer os.Setenv("NAMESPACED", "")
+
+	assert.NotNil(t, ValidateNamespaces())
+
+	assert.True(t, FromEnv().DisableClusterRoles)
+	config, err := Load(map[string]string{"DISABLE_CLUSTER_ROLES": "false"})
+	assert.Nil(t, err)
+	assert.True(t, config.DisableClusterRoles)
+
+	os.Setenv("FLUX_NAMESPACE", "team-a")
+	defer os.Setenv("FLUX_NAMESPACE", "")
+	assert.Nil(t, ValidateNamespaces())
+}
+
+func TestPropagated(t *testing.T) {
+	config, err := Defaults().Override(map[string]string{
+		"PROPAGATE_LABELS":      "team,,example.com/*",
+		"PROPAGATE_ANNOTATIONS": "",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"team", "example.com/*"}, config.PropagatedLabels())
+	assert.Equal(t, []string{}, config.PropagatedAnnotations())
+}
+
+func TestHold(t *testing.T) {
+	defer Set(nil)
+
+	release := Hold()
+
+	set := make(chan bool)
+	go func() {
+		config := FromEnv()
+		config.FluxVersion = "1.10.0"
+		Set(&config)
+		close(set)
+	}()
+
+	select {
+	case <-set:
+		t.Fatal("config changed while held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.NotEqual(t, "1.10.0", Get().FluxVersion)
+
+	release()
+	<-set
+	assert.Equal(t, "1.10.0", Get().FluxVersion)
+}
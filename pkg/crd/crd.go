@@ -0,0 +1,51 @@
+package crd
+
+import (
+	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+)
+
+// Return a CRD that serves and stores a single version of kind, validated by
+// schema.
+func New(group, version, kind, plural, scope string, schema *JSONSchemaProps) *CustomResourceDefinition {
+	return &CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CustomResourceDefinition",
+			APIVersion: "apiextensions.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%s.%s", plural, group),
+		},
+		Spec: CustomResourceDefinitionSpec{
+			Group: group,
+			Names: CustomResourceDefinitionNames{
+				Kind:     kind,
+				ListKind: kind + "List",
+				Plural:   plural,
+				Singular: strings.ToLower(kind),
+			},
+			Scope: scope,
+			Versions: []CustomResourceDefinitionVersion{
+				{
+					Name:    version,
+					Served:  true,
+					Storage: true,
+					Schema: &CustomResourceValidation{
+						OpenAPIV3Schema: schema,
+					},
+				},
+			},
+		},
+	}
+}
+
+// Return the version of the CRD that is stored, nil if there is none.
+func (c *CustomResourceDefinition) StorageVersion() *CustomResourceDefinitionVersion {
+	for index := range c.Spec.Versions {
+		if c.Spec.Versions[index].Storage {
+			return &c.Spec.Versions[index]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package crd
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/kube-openapi/pkg/common"
+	"reflect"
+	"strings"
+)
+
+// Generates structural schemas from Go types, so that every field is typed and
+// the API server can prune unknown fields and apply defaults.
+type SchemaGenerator struct {
+	// The OpenAPI definitions by Go type name, used for descriptions and
+	// required fields.
+	Definitions map[string]common.OpenAPIDefinition
+	// Field defaults by Go type name and JSON field name.
+	Defaults map[string]map[string]interface{}
+	// The values a field allows by Go type name and JSON field name.
+	Enums map[string]map[string][]interface{}
+}
+
+var (
+	quantityType    = reflect.TypeOf(resource.Quantity{})
+	intOrStringType = reflect.TypeOf(intstr.IntOrString{})
+	timeType        = reflect.TypeOf(metav1.Time{})
+	objectMetaType  = reflect.TypeOf(metav1.ObjectMeta{})
+	listMetaType    = reflect.TypeOf(metav1.ListMeta{})
+	rawType         = reflect.TypeOf(runtime.RawExtension{})
+)
+
+// Return the Go type name used by OpenAPI definitions, e.g.
+// `k8s.io/api/core/v1.ResourceRequirements`.
+func typeName(t reflect.Type) string {
+	if t.Name() == "" {
+		return ""
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// Return a schema that accepts any object.
+func PreserveUnknownFields() *JSONSchemaProps {
+	return &JSONSchemaProps{
+		Type:            "object",
+		PreserveUnknown: true,
+	}
+}
+
+// Return the structural schema of the Go type of obj.
+func (g SchemaGenerator) Schema(obj interface{}) *JSONSchemaProps {
+	schema := g.schema(reflect.TypeOf(obj))
+	return &schema
+}
+
+func (g SchemaGenerator) schema(t reflect.Type) JSONSchemaProps {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case quantityType, intOrStringType:
+		return JSONSchemaProps{
+			AnyOf:       []JSONSchemaProps{{Type: "integer"}, {Type: "string"}},
+			IntOrString: true,
+		}
+	case timeType:
+		return JSONSchemaProps{Type: "string", Format: "date-time"}
+	case objectMetaType, listMetaType:
+		return JSONSchemaProps{Type: "object"}
+	case rawType:
+		return *PreserveUnknownFields()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return JSONSchemaProps{Type: "string"}
+	case reflect.Bool:
+		return JSONSchemaProps{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return JSONSchemaProps{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return JSONSchemaProps{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return JSONSchemaProps{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return JSONSchemaProps{Type: "string", Format: "byte"}
+		}
+		items := g.schema(t.Elem())
+		return JSONSchemaProps{Type: "array", Items: &items}
+	case reflect.Map:
+		values := g.schema(t.Elem())
+		return JSONSchemaProps{Type: "object", AdditionalProperties: &values}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return JSONSchemaProps{PreserveUnknown: true}
+	}
+}
+
+// Return the schema of a struct, with the fields of embedded structs inlined.
+func (g SchemaGenerator) structSchema(t reflect.Type) JSONSchemaProps {
+	name := typeName(t)
+	definition := g.Definitions[name].Schema
+
+	schema := JSONSchemaProps{
+		Type:        "object",
+		Description: definition.Description,
+		Properties:  map[string]JSONSchemaProps{},
+	}
+
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		property := tag[0]
+		if property == "-" {
+			continue
+		}
+
+		if property == "" && field.Anonymous {
+			embedded := g.schema(field.Type)
+			for key, value := range embedded.Properties {
+				schema.Properties[key] = value
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		} else if property == "" {
+			property = field.Name
+		}
+
+		fieldSchema := g.schema(field.Type)
+		if description := definition.Properties[property].Description; description != "" {
+			fieldSchema.Description = description
+		}
+
+		if value, ok := g.Defaults[name][property]; ok {
+			fieldSchema.Default = value
+		}
+
+		if values, ok := g.Enums[name][property]; ok {
+			fieldSchema.Enum = values
+		}
+
+		schema.Properties[property] = fieldSchema
+	}
+
+	for _, property := range definition.Required {
+		if _, ok := schema.Properties[property]; ok {
+			schema.Required = append(schema.Required, property)
+		}
+	}
+
+	if len(schema.Properties) == 0 {
+		schema.Properties = nil
+	}
+
+	return schema
+}
@@ -0,0 +1,94 @@
+package crd
+
+import (
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+type testSpec struct {
+	Name      string                       `json:"name"`
+	Replicas  *int32                       `json:"replicas,omitempty"`
+	Args      map[string]string            `json:"args,omitempty"`
+	Tags      []string                     `json:"tags,omitempty"`
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	Since     *metav1.Time                 `json:"since,omitempty"`
+	Ignored   string                       `json:"-"`
+	hidden    string
+}
+
+type testObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              testSpec `json:"spec"`
+}
+
+// Return an error message for every node of schema that is not structural.
+func checkStructural(path string, schema JSONSchemaProps) []string {
+	errors := []string{}
+	if schema.Type == "" && !schema.IntOrString && !schema.PreserveUnknown {
+		errors = append(errors, path+" has no type")
+	}
+
+	for name, property := range schema.Properties {
+		errors = append(errors, checkStructural(path+"."+name, property)...)
+	}
+
+	if schema.Items != nil {
+		errors = append(errors, checkStructural(path+"[]", *schema.Items)...)
+	}
+
+	if schema.AdditionalProperties != nil {
+		errors = append(errors, checkStructural(path+"{}", *schema.AdditionalProperties)...)
+	}
+
+	return errors
+}
+
+func TestSchema(t *testing.T) {
+	generator := SchemaGenerator{
+		Defaults: map[string]map[string]interface{}{
+			"github.com/justinbarrick/flux-operator/pkg/crd.testSpec": {"name": "example"},
+		},
+		Enums: map[string]map[string][]interface{}{
+			"github.com/justinbarrick/flux-operator/pkg/crd.testSpec": {"name": {"example", "other"}},
+		},
+	}
+
+	schema := generator.Schema(&testObject{})
+	assert.Equal(t, []string{}, checkStructural("", *schema))
+
+	assert.Equal(t, "string", schema.Properties["apiVersion"].Type)
+	assert.Equal(t, "string", schema.Properties["kind"].Type)
+	assert.Equal(t, "object", schema.Properties["metadata"].Type)
+	assert.Nil(t, schema.Properties["metadata"].Properties)
+
+	spec := schema.Properties["spec"]
+	assert.Equal(t, "example", spec.Properties["name"].Default)
+	assert.Equal(t, []interface{}{"example", "other"}, spec.Properties["name"].Enum)
+	assert.Equal(t, "integer", spec.Properties["replicas"].Type)
+	assert.Equal(t, "string", spec.Properties["args"].AdditionalProperties.Type)
+	assert.Equal(t, "string", spec.Properties["tags"].Items.Type)
+	assert.Equal(t, "date-time", spec.Properties["since"].Format)
+	assert.True(t, spec.Properties["resources"].Properties["limits"].AdditionalProperties.IntOrString)
+
+	_, ok := spec.Properties["Ignored"]
+	assert.False(t, ok)
+	_, ok = spec.Properties["hidden"]
+	assert.False(t, ok)
+}
+
+func TestNew(t *testing.T) {
+	definition := New("example.com", "v1", "Example", "examples", "Namespaced", PreserveUnknownFields())
+	assert.Equal(t, "examples.example.com", definition.Name)
+	assert.Equal(t, "ExampleList", definition.Spec.Names.ListKind)
+	assert.Equal(t, "v1", definition.StorageVersion().Name)
+	assert.False(t, definition.IsEstablished())
+	assert.Nil(t, definition.WebhookClientConfig())
+
+	definition.Status.Conditions = []CustomResourceDefinitionCondition{{Type: Established, Status: "True"}}
+	copied := definition.DeepCopyObject().(*CustomResourceDefinition)
+	assert.True(t, copied.IsEstablished())
+	assert.Equal(t, definition, copied)
+}
@@ -0,0 +1,171 @@
+package crd
+
+import (
+	"encoding/json"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// The apiextensions.k8s.io/v1 CustomResourceDefinition API, which the vendored
+// apiextensions-apiserver predates. Only the fields the installer sets or reads
+// are included.
+type CustomResourceDefinition struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              CustomResourceDefinitionSpec   `json:"spec"`
+	Status            CustomResourceDefinitionStatus `json:"status,omitempty"`
+}
+
+type CustomResourceDefinitionSpec struct {
+	Group      string                            `json:"group"`
+	Names      CustomResourceDefinitionNames     `json:"names"`
+	Scope      string                            `json:"scope"`
+	Versions   []CustomResourceDefinitionVersion `json:"versions"`
+	Conversion *CustomResourceConversion         `json:"conversion,omitempty"`
+}
+
+type CustomResourceDefinitionNames struct {
+	Plural     string   `json:"plural"`
+	Singular   string   `json:"singular,omitempty"`
+	ShortNames []string `json:"shortNames,omitempty"`
+	Kind       string   `json:"kind"`
+	ListKind   string   `json:"listKind,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+type CustomResourceDefinitionVersion struct {
+	Name                     string                           `json:"name"`
+	Served                   bool                             `json:"served"`
+	Storage                  bool                             `json:"storage"`
+	Schema                   *CustomResourceValidation        `json:"schema,omitempty"`
+	Subresources             *CustomResourceSubresources      `json:"subresources,omitempty"`
+	AdditionalPrinterColumns []CustomResourceColumnDefinition `json:"additionalPrinterColumns,omitempty"`
+}
+
+type CustomResourceValidation struct {
+	OpenAPIV3Schema *JSONSchemaProps `json:"openAPIV3Schema,omitempty"`
+}
+
+type CustomResourceSubresources struct {
+	Status *CustomResourceSubresourceStatus `json:"status,omitempty"`
+}
+
+type CustomResourceSubresourceStatus struct{}
+
+type CustomResourceColumnDefinition struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Format      string `json:"format,omitempty"`
+	Description string `json:"description,omitempty"`
+	Priority    int32  `json:"priority,omitempty"`
+	JSONPath    string `json:"jsonPath"`
+}
+
+type CustomResourceConversion struct {
+	// `None` or `Webhook`.
+	Strategy string             `json:"strategy"`
+	Webhook  *WebhookConversion `json:"webhook,omitempty"`
+}
+
+type WebhookConversion struct {
+	ClientConfig             *WebhookClientConfig `json:"clientConfig,omitempty"`
+	ConversionReviewVersions []string             `json:"conversionReviewVersions"`
+}
+
+type WebhookClientConfig struct {
+	Service *ServiceReference `json:"service,omitempty"`
+	// PEM encoded CA certificates that sign the webhook's serving certificate.
+	CABundle []byte `json:"caBundle,omitempty"`
+}
+
+type ServiceReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Path      string `json:"path,omitempty"`
+	Port      int32  `json:"port,omitempty"`
+}
+
+type CustomResourceDefinitionStatus struct {
+	Conditions     []CustomResourceDefinitionCondition `json:"conditions,omitempty"`
+	StoredVersions []string                            `json:"storedVersions,omitempty"`
+}
+
+type CustomResourceDefinitionCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// A request from the API server to convert custom resources to another version,
+// answered with the same kind.
+type ConversionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *ConversionRequest  `json:"request,omitempty"`
+	Response        *ConversionResponse `json:"response,omitempty"`
+}
+
+type ConversionRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+type ConversionResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           metav1.Status          `json:"result"`
+}
+
+// A structural OpenAPI v3 schema.
+type JSONSchemaProps struct {
+	Description          string                     `json:"description,omitempty"`
+	Type                 string                     `json:"type,omitempty"`
+	Format               string                     `json:"format,omitempty"`
+	Default              interface{}                `json:"default,omitempty"`
+	Enum                 []interface{}              `json:"enum,omitempty"`
+	Required             []string                   `json:"required,omitempty"`
+	Items                *JSONSchemaProps           `json:"items,omitempty"`
+	Properties           map[string]JSONSchemaProps `json:"properties,omitempty"`
+	AdditionalProperties *JSONSchemaProps           `json:"additionalProperties,omitempty"`
+	AnyOf                []JSONSchemaProps          `json:"anyOf,omitempty"`
+	PreserveUnknown      bool                       `json:"x-kubernetes-preserve-unknown-fields,omitempty"`
+	IntOrString          bool                       `json:"x-kubernetes-int-or-string,omitempty"`
+}
+
+// Return a deep copy of the CRD. The schema holds arbitrary default values, so
+// it is copied through JSON rather than field by field.
+func (c *CustomResourceDefinition) DeepCopyObject() runtime.Object {
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		panic(err)
+	}
+
+	copied := &CustomResourceDefinition{}
+	if err := json.Unmarshal(encoded, copied); err != nil {
+		panic(err)
+	}
+	return copied
+}
+
+// Return the client config of the CRD's conversion webhook, nil if it has none.
+func (c *CustomResourceDefinition) WebhookClientConfig() *WebhookClientConfig {
+	if c.Spec.Conversion == nil || c.Spec.Conversion.Webhook == nil {
+		return nil
+	}
+	return c.Spec.Conversion.Webhook.ClientConfig
+}
+
+// The condition set once a CRD's names are accepted and it is served.
+const Established = "Established"
+
+// Return true if the CRD has been established.
+func (c *CustomResourceDefinition) IsEstablished() bool {
+	for _, condition := range c.Status.Conditions {
+		if condition.Type == Established && condition.Status == "True" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package desired
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sort"
+)
+
+// Look up a ConfigMap or Secret in the cluster, returning nil if it does not
+// exist.
+type Lookup func(kind, namespace, name string) (runtime.Object, error)
+
+// A ConfigMap or Secret referenced by a pod.
+type reference struct {
+	kind string
+	name string
+}
+
+// Return the ConfigMaps and Secrets that a pod mounts or reads environment
+// variables from, sorted by kind and name.
+func podReferences(spec corev1.PodSpec) []reference {
+	found := map[reference]bool{}
+
+	for _, volume := range spec.Volumes {
+		if volume.ConfigMap != nil {
+			found[reference{"ConfigMap", volume.ConfigMap.Name}] = true
+		}
+
+		if volume.Secret != nil {
+			found[reference{"Secret", volume.Secret.SecretName}] = true
+		}
+
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					found[reference{"ConfigMap", source.ConfigMap.Name}] = true
+				}
+
+				if source.Secret != nil {
+					found[reference{"Secret", source.Secret.Name}] = true
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, source := range container.EnvFrom {
+			if source.ConfigMapRef != nil {
+				found[reference{"ConfigMap", source.ConfigMapRef.Name}] = true
+			}
+
+			if source.SecretRef != nil {
+				found[reference{"Secret", source.SecretRef.Name}] = true
+			}
+		}
+
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				found[reference{"ConfigMap", env.ValueFrom.ConfigMapKeyRef.Name}] = true
+			}
+
+			if env.ValueFrom.SecretKeyRef != nil {
+				found[reference{"Secret", env.ValueFrom.SecretKeyRef.Name}] = true
+			}
+		}
+	}
+
+	references := []reference{}
+	for ref := range found {
+		references = append(references, ref)
+	}
+
+	sort.Slice(references, func(i, j int) bool {
+		if references[i].kind != references[j].kind {
+			return references[i].kind < references[j].kind
+		}
+		return references[i].name < references[j].name
+	})
+
+	return references
+}
+
+// Return the data of a ConfigMap or Secret, or nil if it is neither.
+func objectData(obj runtime.Object) map[string][]byte {
+	switch object := obj.(type) {
+	case *corev1.ConfigMap:
+		data := map[string][]byte{}
+		for key, value := range object.Data {
+			data[key] = []byte(value)
+		}
+		return data
+	case *corev1.Secret:
+		data := map[string][]byte{}
+		for key, value := range object.Data {
+			data[key] = value
+		}
+		for key, value := range object.StringData {
+			data[key] = []byte(value)
+		}
+		return data
+	}
+	return nil
+}
+
+// Return the ConfigMap or Secret in objects matching ref in namespace, or look
+// it up in the cluster if it is not one of them. Objects that flux-operator
+// manages are taken from objects so that changes to them roll pods in the same
+// reconcile, and so that flux storing its key in the git secret it creates
+// does not.
+func findReference(objects []runtime.Object, namespace string, ref reference, lookup Lookup) (runtime.Object, error) {
+	for _, object := range objects {
+		objectMeta, err := meta.Accessor(object)
+		if err != nil {
+			continue
+		}
+
+		if object.GetObjectKind().GroupVersionKind().Kind == ref.kind && objectMeta.GetNamespace() == namespace && objectMeta.GetName() == ref.name {
+			return object, nil
+		}
+	}
+
+	if lookup == nil {
+		return nil, nil
+	}
+
+	return lookup(ref.kind, namespace, ref.name)
+}
+
+// Return a checksum of the contents of the ConfigMaps and Secrets that a pod
+// references, or an empty string if it references none.
+func podChecksum(objects []runtime.Object, namespace string, spec corev1.PodSpec, lookup Lookup) (string, error) {
+	references := podReferences(spec)
+	if len(references) == 0 {
+		return "", nil
+	}
+
+	hash := sha256.New()
+	for _, ref := range references {
+		object, err := findReference(objects, namespace, ref, lookup)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up %s %s/%s: %v", ref.kind, namespace, ref.name, err)
+		}
+
+		fmt.Fprintf(hash, "%s/%s\n", ref.kind, ref.name)
+		if object == nil {
+			continue
+		}
+
+		data := objectData(object)
+		keys := []string{}
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fmt.Fprintf(hash, "%s=%x\n", key, sha256.Sum256(data[key]))
+		}
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// Annotate the pod template of each workload in objects with a checksum of the
+// ConfigMaps and Secrets it references, so that it is rolled out whenever their
+// contents change. ConfigMaps and Secrets that are not in objects are looked up
+// with lookup, or left out of the checksum if lookup is nil.
+func SetChecksums(objects []runtime.Object, lookup Lookup) error {
+	for _, object := range objects {
+		template := utils.PodTemplate(object)
+		if template == nil {
+			continue
+		}
+
+		objectMeta, err := meta.Accessor(object)
+		if err != nil {
+			return err
+		}
+
+		checksum, err := podChecksum(objects, objectMeta.GetNamespace(), template.Spec, lookup)
+		if err != nil {
+			return err
+		}
+
+		if checksum == "" {
+			continue
+		}
+
+		annotations := map[string]string{}
+		for key, value := range template.ObjectMeta.Annotations {
+			annotations[key] = value
+		}
+		annotations[utils.ChecksumAnnotation] = checksum
+		template.ObjectMeta.Annotations = annotations
+	}
+
+	return nil
+}
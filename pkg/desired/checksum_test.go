@@ -0,0 +1,125 @@
+package desired
+
+import (
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/justinbarrick/flux-operator/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"testing"
+)
+
+// Return the checksum annotations of the Deployments in objects by name.
+func checksums(objects []runtime.Object) map[string]string {
+	found := map[string]string{}
+	for _, object := range objects {
+		if deployment, ok := object.(*extensions.Deployment); ok {
+			found[deployment.Name] = deployment.Spec.Template.ObjectMeta.Annotations[utils.ChecksumAnnotation]
+		}
+	}
+	return found
+}
+
+func TestPodReferences(t *testing.T) {
+	spec := corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "key"}}},
+			{VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "hosts"},
+			}}},
+		},
+		Containers: []corev1.Container{
+			{
+				EnvFrom: []corev1.EnvFromSource{
+					{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "slack"}}},
+				},
+				Env: []corev1.EnvVar{
+					{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "key"},
+						Key:                  "token",
+					}}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []reference{
+		{"ConfigMap", "hosts"},
+		{"Secret", "key"},
+		{"Secret", "slack"},
+	}, podReferences(spec))
+}
+
+func TestChecksumsFollowKnownHosts(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.HelmOperator.Enabled = true
+	cr.Spec.KnownHosts = "github.com ssh-rsa AAAA"
+
+	objects, err := FluxObjects(cr, true, nil)
+	assert.Nil(t, err)
+
+	before := checksums(objects)
+	assert.NotEqual(t, "", before["flux-example"])
+	assert.NotEqual(t, "", before["flux-example-helm-operator"])
+	assert.Equal(t, "", before["flux-example-memcached"])
+
+	cr.Spec.KnownHosts = "github.com ssh-rsa BBBB"
+	objects, err = FluxObjects(cr, true, nil)
+	assert.Nil(t, err)
+
+	after := checksums(objects)
+	assert.NotEqual(t, before["flux-example"], after["flux-example"])
+	assert.NotEqual(t, before["flux-example-helm-operator"], after["flux-example-helm-operator"])
+}
+
+func TestChecksumsLookUpUnmanagedSecrets(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.GitSecret = "my-key"
+
+	key := []byte("one")
+	lookups := []string{}
+	lookup := func(kind, namespace, name string) (runtime.Object, error) {
+		lookups = append(lookups, fmt.Sprintf("%s:%s/%s", namespace, kind, name))
+		return &corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{Kind: kind, APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string][]byte{"identity": key},
+		}, nil
+	}
+
+	objects, err := FluxObjects(cr, false, lookup)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"default:Secret/my-key"}, lookups)
+	before := checksums(objects)["flux-example"]
+
+	key = []byte("two")
+	objects, err = FluxObjects(cr, false, lookup)
+	assert.Nil(t, err)
+	assert.NotEqual(t, before, checksums(objects)["flux-example"])
+}
+
+func TestChecksumsIgnoreManagedSecretData(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	lookup := func(kind, namespace, name string) (runtime.Object, error) {
+		return nil, fmt.Errorf("unexpected lookup of %s", name)
+	}
+
+	objects, err := FluxObjects(cr, true, lookup)
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", checksums(objects)["flux-example"])
+}
+
+func TestChecksumsLookupError(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	lookup := func(kind, namespace, name string) (runtime.Object, error) {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	_, err := FluxObjects(cr, false, lookup)
+	assert.Equal(t, "failed to look up Secret default/flux-git-example-deploy: forbidden", err.Error())
+}
@@ -0,0 +1,93 @@
+package desired
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/flux"
+	"github.com/justinbarrick/flux-operator/pkg/fluxcloud"
+	"github.com/justinbarrick/flux-operator/pkg/helm-operator"
+	"github.com/justinbarrick/flux-operator/pkg/kinds"
+	"github.com/justinbarrick/flux-operator/pkg/memcached"
+	"github.com/justinbarrick/flux-operator/pkg/rbac"
+	"github.com/justinbarrick/flux-operator/pkg/tiller"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Return every kind of object created for Fluxes, as registered by the packages
+// that create them.
+func Kinds() []kinds.Kind {
+	return kinds.All()
+}
+
+// A component of a Flux and the objects that make it up.
+type component struct {
+	name    string
+	version string
+	objects []runtime.Object
+}
+
+// Create flux, tiller, and helm-operator instances from a CR and return them
+// as a list of objects. The SSH key secret is only included if manageSSHKey is
+// set, it is not managed when the user created it themselves. The cluster is
+// only consulted through lookup to checksum the ConfigMaps and Secrets that
+// flux-operator does not manage, if lookup is nil they are left out. Objects
+// are returned in the order they are created in.
+func FluxObjects(cr *v1alpha1.Flux, manageSSHKey bool, lookup Lookup) ([]runtime.Object, error) {
+	_, fluxVersion := flux.FluxImage(cr)
+	fluxObjects := rbac.FluxRoles(cr)
+	fluxObjects = append(fluxObjects, flux.NewFluxDeployment(cr))
+
+	if manageSSHKey {
+		fluxObjects = append(fluxObjects, flux.NewFluxSSHKey(cr))
+	}
+
+	knownHosts := flux.NewFluxKnownHosts(cr)
+	if knownHosts != nil {
+		fluxObjects = append(fluxObjects, knownHosts)
+	}
+
+	tillerObjects, err := tiller.NewTiller(cr)
+	if err != nil {
+		return nil, err
+	}
+	_, tillerVersion := tiller.TillerImage(cr)
+
+	helmOperatorObjects := []runtime.Object{}
+	helmOperator := helm_operator.NewHelmOperatorDeployment(cr)
+	if helmOperator != nil {
+		helmOperatorObjects = append(helmOperatorObjects, helmOperator)
+	}
+	_, helmOperatorVersion := helm_operator.HelmOperatorImage(cr)
+
+	components := []component{
+		{"flux", fluxVersion, fluxObjects},
+		{"memcached", config.Get().MemcachedVersion, memcached.NewMemcached(cr)},
+		{"fluxcloud", fluxcloud.FluxcloudVersion(cr), fluxcloud.NewFluxcloud(cr)},
+		{"tiller", tillerVersion, tillerObjects},
+		{"helm-operator", helmOperatorVersion, helmOperatorObjects},
+	}
+
+	labels := utils.SelectKeys(cr.ObjectMeta.Labels, config.Get().PropagatedLabels())
+	annotations := utils.SelectKeys(cr.ObjectMeta.Annotations, config.Get().PropagatedAnnotations())
+
+	objects := []runtime.Object{}
+	for _, component := range components {
+		for _, object := range component.objects {
+			utils.SetObjectOwner(cr, object)
+			utils.SetObjectMetadata(object, utils.AppLabels(cr, component.name, component.version), nil)
+			utils.SetObjectMetadata(object, labels, annotations)
+			objects = append(objects, object)
+		}
+	}
+
+	if err := SetChecksums(objects, lookup); err != nil {
+		return nil, err
+	}
+
+	for _, object := range objects {
+		utils.SetObjectHash(object)
+	}
+
+	return kinds.Sort(objects), nil
+}
@@ -0,0 +1,30 @@
+package desired
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestKindsCoverFluxObjects(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.KnownHosts = "github.com ssh-rsa AAAA"
+	cr.Spec.Tiller.Enabled = true
+	cr.Spec.HelmOperator.Enabled = true
+	cr.Spec.FluxCloud.Enabled = true
+	cr.Spec.ClusterRole.Enabled = true
+	cr.Spec.Role.Enabled = true
+
+	objects, err := FluxObjects(cr, true, nil)
+	assert.Nil(t, err)
+
+	registered := map[string]bool{}
+	for _, kind := range Kinds() {
+		registered[kind.GroupVersionKind().String()] = true
+	}
+
+	for _, object := range objects {
+		kind := object.GetObjectKind().GroupVersionKind()
+		assert.True(t, registered[kind.String()], "%s is not registered", kind)
+	}
+}
@@ -0,0 +1,142 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons recorded on Flux events.
+const (
+	// A child resource was created.
+	ReasonCreated = "Created"
+	// An out of date child resource was updated.
+	ReasonUpdated = "Updated"
+	// A child resource that is no longer desired was deleted.
+	ReasonDeleted = "Deleted"
+	// Creating a child resource failed.
+	ReasonCreateFailed = "CreateFailed"
+	// Updating a child resource failed.
+	ReasonUpdateFailed = "UpdateFailed"
+	// Deleting a child resource failed.
+	ReasonDeleteFailed = "DeleteFailed"
+	// A child resource was not applied because an object it depends on failed to apply.
+	ReasonDependencyFailed = "DependencyFailed"
+	// The desired or existing state of the Flux could not be determined.
+	ReasonReconcileFailed = "ReconcileFailed"
+	// Reconciling the Flux was suspended.
+	ReasonSuspended = "Suspended"
+	// Reconciling the Flux was resumed.
+	ReasonResumed = "Resumed"
+	// The changes a reconcile would make were computed in plan mode.
+	ReasonPlanned = "Planned"
+	// The Flux is not reconciled because it violates a FluxPolicy.
+	ReasonPolicyViolation = "PolicyViolation"
+	// A namespace that the Flux has objects in does not exist yet.
+	ReasonNamespaceMissing = "NamespaceMissing"
+	// A role referenced by the Flux is invalid or does not exist.
+	ReasonRoleMissing = "RoleMissing"
+	// The Flux asks for something that flux-operator can not provide in namespaced mode.
+	ReasonUnsupported = "Unsupported"
+)
+
+const (
+	// The component name that events are recorded as.
+	Component = "flux-operator"
+	// Identical events recorded within this window are dropped.
+	DefaultDedupWindow = 10 * time.Minute
+)
+
+// Records events on Flux CRs, dropping events identical to one recorded within
+// the dedup window so that failures retried on every resync do not flood the API.
+type Recorder struct {
+	recorder    record.EventRecorder
+	dedupWindow time.Duration
+	now         func() time.Time
+
+	lock   sync.Mutex
+	recent map[string]time.Time
+}
+
+var defaultRecorder = NewRecorder(&record.FakeRecorder{})
+
+// Create a new Recorder that sends events to recorder.
+func NewRecorder(recorder record.EventRecorder) *Recorder {
+	return &Recorder{
+		recorder:    recorder,
+		dedupWindow: DefaultDedupWindow,
+		now:         time.Now,
+		recent:      map[string]time.Time{},
+	}
+}
+
+// Create an event recorder that sends events to the Kubernetes API.
+func NewKubernetesRecorder(client kubernetes.Interface) record.EventRecorder {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		logrus.Errorf("Failed to register Flux types for events: %v", err)
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: client.CoreV1().Events(""),
+	})
+
+	return broadcaster.NewRecorder(scheme, corev1.EventSource{
+		Component: Component,
+	})
+}
+
+// Set the recorder used by the package level functions.
+func SetRecorder(recorder *Recorder) {
+	defaultRecorder = recorder
+}
+
+// Return true if the event should be recorded and remember that it was.
+func (r *Recorder) shouldRecord(cr *v1alpha1.Flux, eventType, reason, message string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	for key, recorded := range r.recent {
+		if now.Sub(recorded) >= r.dedupWindow {
+			delete(r.recent, key)
+		}
+	}
+
+	key := fmt.Sprintf("%s/%s/%s/%s/%s/%s", cr.Namespace, cr.Name, cr.UID, eventType, reason, message)
+	if _, ok := r.recent[key]; ok {
+		return false
+	}
+
+	r.recent[key] = now
+	return true
+}
+
+// Record an event on a Flux CR.
+func (r *Recorder) Eventf(cr *v1alpha1.Flux, eventType, reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if !r.shouldRecord(cr, eventType, reason, message) {
+		return
+	}
+
+	r.recorder.Event(cr, eventType, reason, message)
+}
+
+// Record a Normal event on a Flux CR.
+func Normalf(cr *v1alpha1.Flux, reason, messageFmt string, args ...interface{}) {
+	defaultRecorder.Eventf(cr, corev1.EventTypeNormal, reason, messageFmt, args...)
+}
+
+// Record a Warning event on a Flux CR.
+func Warningf(cr *v1alpha1.Flux, reason, messageFmt string, args ...interface{}) {
+	defaultRecorder.Eventf(cr, corev1.EventTypeWarning, reason, messageFmt, args...)
+}
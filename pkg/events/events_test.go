@@ -0,0 +1,53 @@
+package events
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/record"
+	"testing"
+	"time"
+)
+
+func newTestRecorder() (*Recorder, *record.FakeRecorder, *time.Time) {
+	fake := record.NewFakeRecorder(10)
+	recorder := NewRecorder(fake)
+
+	now := time.Now()
+	recorder.now = func() time.Time {
+		return now
+	}
+
+	return recorder, fake, &now
+}
+
+func TestEventf(t *testing.T) {
+	recorder, fake, _ := newTestRecorder()
+
+	recorder.Eventf(test_utils.NewFlux(), "Normal", ReasonCreated, "Created %s", "default:Deployment/flux-example")
+	assert.Equal(t, "Normal Created Created default:Deployment/flux-example", <-fake.Events)
+}
+
+func TestEventfDeduplicates(t *testing.T) {
+	recorder, fake, now := newTestRecorder()
+	cr := test_utils.NewFlux()
+
+	recorder.Eventf(cr, "Warning", ReasonCreateFailed, "Failed to create %s", "a")
+	recorder.Eventf(cr, "Warning", ReasonCreateFailed, "Failed to create %s", "a")
+	recorder.Eventf(cr, "Warning", ReasonCreateFailed, "Failed to create %s", "b")
+	assert.Equal(t, 2, len(fake.Events))
+
+	*now = now.Add(DefaultDedupWindow)
+	recorder.Eventf(cr, "Warning", ReasonCreateFailed, "Failed to create %s", "a")
+	assert.Equal(t, 3, len(fake.Events))
+}
+
+func TestEventfDoesNotDeduplicateAcrossFluxes(t *testing.T) {
+	recorder, fake, _ := newTestRecorder()
+	first := test_utils.NewFlux()
+	second := test_utils.NewFlux()
+	second.Name = "other"
+
+	recorder.Eventf(first, "Normal", ReasonDeleted, "Deleted unwanted a")
+	recorder.Eventf(second, "Normal", ReasonDeleted, "Deleted unwanted a")
+	assert.Equal(t, 2, len(fake.Events))
+}
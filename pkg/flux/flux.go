@@ -3,19 +3,31 @@ package flux
 import (
 	"fmt"
 	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
 	"github.com/justinbarrick/flux-operator/pkg/fluxcloud"
+	"github.com/justinbarrick/flux-operator/pkg/kinds"
 	"github.com/justinbarrick/flux-operator/pkg/memcached"
 	"github.com/justinbarrick/flux-operator/pkg/rbac"
 	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sort"
+	"strings"
 )
 
+// Register the kinds of objects this package creates for Fluxes.
+func init() {
+	kinds.Register(kinds.Deployment, kinds.Secret, kinds.ConfigMap)
+}
+
 func GitSecretName(cr *v1alpha1.Flux) string {
-	secretName := utils.Getenv("GIT_SECRET_NAME", fmt.Sprintf("flux-git-%s-deploy", cr.Name))
+	secretName := fmt.Sprintf("flux-git-%s-deploy", cr.Name)
+	if config.Get().GitSecretName != "" {
+		secretName = config.Get().GitSecretName
+	}
 
 	if cr.Spec.GitSecret != "" {
 		secretName = cr.Spec.GitSecret
@@ -29,11 +41,7 @@ func KnownHostsName(cr *v1alpha1.Flux) string {
 		return fmt.Sprintf("flux-git-%s-known-hosts", cr.Name)
 	}
 
-	if utils.Getenv("KNOWN_HOSTS_CONFIGMAP", "") != "" {
-		return utils.Getenv("KNOWN_HOSTS_CONFIGMAP", "")
-	}
-
-	return ""
+	return config.Get().KnownHostsConfigMap
 }
 
 func MakeGitVolumes(cr *v1alpha1.Flux) ([]corev1.Volume, []corev1.VolumeMount) {
@@ -116,6 +124,13 @@ func MakeFluxArgs(cr *v1alpha1.Flux) (args []string) {
 		"memcached-hostname": memcached.MemcachedName(cr),
 	}
 
+	// Flux is restricted to the namespaces its role is created in, its own
+	// namespace and its target namespaces. Without a cluster role flux can
+	// only see those.
+	if len(cr.Spec.TargetNamespaces) > 0 || config.Namespaced() {
+		argMap["k8s-namespace-whitelist"] = strings.Join(rbac.RoleNamespaces(cr), ",")
+	}
+
 	if cr.Spec.FluxCloud.Enabled == true {
 		argMap["connect"] = fmt.Sprintf("ws://%s/", fluxcloud.FluxcloudName(cr))
 	}
@@ -133,18 +148,25 @@ func MakeFluxArgs(cr *v1alpha1.Flux) (args []string) {
 	return
 }
 
-// NewFluxDeployment creates a new flux pod
-func NewFluxDeployment(cr *v1alpha1.Flux) *extensions.Deployment {
-	fluxImage := utils.Getenv("FLUX_IMAGE", utils.FluxImage)
+// Return the flux image and version to run for the CR.
+func FluxImage(cr *v1alpha1.Flux) (string, string) {
+	fluxImage := config.Get().FluxImage
 	if cr.Spec.FluxImage != "" {
 		fluxImage = cr.Spec.FluxImage
 	}
 
-	fluxVersion := utils.Getenv("FLUX_VERSION", utils.FluxVersion)
+	fluxVersion := config.Get().FluxVersion
 	if cr.Spec.FluxVersion != "" {
 		fluxVersion = cr.Spec.FluxVersion
 	}
 
+	return fluxImage, fluxVersion
+}
+
+// NewFluxDeployment creates a new flux pod
+func NewFluxDeployment(cr *v1alpha1.Flux) *extensions.Deployment {
+	fluxImage, fluxVersion := FluxImage(cr)
+
 	meta := utils.NewObjectMeta(cr, "")
 	labels := map[string]string{
 		"name": "flux",
@@ -221,6 +243,30 @@ func NewFluxSSHKey(cr *v1alpha1.Flux) *corev1.Secret {
 	}
 }
 
+// Return the key of the git secret that flux stores its private SSH key in.
+func GitSecretDataKey(cr *v1alpha1.Flux) string {
+	if key := cr.Spec.Args["k8s-secret-data-key"]; key != "" {
+		return key
+	}
+	return "identity"
+}
+
+// Return the public key of the private SSH key flux stored in the git secret in
+// authorized_keys format, or an empty string if flux has not generated it yet.
+func DeployKey(cr *v1alpha1.Flux, secret *corev1.Secret) (string, error) {
+	privateKey := secret.Data[GitSecretDataKey(cr)]
+	if len(privateKey) == 0 {
+		return "", nil
+	}
+
+	signer, err := ssh.ParsePrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))), nil
+}
+
 func NewFluxKnownHosts(cr *v1alpha1.Flux) *corev1.ConfigMap {
 	if cr.Spec.KnownHosts == "" {
 		return nil
@@ -1,16 +1,22 @@
 package flux
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"github.com/justinbarrick/flux-operator/pkg/fluxcloud"
 	"github.com/justinbarrick/flux-operator/pkg/memcached"
 	"github.com/justinbarrick/flux-operator/pkg/utils"
 	"github.com/justinbarrick/flux-operator/pkg/utils/test"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"os"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -61,6 +67,25 @@ func TestMakeFluxArgsNoArgs(t *testing.T) {
 	assert.Equal(t, args, expectedArgs)
 }
 
+func TestMakeFluxArgsTargetNamespaces(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.TargetNamespaces = []string{"team-a", "team-b"}
+	args := MakeFluxArgs(cr)
+	assert.Contains(t, args, "--k8s-namespace-whitelist=default,team-a,team-b")
+
+	cr.Spec.TargetNamespaces = []string{"team-a", "default"}
+	assert.Contains(t, MakeFluxArgs(cr), "--k8s-namespace-whitelist=default,team-a")
+}
+
+func TestMakeFluxArgsNamespaced(t *testing.T) {
+	cr := test_utils.NewFlux()
+	assert.NotContains(t, MakeFluxArgs(cr), "--k8s-namespace-whitelist=default")
+
+	os.Setenv("NAMESPACED", "true")
+	defer os.Setenv("NAMESPACED", "")
+	assert.Contains(t, MakeFluxArgs(cr), "--k8s-namespace-whitelist=default")
+}
+
 func TestMakeFluxArgsOverrideInterval(t *testing.T) {
 	cr := test_utils.NewFlux()
 	cr.Spec.GitPollInterval = "0m30s"
@@ -289,3 +314,38 @@ func TestMakeGitVolumesWithKnownHostsFromEnvironment(t *testing.T) {
 	assert.Equal(t, volumeMounts[1].SubPath, "known_hosts")
 	assert.Equal(t, volumeMounts[1].MountPath, "/root/.ssh/known_hosts")
 }
+
+func TestDeployKey(t *testing.T) {
+	cr := test_utils.NewFlux()
+	secret := NewFluxSSHKey(cr)
+
+	deployKey, err := DeployKey(cr, secret)
+	assert.Nil(t, err)
+	assert.Equal(t, "", deployKey)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+
+	publicKey, err := ssh.NewPublicKey(&privateKey.PublicKey)
+	assert.Nil(t, err)
+
+	secret.Data = map[string][]byte{
+		"identity": pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+		}),
+	}
+
+	deployKey, err = DeployKey(cr, secret)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))), deployKey)
+
+	cr.Spec.Args = map[string]string{"k8s-secret-data-key": "key"}
+	deployKey, err = DeployKey(cr, secret)
+	assert.Nil(t, err)
+	assert.Equal(t, "", deployKey)
+
+	secret.Data["key"] = []byte("garbage")
+	_, err = DeployKey(cr, secret)
+	assert.NotNil(t, err)
+}
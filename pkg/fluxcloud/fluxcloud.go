@@ -3,6 +3,8 @@ package fluxcloud
 import (
 	"fmt"
 	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/kinds"
 	"github.com/justinbarrick/flux-operator/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
@@ -12,6 +14,11 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// Register the kinds of objects this package creates for Fluxes.
+func init() {
+	kinds.Register(kinds.Deployment, kinds.Service)
+}
+
 // Generate fluxcloud name
 func FluxcloudName(cr *v1alpha1.Flux) string {
 	return fmt.Sprintf("flux-%s-fluxcloud", cr.ObjectMeta.Name)
@@ -46,17 +53,30 @@ func NewFluxcloudService(cr *v1alpha1.Flux) *corev1.Service {
 
 // Returns the image name for a fluxcloud instance.
 func FluxcloudImage(cr *v1alpha1.Flux) string {
-	fluxcloudImage := utils.Getenv("FLUXCLOUD_IMAGE", cr.Spec.FluxCloud.FluxCloudImage)
+	fluxcloudImage := cr.Spec.FluxCloud.FluxCloudImage
+	if config.Get().FluxcloudImage != "" {
+		fluxcloudImage = config.Get().FluxcloudImage
+	}
+
 	if fluxcloudImage == "" {
 		fluxcloudImage = utils.FluxcloudImage
 	}
 
-	fluxcloudVersion := utils.Getenv("FLUXCLOUD_VERSION", cr.Spec.FluxCloud.FluxCloudVersion)
+	return fmt.Sprintf("%s:%s", fluxcloudImage, FluxcloudVersion(cr))
+}
+
+// Returns the version of a fluxcloud instance.
+func FluxcloudVersion(cr *v1alpha1.Flux) string {
+	fluxcloudVersion := cr.Spec.FluxCloud.FluxCloudVersion
+	if config.Get().FluxcloudVersion != "" {
+		fluxcloudVersion = config.Get().FluxcloudVersion
+	}
+
 	if fluxcloudVersion == "" {
 		fluxcloudVersion = utils.FluxcloudVersion
 	}
 
-	return fmt.Sprintf("%s:%s", fluxcloudImage, fluxcloudVersion)
+	return fluxcloudVersion
 }
 
 // NewFluxcloudDeployment creates a new fluxcloud deployment
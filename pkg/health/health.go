@@ -0,0 +1,171 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// The port that the health endpoints are served on.
+	Port = 8080
+	// The path of the liveness endpoint.
+	LivenessPath = "/healthz"
+	// The path of the readiness endpoint.
+	ReadinessPath = "/readyz"
+	// A reconcile running for longer than this is considered wedged.
+	DefaultStallTimeout = 5 * time.Minute
+)
+
+// Tracks the liveness and readiness of flux-operator and the reconciles that
+// are currently in flight.
+type Checker struct {
+	// A reconcile that has been running for longer than StallTimeout fails the
+	// liveness check.
+	StallTimeout time.Duration
+
+	lock         sync.Mutex
+	ready        bool
+	shuttingDown bool
+	nextId       int
+	inFlight     map[int]time.Time
+	drained      *sync.Cond
+}
+
+// Create a new Checker that is not yet ready.
+func NewChecker() *Checker {
+	checker := &Checker{
+		StallTimeout: DefaultStallTimeout,
+		inFlight:     map[int]time.Time{},
+	}
+	checker.drained = sync.NewCond(&checker.lock)
+	return checker
+}
+
+// Mark flux-operator as ready or not ready to serve.
+func (c *Checker) SetReady(ready bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.ready = ready
+}
+
+// Return true if flux-operator is ready and is not shutting down.
+func (c *Checker) Ready() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.ready && !c.shuttingDown
+}
+
+// Return an error if any reconcile has been running for longer than the
+// StallTimeout.
+func (c *Checker) Alive() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, started := range c.inFlight {
+		if running := time.Since(started); running > c.StallTimeout {
+			return fmt.Errorf("reconcile has been running for %s", running)
+		}
+	}
+
+	return nil
+}
+
+// Record the start of a reconcile, the returned function must be called when
+// it completes. If flux-operator is shutting down, ok is false and the
+// reconcile should not be started.
+func (c *Checker) StartReconcile() (done func(), ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.shuttingDown {
+		return func() {}, false
+	}
+
+	id := c.nextId
+	c.nextId++
+	c.inFlight[id] = time.Now()
+
+	return func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		delete(c.inFlight, id)
+		c.drained.Broadcast()
+	}, true
+}
+
+// Stop accepting new reconciles and wait up to timeout for any in-flight
+// reconciles to complete. Returns false if the timeout was reached.
+func (c *Checker) Drain(timeout time.Duration) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.shuttingDown = true
+
+	timer := time.AfterFunc(timeout, func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.drained.Broadcast()
+	})
+	defer timer.Stop()
+
+	deadline := time.Now().Add(timeout)
+	for len(c.inFlight) > 0 {
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		c.drained.Wait()
+	}
+
+	return true
+}
+
+// Return an http.Handler serving the liveness and readiness endpoints.
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(LivenessPath, func(w http.ResponseWriter, r *http.Request) {
+		if err := c.Alive(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.HandleFunc(ReadinessPath, func(w http.ResponseWriter, r *http.Request) {
+		if !c.Ready() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprintln(w, "ok")
+	})
+
+	return mux
+}
+
+// Serve the health endpoints on address until ctx is cancelled.
+func (c *Checker) ListenAndServe(ctx context.Context, address string) error {
+	server := &http.Server{
+		Addr:    address,
+		Handler: c.Handler(),
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	logrus.Infof("Serving health checks on %s", address)
+	err := server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, checker *Checker, path string) int {
+	recorder := httptest.NewRecorder()
+	checker.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+	return recorder.Code
+}
+
+func TestReadiness(t *testing.T) {
+	checker := NewChecker()
+	assert.Equal(t, http.StatusServiceUnavailable, get(t, checker, ReadinessPath))
+
+	checker.SetReady(true)
+	assert.Equal(t, http.StatusOK, get(t, checker, ReadinessPath))
+
+	checker.SetReady(false)
+	assert.Equal(t, http.StatusServiceUnavailable, get(t, checker, ReadinessPath))
+}
+
+func TestNotReadyWhileDraining(t *testing.T) {
+	checker := NewChecker()
+	checker.SetReady(true)
+
+	assert.True(t, checker.Drain(time.Second))
+	assert.False(t, checker.Ready())
+	assert.Equal(t, http.StatusServiceUnavailable, get(t, checker, ReadinessPath))
+}
+
+func TestLiveness(t *testing.T) {
+	checker := NewChecker()
+	assert.Equal(t, http.StatusOK, get(t, checker, LivenessPath))
+
+	done, ok := checker.StartReconcile()
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusOK, get(t, checker, LivenessPath))
+	done()
+}
+
+func TestLivenessStalledReconcile(t *testing.T) {
+	checker := NewChecker()
+	checker.StallTimeout = time.Millisecond
+
+	done, _ := checker.StartReconcile()
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, http.StatusInternalServerError, get(t, checker, LivenessPath))
+
+	done()
+	assert.Equal(t, http.StatusOK, get(t, checker, LivenessPath))
+}
+
+func TestDrainWaitsForReconciles(t *testing.T) {
+	checker := NewChecker()
+	done, _ := checker.StartReconcile()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done()
+	}()
+
+	assert.True(t, checker.Drain(time.Second))
+
+	_, ok := checker.StartReconcile()
+	assert.False(t, ok)
+}
+
+func TestDrainTimeout(t *testing.T) {
+	checker := NewChecker()
+	done, _ := checker.StartReconcile()
+	defer done()
+
+	assert.False(t, checker.Drain(10*time.Millisecond))
+}
@@ -3,7 +3,9 @@ package helm_operator
 import (
 	"fmt"
 	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
 	"github.com/justinbarrick/flux-operator/pkg/flux"
+	"github.com/justinbarrick/flux-operator/pkg/kinds"
 	"github.com/justinbarrick/flux-operator/pkg/rbac"
 	"github.com/justinbarrick/flux-operator/pkg/tiller"
 	"github.com/justinbarrick/flux-operator/pkg/utils"
@@ -14,6 +16,11 @@ import (
 	"sort"
 )
 
+// Register the kinds of objects this package creates for Fluxes.
+func init() {
+	kinds.Register(kinds.Deployment)
+}
+
 // Create helm-operator command arguments from CR
 func MakeHelmOperatorArgs(cr *v1alpha1.Flux) (args []string) {
 	path := cr.Spec.HelmOperator.ChartPath
@@ -63,22 +70,29 @@ func MakeHelmOperatorArgs(cr *v1alpha1.Flux) (args []string) {
 	return
 }
 
-// NewHelmOperatorDeployment creates a new helm-operator deployment
-func NewHelmOperatorDeployment(cr *v1alpha1.Flux) *extensions.Deployment {
-	if !cr.Spec.HelmOperator.Enabled {
-		return nil
-	}
-
-	operatorImage := utils.Getenv("HELM_OPERATOR_IMAGE", utils.HelmOperatorImage)
+// Return the helm-operator image and version to run for the CR.
+func HelmOperatorImage(cr *v1alpha1.Flux) (string, string) {
+	operatorImage := config.Get().HelmOperatorImage
 	if cr.Spec.HelmOperator.HelmOperatorImage != "" {
 		operatorImage = cr.Spec.HelmOperator.HelmOperatorImage
 	}
 
-	operatorVersion := utils.Getenv("HELM_OPERATOR_VERSION", utils.HelmOperatorVersion)
+	operatorVersion := config.Get().HelmOperatorVersion
 	if cr.Spec.HelmOperator.HelmOperatorVersion != "" {
 		operatorVersion = cr.Spec.HelmOperator.HelmOperatorVersion
 	}
 
+	return operatorImage, operatorVersion
+}
+
+// NewHelmOperatorDeployment creates a new helm-operator deployment
+func NewHelmOperatorDeployment(cr *v1alpha1.Flux) *extensions.Deployment {
+	if !cr.Spec.HelmOperator.Enabled {
+		return nil
+	}
+
+	operatorImage, operatorVersion := HelmOperatorImage(cr)
+
 	labels := map[string]string{
 		"app":  "helm-operator",
 		"flux": cr.Name,
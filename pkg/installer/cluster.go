@@ -0,0 +1,442 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/crd"
+	"github.com/justinbarrick/flux-operator/pkg/desired"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// The resource names of the kinds created by the installer that are not created
+// for Fluxes.
+var resourceNames = map[string]string{
+	"CustomResourceDefinition": "customresourcedefinitions",
+	"PodDisruptionBudget":      "poddisruptionbudgets",
+}
+
+// Return the resource name of kind, either one of the kinds created by the
+// installer or for Fluxes.
+func resourceName(kind string) (string, bool) {
+	if resource, ok := resourceNames[kind]; ok {
+		return resource, true
+	}
+
+	for _, registered := range desired.Kinds() {
+		if registered.Kind == kind {
+			return registered.Resource, true
+		}
+	}
+
+	return "", false
+}
+
+// Applies flux-operator's objects to a cluster through the Kubernetes REST API.
+type Client struct {
+	rest rest.Interface
+	// How long to wait for the CRDs and the flux-operator Deployment to become
+	// ready (default: 5 minutes).
+	Timeout time.Duration
+	// How often to check if they are ready (default: 2 seconds).
+	PollInterval time.Duration
+}
+
+// Create a client for the cluster in kubeconfig, or the default kubeconfig if
+// empty, using context if it is set.
+func NewClientFromKubeconfig(kubeconfig, context string) (*Client, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{
+		CurrentContext: context,
+	}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(config)
+}
+
+// Create a client for the cluster in config.
+func NewClient(config *rest.Config) (*Client, error) {
+	config = rest.CopyConfig(config)
+	config.APIPath = "/"
+	config.GroupVersion = &schema.GroupVersion{}
+	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: scheme.Codecs}
+
+	restClient, err := rest.UnversionedRESTClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		rest:         restClient,
+		Timeout:      5 * time.Minute,
+		PollInterval: 2 * time.Second,
+	}, nil
+}
+
+// Return the API path of the collection of resources in namespace.
+func collectionPath(apiVersion, resource, namespace string) string {
+	path := []string{"/apis", apiVersion}
+	if apiVersion == "v1" {
+		path = []string{"/api", apiVersion}
+	}
+
+	if namespace != "" {
+		path = append(path, "namespaces", namespace)
+	}
+
+	return strings.Join(append(path, resource), "/")
+}
+
+// Return the API path of the collection obj belongs in and its name.
+func objectPath(obj runtime.Object) (string, string, error) {
+	kind := obj.GetObjectKind().GroupVersionKind()
+
+	resource, ok := resourceName(kind.Kind)
+	if !ok {
+		return "", "", fmt.Errorf("unknown kind %s", kind.Kind)
+	}
+
+	objectMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return "", "", err
+	}
+
+	apiVersion, _ := kind.ToAPIVersionAndKind()
+	return collectionPath(apiVersion, resource, objectMeta.GetNamespace()), objectMeta.GetName(), nil
+}
+
+// Return a human readable name for obj.
+func objectName(obj runtime.Object) string {
+	objectMeta, _ := meta.Accessor(obj)
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+
+	if objectMeta.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", kind, objectMeta.GetName())
+	}
+
+	return fmt.Sprintf("%s %s/%s", kind, objectMeta.GetNamespace(), objectMeta.GetName())
+}
+
+// Fetch the object at path into out, which may be nil.
+func (c *Client) get(path string, out interface{}) error {
+	body, err := c.rest.Get().AbsPath(path).DoRaw()
+	if err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// List the objects in the collection at path matching the label selector into out.
+func (c *Client) list(path, selector string, out interface{}) error {
+	body, err := c.rest.Get().AbsPath(path).Param("labelSelector", selector).DoRaw()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// Return true if obj exists.
+func (c *Client) Exists(obj runtime.Object) (bool, error) {
+	collection, name, err := objectPath(obj)
+	if err != nil {
+		return false, err
+	}
+
+	err = c.get(collection+"/"+name, nil)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Create obj or replace it if it already exists. ServiceAccounts are not
+// replaced so that their token secrets are kept, and CRDs keep the CA bundle
+// that flux-operator or an admin set on their conversion webhook.
+func (c *Client) Apply(obj runtime.Object) error {
+	collection, name, err := objectPath(obj)
+	if err != nil {
+		return err
+	}
+
+	existing := metav1.ObjectMeta{}
+	err = c.get(collection+"/"+name, &struct {
+		Metadata *metav1.ObjectMeta `json:"metadata"`
+	}{&existing})
+
+	if errors.IsNotFound(err) {
+		logrus.Infof("Creating %s", objectName(obj))
+
+		body, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+
+		return c.rest.Post().AbsPath(collection).SetHeader("Content-Type", "application/json").Body(body).Do().Error()
+	} else if err != nil {
+		return err
+	}
+
+	if obj.GetObjectKind().GroupVersionKind().Kind == "ServiceAccount" {
+		return nil
+	}
+
+	logrus.Infof("Updating %s", objectName(obj))
+
+	obj = obj.DeepCopyObject()
+	objectMeta, _ := meta.Accessor(obj)
+	objectMeta.SetResourceVersion(existing.ResourceVersion)
+
+	if definition, ok := obj.(*crd.CustomResourceDefinition); ok {
+		if err := c.keepCABundle(collection+"/"+name, definition); err != nil {
+			return err
+		}
+	}
+
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	return c.rest.Put().AbsPath(collection, name).SetHeader("Content-Type", "application/json").Body(body).Do().Error()
+}
+
+// Set the CA bundle of definition's conversion webhook to that of the existing
+// CRD at path, unless definition sets one.
+func (c *Client) keepCABundle(path string, definition *crd.CustomResourceDefinition) error {
+	clientConfig := definition.WebhookClientConfig()
+	if clientConfig == nil || len(clientConfig.CABundle) != 0 {
+		return nil
+	}
+
+	current := &crd.CustomResourceDefinition{}
+	if err := c.get(path, current); err != nil {
+		return err
+	}
+
+	if currentConfig := current.WebhookClientConfig(); currentConfig != nil {
+		clientConfig.CABundle = currentConfig.CABundle
+	}
+
+	return nil
+}
+
+// Delete obj, it is not an error if it does not exist.
+func (c *Client) Delete(obj runtime.Object) error {
+	collection, name, err := objectPath(obj)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Deleting %s", objectName(obj))
+
+	err = c.rest.Delete().AbsPath(collection, name).Do().Error()
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Wait until the CRD has been established.
+func (c *Client) WaitForCRD(definition *crd.CustomResourceDefinition) error {
+	logrus.Infof("Waiting for %s to be established", objectName(definition))
+
+	collection, name, err := objectPath(definition)
+	if err != nil {
+		return err
+	}
+
+	return wait.PollImmediate(c.PollInterval, c.Timeout, func() (bool, error) {
+		current := &crd.CustomResourceDefinition{}
+		if err := c.get(collection+"/"+name, current); err != nil {
+			return false, err
+		}
+
+		return current.IsEstablished(), nil
+	})
+}
+
+// Wait until every replica of the Deployment has been updated and is available.
+func (c *Client) WaitForDeployment(deployment *appsv1.Deployment) error {
+	logrus.Infof("Waiting for %s to be ready", objectName(deployment))
+
+	collection, name, err := objectPath(deployment)
+	if err != nil {
+		return err
+	}
+
+	return wait.PollImmediate(c.PollInterval, c.Timeout, func() (bool, error) {
+		current := &appsv1.Deployment{}
+		if err := c.get(collection+"/"+name, current); err != nil {
+			return false, err
+		}
+
+		replicas := int32(1)
+		if current.Spec.Replicas != nil {
+			replicas = *current.Spec.Replicas
+		}
+
+		status := current.Status
+		return status.ObservedGeneration >= current.Generation && status.UpdatedReplicas == replicas &&
+			status.AvailableReplicas == replicas, nil
+	})
+}
+
+// Return the number of Fluxes in namespaces, or in the whole cluster if
+// namespaces is empty. There are none if the Flux CRD is not installed.
+func (c *Client) CountFluxes(namespaces []string) (int, error) {
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	count := 0
+	for _, namespace := range namespaces {
+		fluxes := &struct {
+			Items []json.RawMessage `json:"items"`
+		}{}
+
+		err := c.get(collectionPath("flux.codesink.net/v1alpha1", "fluxes", namespace), fluxes)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+
+		count += len(fluxes.Items)
+	}
+
+	return count, nil
+}
+
+// Return the objects of a flux-operator that should exist, skipping nils.
+func installObjects(config FluxOperatorConfig) []runtime.Object {
+	objects := []runtime.Object{}
+	for _, obj := range NewFluxOperator(config) {
+		if !reflect.ValueOf(obj).IsNil() {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
+// Apply every object of a flux-operator, waiting for the CRDs before the rest
+// of the objects are created and then for the Deployment to become ready.
+func apply(client *Client, config FluxOperatorConfig) error {
+	objects := installObjects(config)
+
+	for _, obj := range objects {
+		if err := client.Apply(obj); err != nil {
+			return fmt.Errorf("failed to apply %s: %v", objectName(obj), err)
+		}
+
+		if definition, ok := obj.(*crd.CustomResourceDefinition); ok {
+			if err := client.WaitForCRD(definition); err != nil {
+				return fmt.Errorf("%s was not established: %v", objectName(definition), err)
+			}
+		}
+	}
+
+	// A PodDisruptionBudget left over from more replicas would block evictions.
+	if NewPodDisruptionBudget(config) == nil {
+		budget := newPodDisruptionBudget(config)
+		if err := client.Delete(budget); err != nil {
+			return fmt.Errorf("failed to delete %s: %v", objectName(budget), err)
+		}
+	}
+
+	deployment := NewFluxOperatorDeployment(config)
+	if err := client.WaitForDeployment(deployment); err != nil {
+		return fmt.Errorf("%s did not become ready: %v", objectName(deployment), err)
+	}
+
+	return nil
+}
+
+// Install flux-operator into the cluster, failing if it is already installed.
+func Install(client *Client, config FluxOperatorConfig) error {
+	exists, err := client.Exists(NewFluxOperatorDeployment(config))
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return fmt.Errorf("flux-operator %s is already installed in %s, use upgrade instead", GetName(config), GetNamespace(config))
+	}
+
+	return apply(client, config)
+}
+
+// Upgrade an installed flux-operator, failing if it is not installed.
+func Upgrade(client *Client, config FluxOperatorConfig) error {
+	exists, err := client.Exists(NewFluxOperatorDeployment(config))
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("flux-operator %s is not installed in %s, use install instead", GetName(config), GetNamespace(config))
+	}
+
+	return apply(client, config)
+}
+
+// Remove flux-operator and its CRDs from the cluster. Deleting the CRDs deletes
+// every Flux, so it is refused while any exist unless force is set.
+func Uninstall(client *Client, config FluxOperatorConfig, force bool) error {
+	// The CRDs hold the Fluxes of every namespace, not only the watched ones. In
+	// namespaced mode the CRDs are left in place.
+	namespaces := []string{""}
+	if config.Namespaced {
+		namespaces = GetWatchNamespaces(config)
+	}
+
+	count, err := client.CountFluxes(namespaces)
+	if err != nil {
+		return fmt.Errorf("failed to check for Fluxes: %v", err)
+	}
+
+	if count > 0 && !force {
+		return fmt.Errorf("refusing to uninstall flux-operator while %d Fluxes exist, delete them first or force it", count)
+	}
+
+	// The PodDisruptionBudget is only part of the install with more than one
+	// replica, but may have been created by an earlier install. The webhook's
+	// Secret is created by flux-operator and deleted after it is stopped.
+	objects := []runtime.Object{newWebhookSecret(config)}
+	objects = append(objects, installObjects(config)...)
+	if NewPodDisruptionBudget(config) == nil {
+		objects = append(objects, newPodDisruptionBudget(config))
+	}
+
+	for index := len(objects) - 1; index >= 0; index-- {
+		if err := client.Delete(objects[index]); err != nil {
+			return fmt.Errorf("failed to delete %s: %v", objectName(objects[index]), err)
+		}
+	}
+
+	return nil
+}
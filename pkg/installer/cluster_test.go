@@ -0,0 +1,316 @@
+package installer
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A fake Kubernetes API server storing objects as JSON by path. CRDs are
+// established and Deployments become ready as soon as they are stored.
+type fakeAPIServer struct {
+	lock    sync.Mutex
+	objects map[string]map[string]interface{}
+	server  *httptest.Server
+}
+
+func newFakeAPIServer() *fakeAPIServer {
+	fake := &fakeAPIServer{objects: map[string]map[string]interface{}{}}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	return fake
+}
+
+func (f *fakeAPIServer) status(w http.ResponseWriter, code int, reason string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"kind":       "Status",
+		"apiVersion": "v1",
+		"status":     "Failure",
+		"reason":     reason,
+		"code":       code,
+	})
+}
+
+func (f *fakeAPIServer) store(path string, obj map[string]interface{}) {
+	kind := obj["kind"]
+	if kind == "CustomResourceDefinition" {
+		obj["status"] = map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Established", "status": "True"},
+			},
+		}
+	} else if kind == "Deployment" {
+		replicas := float64(1)
+		if spec, ok := obj["spec"].(map[string]interface{}); ok && spec["replicas"] != nil {
+			replicas = spec["replicas"].(float64)
+		}
+		obj["status"] = map[string]interface{}{
+			"updatedReplicas":   replicas,
+			"availableReplicas": replicas,
+		}
+	}
+	f.objects[path] = obj
+}
+
+func (f *fakeAPIServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	path := r.URL.Path
+
+	switch r.Method {
+	case "GET":
+		if obj, ok := f.objects[path]; ok {
+			json.NewEncoder(w).Encode(obj)
+			return
+		}
+
+		if isCollection(path) {
+			selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+			if err != nil {
+				f.status(w, http.StatusBadRequest, "BadRequest")
+				return
+			}
+
+			items := []interface{}{}
+			for key, obj := range f.objects {
+				if !inCollection(key, path) {
+					continue
+				}
+
+				metadata, _ := obj["metadata"].(map[string]interface{})
+				objLabels := labels.Set{}
+				if found, ok := metadata["labels"].(map[string]interface{}); ok {
+					for k, v := range found {
+						objLabels[k] = v.(string)
+					}
+				}
+
+				if selector.Matches(objLabels) {
+					items = append(items, obj)
+				}
+			}
+
+			json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+			return
+		}
+
+		f.status(w, http.StatusNotFound, "NotFound")
+	case "POST", "PUT":
+		body, _ := ioutil.ReadAll(r.Body)
+		obj := map[string]interface{}{}
+		json.Unmarshal(body, &obj)
+
+		if r.Method == "POST" {
+			path = path + "/" + obj["metadata"].(map[string]interface{})["name"].(string)
+			if _, ok := f.objects[path]; ok {
+				f.status(w, http.StatusConflict, "AlreadyExists")
+				return
+			}
+		} else if _, ok := f.objects[path]; !ok {
+			f.status(w, http.StatusNotFound, "NotFound")
+			return
+		}
+
+		f.store(path, obj)
+		json.NewEncoder(w).Encode(obj)
+	case "DELETE":
+		if _, ok := f.objects[path]; !ok {
+			f.status(w, http.StatusNotFound, "NotFound")
+			return
+		}
+
+		delete(f.objects, path)
+		f.status(w, http.StatusOK, "")
+	}
+}
+
+// Return true if path is a collection that can be listed.
+func isCollection(path string) bool {
+	for _, resource := range []string{"/fluxes", "/deployments", "/services", "/configmaps"} {
+		if strings.HasSuffix(path, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return true if the object at key is in the collection at path, a collection
+// without a namespace includes the objects in every namespace.
+func inCollection(key, path string) bool {
+	collection := key[:strings.LastIndex(key, "/")]
+	if collection == path {
+		return true
+	}
+
+	group := path[:strings.LastIndex(path, "/")]
+	resource := path[len(group):]
+	if !strings.HasPrefix(collection, group+"/namespaces/") || !strings.HasSuffix(collection, resource) {
+		return false
+	}
+
+	return !strings.Contains(strings.TrimSuffix(collection[len(group+"/namespaces/"):], resource), "/")
+}
+
+func (f *fakeAPIServer) has(path string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	_, ok := f.objects[path]
+	return ok
+}
+
+func newTestClient(t *testing.T, fake *fakeAPIServer) *Client {
+	client, err := NewClient(&rest.Config{Host: fake.server.URL})
+	assert.Nil(t, err)
+	client.Timeout = time.Second
+	client.PollInterval = 10 * time.Millisecond
+	return client
+}
+
+func TestInstall(t *testing.T) {
+	fake := newFakeAPIServer()
+	defer fake.server.Close()
+	client := newTestClient(t, fake)
+
+	config := FluxOperatorConfig{Namespace: "flux"}
+	assert.Nil(t, Install(client, config))
+
+	assert.True(t, fake.has("/apis/apiextensions.k8s.io/v1/customresourcedefinitions/fluxes.flux.codesink.net"))
+	assert.True(t, fake.has("/apis/apps/v1/namespaces/flux/deployments/flux-operator"))
+	assert.True(t, fake.has("/api/v1/namespaces/flux/serviceaccounts/flux-operator"))
+	assert.True(t, fake.has("/apis/rbac.authorization.k8s.io/v1/clusterroles/flux-operator"))
+	assert.True(t, fake.has("/api/v1/namespaces/flux/configmaps/flux-operator-config"))
+
+	assert.NotNil(t, Install(client, config))
+}
+
+func TestUpgrade(t *testing.T) {
+	fake := newFakeAPIServer()
+	defer fake.server.Close()
+	client := newTestClient(t, fake)
+
+	config := FluxOperatorConfig{}
+	assert.NotNil(t, Upgrade(client, config))
+
+	assert.Nil(t, Install(client, config))
+
+	config.FluxOperatorVersion = "v1.0.0"
+	assert.Nil(t, Upgrade(client, config))
+
+	fake.lock.Lock()
+	deployment := fake.objects["/apis/apps/v1/namespaces/default/deployments/flux-operator"]
+	fake.lock.Unlock()
+
+	containers := deployment["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})
+	assert.Equal(t, GetFluxOperatorImage(config), containers[0].(map[string]interface{})["image"])
+}
+
+func TestWaitForDeploymentTimesOut(t *testing.T) {
+	fake := newFakeAPIServer()
+	defer fake.server.Close()
+	client := newTestClient(t, fake)
+	client.Timeout = 50 * time.Millisecond
+
+	config := FluxOperatorConfig{Replicas: 2}
+	assert.Nil(t, apply(client, config))
+
+	fake.lock.Lock()
+	fake.objects["/apis/apps/v1/namespaces/default/deployments/flux-operator"]["status"] = map[string]interface{}{
+		"updatedReplicas": 1,
+	}
+	fake.lock.Unlock()
+
+	assert.NotNil(t, client.WaitForDeployment(NewFluxOperatorDeployment(config)))
+}
+
+func TestUninstall(t *testing.T) {
+	fake := newFakeAPIServer()
+	defer fake.server.Close()
+	client := newTestClient(t, fake)
+
+	config := FluxOperatorConfig{}
+	assert.Nil(t, Install(client, config))
+
+	fluxPath := "/apis/flux.codesink.net/v1alpha1/fluxes/example"
+	fake.store(fluxPath, map[string]interface{}{"kind": "Flux"})
+
+	secretPath := "/api/v1/namespaces/default/secrets/flux-operator-webhook"
+	fake.store(secretPath, map[string]interface{}{"kind": "Secret"})
+
+	err := Uninstall(client, config, false)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "1 Fluxes exist")
+	assert.True(t, fake.has("/apis/apps/v1/namespaces/default/deployments/flux-operator"))
+
+	assert.Nil(t, Uninstall(client, config, true))
+	assert.False(t, fake.has("/apis/apps/v1/namespaces/default/deployments/flux-operator"))
+	assert.False(t, fake.has("/apis/apiextensions.k8s.io/v1/customresourcedefinitions/fluxes.flux.codesink.net"))
+	assert.False(t, fake.has("/apis/rbac.authorization.k8s.io/v1/clusterroles/flux-operator"))
+	assert.False(t, fake.has(secretPath))
+}
+
+func TestUpgradeKeepsCABundle(t *testing.T) {
+	fake := newFakeAPIServer()
+	defer fake.server.Close()
+	client := newTestClient(t, fake)
+
+	config := FluxOperatorConfig{}
+	assert.Nil(t, Install(client, config))
+
+	crdPath := "/apis/apiextensions.k8s.io/v1/customresourcedefinitions/fluxes.flux.codesink.net"
+	clientConfig := func() map[string]interface{} {
+		fake.lock.Lock()
+		defer fake.lock.Unlock()
+
+		conversion := fake.objects[crdPath]["spec"].(map[string]interface{})["conversion"]
+		return conversion.(map[string]interface{})["webhook"].(map[string]interface{})["clientConfig"].(map[string]interface{})
+	}
+
+	assert.Nil(t, clientConfig()["caBundle"])
+	clientConfig()["caBundle"] = "Y2E="
+
+	assert.Nil(t, Upgrade(client, config))
+	assert.Equal(t, "Y2E=", clientConfig()["caBundle"])
+}
+
+func TestUninstallCountsFluxesInEveryNamespace(t *testing.T) {
+	fake := newFakeAPIServer()
+	defer fake.server.Close()
+	client := newTestClient(t, fake)
+
+	config := FluxOperatorConfig{FluxNamespace: "team-a"}
+	assert.Nil(t, Install(client, config))
+
+	fake.store("/apis/flux.codesink.net/v1alpha1/namespaces/team-b/fluxes/example", map[string]interface{}{"kind": "Flux"})
+
+	err := Uninstall(client, config, false)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "1 Fluxes exist")
+}
+
+func TestUninstallDeletesPodDisruptionBudget(t *testing.T) {
+	fake := newFakeAPIServer()
+	defer fake.server.Close()
+	client := newTestClient(t, fake)
+
+	pdbPath := "/apis/policy/v1/namespaces/default/poddisruptionbudgets/flux-operator"
+
+	assert.Nil(t, Install(client, FluxOperatorConfig{Replicas: 3}))
+	assert.True(t, fake.has(pdbPath))
+
+	assert.Nil(t, Upgrade(client, FluxOperatorConfig{Replicas: 1}))
+	assert.False(t, fake.has(pdbPath))
+
+	assert.Nil(t, Upgrade(client, FluxOperatorConfig{Replicas: 3}))
+	assert.Nil(t, Uninstall(client, FluxOperatorConfig{}, false))
+	assert.False(t, fake.has(pdbPath))
+}
@@ -2,20 +2,29 @@ package installer
 
 import (
 	"fmt"
-	crdutils "github.com/ant31/crd-validation/pkg"
+	"github.com/go-openapi/spec"
 	v1alpha1 "github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha2"
+	operatorconfig "github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/crd"
+	"github.com/justinbarrick/flux-operator/pkg/desired"
+	"github.com/justinbarrick/flux-operator/pkg/health"
+	"github.com/justinbarrick/flux-operator/pkg/render"
 	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/justinbarrick/flux-operator/pkg/webhook"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	v1beta1 "k8s.io/api/extensions/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	rbacv1 "k8s.io/api/rbac/v1"
-	extensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/kube-openapi/pkg/common"
 	"os"
-	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 // Represents the configuration for a flux-operator instance.
@@ -26,10 +35,14 @@ type FluxOperatorConfig struct {
 	Namespace string
 	// Whether or not flux-operator should be namespace or cluster scoped.
 	Cluster bool
+	// Only grant flux-operator roles in the namespaces it watches instead of a
+	// cluster role, so that it can be installed without cluster-admin. Cluster
+	// roles are disabled and the CRDs must be installed separately.
+	Namespaced bool
 	// The service account to assign to flux-operator, otherwise one is created.
 	ServiceAccount string
 	// A cluster role to assign to flux-operator (default: a role is created with
-	// full privileges)
+	// the privileges flux-operator needs)
 	ClusterRole string
 	// Do not change any RBAC settings when creating flux-operator.
 	DisableRBAC bool
@@ -37,6 +50,9 @@ type FluxOperatorConfig struct {
 	DisableClusterRoles bool
 	// Do not allow flux-operator to create any roles.
 	DisableRoles bool
+	// Only report the changes flux-operator would make to each Flux's status
+	// instead of applying them.
+	PlanMode bool
 	// The default git secret to use for fluxes.
 	GitSecret string
 	// The flux operator image name
@@ -60,8 +76,16 @@ type FluxOperatorConfig struct {
 	// The flux image version
 	TillerVersion string
 	// If set, restricts flux-operator to look for new Fluxes only in the specified
-	// namespace.
+	// comma separated namespaces.
 	FluxNamespace string
+	// The number of flux-operator replicas to run (default: 1)
+	Replicas int32
+	// How long standby replicas wait before taking over the leader lock (default: `15s`)
+	LeaseDuration string
+	// How long the leader retries renewing the leader lock before giving it up (default: `10s`)
+	RenewDeadline string
+	// How long replicas wait between attempts to acquire or renew the leader lock (default: `2s`)
+	RetryPeriod string
 }
 
 // Return the name that should be used for flux-operator resources.
@@ -102,8 +126,78 @@ func GetNamespace(config FluxOperatorConfig) string {
 	}
 }
 
-// Return the flux operator image name.
-func GetFluxOperatorImage(config FluxOperatorConfig) string {
+// Return the namespaces flux-operator watches for Fluxes, empty if it watches
+// every namespace. In namespaced mode flux-operator's own namespace is watched
+// if none are set.
+func GetWatchNamespaces(config FluxOperatorConfig) []string {
+	namespaces := []string{}
+	for _, namespace := range strings.Split(config.FluxNamespace, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	if config.Namespaced && len(namespaces) == 0 {
+		namespaces = append(namespaces, GetNamespace(config))
+	}
+
+	return namespaces
+}
+
+// Return an error if the config can not be installed.
+func Validate(config FluxOperatorConfig) error {
+	if config.Namespaced && config.Cluster {
+		return fmt.Errorf("a namespaced flux-operator can not use a Cluster scoped Flux CRD, it has no cluster wide permissions")
+	}
+
+	return nil
+}
+
+// Return the number of flux-operator replicas.
+func GetReplicas(config FluxOperatorConfig) int32 {
+	if config.Replicas > 0 {
+		return config.Replicas
+	} else {
+		return 1
+	}
+}
+
+// Return the name of the leader election lock.
+func GetLeaderLockName(config FluxOperatorConfig) string {
+	return fmt.Sprintf("%s-leader", GetName(config))
+}
+
+// Return the name of the ConfigMap holding the operator wide defaults for Fluxes.
+func GetConfigMapName(config FluxOperatorConfig) string {
+	return fmt.Sprintf("%s-config", GetName(config))
+}
+
+// Return the name of the conversion webhook's Service and certificate Secret.
+func GetWebhookName(config FluxOperatorConfig) string {
+	return fmt.Sprintf("%s-webhook", GetName(config))
+}
+
+// Return the operator wide defaults for Fluxes, unset settings use the built in defaults.
+func GetOperatorConfig(config FluxOperatorConfig) operatorconfig.Config {
+	return operatorconfig.Config{
+		GitSecretName:       config.GitSecret,
+		FluxImage:           config.FluxImage,
+		FluxVersion:         config.FluxVersion,
+		HelmOperatorImage:   config.HelmOperatorImage,
+		HelmOperatorVersion: config.HelmOperatorVersion,
+		MemcachedImage:      config.MemcachedImage,
+		MemcachedVersion:    config.MemcachedVersion,
+		TillerImage:         config.TillerImage,
+		TillerVersion:       config.TillerVersion,
+		DisableRoles:        config.DisableRoles,
+		DisableClusterRoles: config.DisableClusterRoles || config.Namespaced,
+		PlanMode:            config.PlanMode,
+	}
+}
+
+// Return the flux operator image name and version.
+func getFluxOperatorImageAndVersion(config FluxOperatorConfig) (string, string) {
 	image := utils.FluxOperatorImage
 	version := "latest"
 
@@ -115,79 +209,164 @@ func GetFluxOperatorImage(config FluxOperatorConfig) string {
 		version = config.FluxOperatorVersion
 	}
 
+	return image, version
+}
+
+// Return the flux operator image name.
+func GetFluxOperatorImage(config FluxOperatorConfig) string {
+	image, version := getFluxOperatorImageAndVersion(config)
 	return fmt.Sprintf("%s:%s", image, version)
 }
 
-// Create Flux CRD
-func NewFluxCRD(config FluxOperatorConfig) *extensions.CustomResourceDefinition {
+// Return the OpenAPI definitions of every version of the flux.codesink.net types.
+func openAPIDefinitions() map[string]common.OpenAPIDefinition {
+	ref := func(path string) spec.Ref {
+		return spec.MustCreateRef(path)
+	}
+
+	definitions := v1alpha1.GetOpenAPIDefinitions(ref)
+	for name, definition := range v1alpha2.GetOpenAPIDefinitions(ref) {
+		definitions[name] = definition
+	}
+	return definitions
+}
+
+// Generates the schemas of the flux.codesink.net CRDs from their types.
+var schemas = crd.SchemaGenerator{
+	Definitions: openAPIDefinitions(),
+	Defaults: map[string]map[string]interface{}{
+		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxSpec": {
+			"gitBranch": "master",
+		},
+		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxRoleRef": {
+			"kind": "ClusterRole",
+		},
+		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha2.Git": {
+			"branch": "master",
+		},
+		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha2.FluxRoleRef": {
+			"kind": "ClusterRole",
+		},
+	},
+	Enums: map[string]map[string][]interface{}{
+		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.FluxRoleRef": {
+			"kind": {"Role", "ClusterRole"},
+		},
+		"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha2.FluxRoleRef": {
+			"kind": {"Role", "ClusterRole"},
+		},
+	},
+}
+
+// Return the printer columns of a version of the Flux CRD, given the paths of its
+// git URL and branch.
+func fluxPrinterColumns(gitURLPath, gitBranchPath string) []crd.CustomResourceColumnDefinition {
+	return []crd.CustomResourceColumnDefinition{
+		{Name: "Git URL", Type: "string", JSONPath: gitURLPath, Description: "The URL to the Git repository."},
+		{Name: "Branch", Type: "string", JSONPath: gitBranchPath, Description: "The git branch to use (default: `master`)."},
+		{Name: "Ready", Type: "boolean", JSONPath: ".status.ready", Description: "True if every Deployment of the Flux is available."},
+		{Name: "Flux Version", Type: "string", JSONPath: ".status.fluxVersion", Description: "The flux version the Flux runs."},
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	}
+}
+
+// Create Flux CRD, v1alpha1 is stored and v1alpha2 is converted to and from it
+// by flux-operator's conversion webhook.
+func NewFluxCRD(config FluxOperatorConfig) *crd.CustomResourceDefinition {
 	scope := "Namespaced"
 	if config.Cluster {
 		scope = "Cluster"
 	}
 
-	spec := "github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1.Flux"
-	crd := crdutils.NewCustomResourceDefinition(crdutils.Config{
-		SpecDefinitionName:    spec,
-		EnableValidation:      true,
-		ResourceScope:         scope,
-		Group:                 "flux.codesink.net",
-		Kind:                  "Flux",
-		Version:               "v1alpha1",
-		Plural:                "fluxes",
-		GetOpenAPIDefinitions: v1alpha1.GetOpenAPIDefinitions,
+	fluxCrd := crd.New("flux.codesink.net", "v1alpha1", "Flux", "fluxes", scope, schemas.Schema(v1alpha1.Flux{}))
+	fluxCrd.Spec.Names.ShortNames = []string{"flx"}
+	fluxCrd.Spec.Names.Categories = []string{"all-gitops"}
+
+	fluxCrd.Spec.Versions = append(fluxCrd.Spec.Versions, crd.CustomResourceDefinitionVersion{
+		Name:   "v1alpha2",
+		Served: true,
+		Schema: &crd.CustomResourceValidation{
+			OpenAPIV3Schema: schemas.Schema(v1alpha2.Flux{}),
+		},
 	})
-	crd.Status = extensions.CustomResourceDefinitionStatus{
-		Conditions:     []extensions.CustomResourceDefinitionCondition{},
-		StoredVersions: []string{},
+
+	for index := range fluxCrd.Spec.Versions {
+		version := &fluxCrd.Spec.Versions[index]
+		version.Subresources = &crd.CustomResourceSubresources{
+			Status: &crd.CustomResourceSubresourceStatus{},
+		}
+
+		if version.Name == "v1alpha1" {
+			version.AdditionalPrinterColumns = fluxPrinterColumns(".spec.gitUrl", ".spec.gitBranch")
+		} else {
+			version.AdditionalPrinterColumns = fluxPrinterColumns(".spec.git.url", ".spec.git.branch")
+		}
 	}
-	return crd
-}
 
-// Create a FluxHelmRelease CRD
-func NewFluxHelmReleaseCRD(FluxOperatorConfig) *extensions.CustomResourceDefinition {
-	return &extensions.CustomResourceDefinition{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "fluxhelmreleases.helm.integrations.flux.weave.works",
-		},
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "CustomResourceDefinition",
-			APIVersion: "apiextensions.k8s.io/v1beta1",
-		},
-		Spec: extensions.CustomResourceDefinitionSpec{
-			Group: "helm.integrations.flux.weave.works",
-			Names: extensions.CustomResourceDefinitionNames{
-				Kind:     "FluxHelmRelease",
-				ListKind: "FluxHelmReleaseList",
-				Plural:   "fluxhelmreleases",
+	fluxCrd.Spec.Conversion = &crd.CustomResourceConversion{
+		Strategy: "Webhook",
+		Webhook: &crd.WebhookConversion{
+			ClientConfig: &crd.WebhookClientConfig{
+				Service: &crd.ServiceReference{
+					Namespace: GetNamespace(config),
+					Name:      GetWebhookName(config),
+					Path:      webhook.ConvertPath,
+					Port:      443,
+				},
 			},
-			Scope:   "Namespaced",
-			Version: "v1alpha2",
+			ConversionReviewVersions: []string{"v1"},
 		},
-		Status: extensions.CustomResourceDefinitionStatus{
-			Conditions:     []extensions.CustomResourceDefinitionCondition{},
-			StoredVersions: []string{},
+	}
+
+	return fluxCrd
+}
+
+// Create the cluster scoped FluxPolicy CRD
+func NewFluxPolicyCRD(FluxOperatorConfig) *crd.CustomResourceDefinition {
+	return crd.New("flux.codesink.net", "v1alpha1", "FluxPolicy", "fluxpolicies", "Cluster", schemas.Schema(v1alpha1.FluxPolicy{}))
+}
+
+// Create a FluxHelmRelease CRD, its schema is owned by helm-operator so any
+// fields are kept.
+func NewFluxHelmReleaseCRD(FluxOperatorConfig) *crd.CustomResourceDefinition {
+	return crd.New("helm.integrations.flux.weave.works", "v1alpha2", "FluxHelmRelease", "fluxhelmreleases", "Namespaced", crd.PreserveUnknownFields())
+}
+
+// Create a probe that checks the flux-operator health endpoint at path.
+func NewHealthProbe(path string) *corev1.Probe {
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: intstr.FromString("health"),
+			},
 		},
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       10,
+		TimeoutSeconds:      5,
+		FailureThreshold:    3,
 	}
 }
 
 // Create a flux-operator deployment
-func NewFluxOperatorDeployment(config FluxOperatorConfig) *v1beta1.Deployment {
-	replicas := int32(1)
+func NewFluxOperatorDeployment(config FluxOperatorConfig) *appsv1.Deployment {
+	replicas := GetReplicas(config)
+	terminationGracePeriod := int64(30)
 
 	labels := map[string]string{
 		"app": "flux-operator",
 	}
 
-	return &v1beta1.Deployment{
+	return &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Deployment",
-			APIVersion: "extensions/v1beta1",
+			APIVersion: "apps/v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "flux-operator",
 			Namespace: GetNamespace(config),
 		},
-		Spec: v1beta1.DeploymentSpec{
+		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
@@ -197,64 +376,81 @@ func NewFluxOperatorDeployment(config FluxOperatorConfig) *v1beta1.Deployment {
 					Labels: labels,
 				},
 				Spec: corev1.PodSpec{
-					ServiceAccountName: GetServiceAccountName(config),
+					ServiceAccountName:            GetServiceAccountName(config),
+					TerminationGracePeriodSeconds: &terminationGracePeriod,
 					Containers: []corev1.Container{
 						{
 							Name:            "flux-operator",
 							Image:           GetFluxOperatorImage(config),
 							ImagePullPolicy: "IfNotPresent",
+							Ports: []corev1.ContainerPort{
+								corev1.ContainerPort{
+									Name:          "health",
+									ContainerPort: health.Port,
+								},
+								corev1.ContainerPort{
+									Name:          "webhook",
+									ContainerPort: webhook.Port,
+								},
+							},
+							LivenessProbe:  NewHealthProbe(health.LivenessPath),
+							ReadinessProbe: NewHealthProbe(health.ReadinessPath),
 							Env: []corev1.EnvVar{
 								corev1.EnvVar{
 									Name:  "WATCH_NAMESPACE",
 									Value: GetNamespace(config),
 								},
 								corev1.EnvVar{
-									Name:  "GIT_SECRET_NAME",
-									Value: config.GitSecret,
+									Name:  "OPERATOR_CONFIG",
+									Value: GetConfigMapName(config),
 								},
 								corev1.EnvVar{
-									Name:  "FLUX_IMAGE",
-									Value: config.FluxImage,
+									Name:  "FLUX_NAMESPACE",
+									Value: strings.Join(GetWatchNamespaces(config), ","),
 								},
 								corev1.EnvVar{
-									Name:  "FLUX_VERSION",
-									Value: config.FluxVersion,
+									Name:  "NAMESPACED",
+									Value: strconv.FormatBool(config.Namespaced),
 								},
 								corev1.EnvVar{
-									Name:  "HELM_OPERATOR_IMAGE",
-									Value: config.HelmOperatorImage,
+									Name:  "WEBHOOK_NAME",
+									Value: GetWebhookName(config),
 								},
 								corev1.EnvVar{
-									Name:  "HELM_OPERATOR_VERSION",
-									Value: config.HelmOperatorVersion,
+									Name:  "LEADER_ELECTION",
+									Value: "true",
 								},
 								corev1.EnvVar{
-									Name:  "MEMCACHED_IMAGE",
-									Value: config.MemcachedImage,
+									Name:  "LEADER_ELECTION_ID",
+									Value: GetLeaderLockName(config),
 								},
 								corev1.EnvVar{
-									Name:  "MEMCACHED_VERSION",
-									Value: config.MemcachedVersion,
+									Name:  "LEADER_ELECTION_LEASE_DURATION",
+									Value: config.LeaseDuration,
 								},
 								corev1.EnvVar{
-									Name:  "TILLER_IMAGE",
-									Value: config.TillerImage,
+									Name:  "LEADER_ELECTION_RENEW_DEADLINE",
+									Value: config.RenewDeadline,
 								},
 								corev1.EnvVar{
-									Name:  "TILLER_VERSION",
-									Value: config.TillerVersion,
+									Name:  "LEADER_ELECTION_RETRY_PERIOD",
+									Value: config.RetryPeriod,
 								},
 								corev1.EnvVar{
-									Name:  "FLUX_NAMESPACE",
-									Value: config.FluxNamespace,
+									Name: "POD_NAME",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{
+											FieldPath: "metadata.name",
+										},
+									},
 								},
 								corev1.EnvVar{
-									Name:  "DISABLE_ROLES",
-									Value: strconv.FormatBool(config.DisableRoles),
-								},
-								corev1.EnvVar{
-									Name:  "DISABLE_CLUSTER_ROLES",
-									Value: strconv.FormatBool(config.DisableClusterRoles),
+									Name: "POD_NAMESPACE",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{
+											FieldPath: "metadata.namespace",
+										},
+									},
 								},
 							},
 							Resources: corev1.ResourceRequirements{
@@ -271,6 +467,64 @@ func NewFluxOperatorDeployment(config FluxOperatorConfig) *v1beta1.Deployment {
 	}
 }
 
+// Create the ConfigMap holding the operator wide defaults for Fluxes, changes to
+// it are picked up by flux-operator without restarting.
+func NewOperatorConfigMap(config FluxOperatorConfig) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetConfigMapName(config),
+			Namespace: GetNamespace(config),
+		},
+		Data: GetOperatorConfig(config).Data(),
+	}
+}
+
+// Create the Service that the API server reaches the conversion webhook of every
+// flux-operator replica through.
+func NewWebhookService(config FluxOperatorConfig) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetWebhookName(config),
+			Namespace: GetNamespace(config),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{
+				"app": "flux-operator",
+			},
+			Ports: []corev1.ServicePort{
+				corev1.ServicePort{
+					Name:       "webhook",
+					Port:       443,
+					TargetPort: intstr.FromString("webhook"),
+				},
+			},
+		},
+	}
+}
+
+// Return the Secret flux-operator stores the conversion webhook's certificates in.
+// flux-operator creates it, so it is only used to delete it on uninstall.
+func newWebhookSecret(config FluxOperatorConfig) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetWebhookName(config),
+			Namespace: GetNamespace(config),
+		},
+	}
+}
+
 // Create the service account
 func NewServiceAccount(config FluxOperatorConfig) *corev1.ServiceAccount {
 	if config.DisableRBAC || config.ServiceAccount != "" {
@@ -289,9 +543,133 @@ func NewServiceAccount(config FluxOperatorConfig) *corev1.ServiceAccount {
 	}
 }
 
-// Create a cluster role
+// Return a rule for each API group of the kinds that flux-operator creates for
+// Fluxes, without any verbs, leaving out cluster scoped kinds if namespaced.
+func managedResources(namespaced bool) []rbacv1.PolicyRule {
+	resources := map[string][]string{}
+	for _, kind := range desired.Kinds() {
+		if namespaced && kind.ClusterScoped {
+			continue
+		}
+		resources[kind.Group()] = append(resources[kind.Group()], kind.Resource)
+	}
+
+	groups := []string{}
+	for group := range resources {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	rules := []rbacv1.PolicyRule{}
+	for _, group := range groups {
+		sort.Strings(resources[group])
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{group},
+			Resources: resources[group],
+		})
+	}
+
+	return rules
+}
+
+// Return the rules flux-operator needs to manage Fluxes: full control of the
+// resources it creates for them, which includes reading the ConfigMaps and
+// Secrets that Flux pods mount, read access to Fluxes, FluxPolicies and
+// namespaces, and creating events. Since Flux roles grant more than flux-operator
+// holds itself, the `escalate` verb is granted on the kinds of roles that Fluxes
+// may be assigned and `bind` on the kinds of roles that they may be bound to.
+func GetClusterRoleRules(config FluxOperatorConfig) []rbacv1.PolicyRule {
+	return getRules(config, false)
+}
+
+// Return the rules flux-operator needs in each namespace it watches in namespaced
+// mode, the rules of GetClusterRoleRules for namespaced resources only.
+func GetRoleRules(config FluxOperatorConfig) []rbacv1.PolicyRule {
+	return getRules(config, true)
+}
+
+// Return the rules flux-operator needs, only for namespaced resources if namespaced.
+func getRules(config FluxOperatorConfig, namespaced bool) []rbacv1.PolicyRule {
+	rules := []rbacv1.PolicyRule{}
+
+	for _, rule := range managedResources(namespaced) {
+		rule.Verbs = []string{"get", "list", "create", "update", "delete"}
+		rules = append(rules, rule)
+	}
+
+	rules = append(rules, rbacv1.PolicyRule{
+		APIGroups: []string{v1alpha1.SchemeGroupVersion.Group},
+		Resources: []string{"fluxes"},
+		Verbs:     []string{"get", "list", "watch", "update"},
+	})
+
+	rules = append(rules, rbacv1.PolicyRule{
+		APIGroups: []string{v1alpha1.SchemeGroupVersion.Group},
+		Resources: []string{"fluxes/status"},
+		Verbs:     []string{"get", "update"},
+	})
+
+	if !namespaced {
+		rules = append(rules,
+			rbacv1.PolicyRule{
+				APIGroups: []string{v1alpha1.SchemeGroupVersion.Group},
+				Resources: []string{"fluxpolicies"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+			rbacv1.PolicyRule{
+				APIGroups: []string{""},
+				Resources: []string{"namespaces"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+			rbacv1.PolicyRule{
+				APIGroups:     []string{"apiextensions.k8s.io"},
+				Resources:     []string{"customresourcedefinitions"},
+				ResourceNames: []string{webhook.FluxCRDName},
+				Verbs:         []string{"get", "update"},
+			},
+		)
+	}
+
+	rules = append(rules, rbacv1.PolicyRule{
+		APIGroups: []string{""},
+		Resources: []string{"events"},
+		Verbs:     []string{"create", "patch"},
+	})
+
+	disableClusterRoles := config.DisableClusterRoles || namespaced
+
+	if !config.DisableRoles {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{"rbac.authorization.k8s.io"},
+			Resources: []string{"roles"},
+			Verbs:     []string{"bind", "escalate"},
+		})
+	}
+
+	// Role references may bind cluster roles in a namespace, so binding cluster
+	// roles is needed unless both kinds of roles are disabled.
+	clusterRoleVerbs := []string{}
+	if !config.DisableRoles || !disableClusterRoles {
+		clusterRoleVerbs = append(clusterRoleVerbs, "bind")
+	}
+	if !disableClusterRoles {
+		clusterRoleVerbs = append(clusterRoleVerbs, "escalate")
+	}
+
+	if len(clusterRoleVerbs) > 0 {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{"rbac.authorization.k8s.io"},
+			Resources: []string{"clusterroles"},
+			Verbs:     clusterRoleVerbs,
+		})
+	}
+
+	return rules
+}
+
+// Create a cluster role with the rules flux-operator needs.
 func NewClusterRole(config FluxOperatorConfig) *rbacv1.ClusterRole {
-	if config.DisableRBAC || config.ClusterRole != "" {
+	if config.DisableRBAC || config.ClusterRole != "" || config.Namespaced {
 		return nil
 	}
 
@@ -303,23 +681,13 @@ func NewClusterRole(config FluxOperatorConfig) *rbacv1.ClusterRole {
 		ObjectMeta: metav1.ObjectMeta{
 			Name: GetClusterRole(config),
 		},
-		Rules: []rbacv1.PolicyRule{
-			rbacv1.PolicyRule{
-				APIGroups: []string{"*"},
-				Resources: []string{"*"},
-				Verbs:     []string{"*"},
-			},
-			rbacv1.PolicyRule{
-				NonResourceURLs: []string{"*"},
-				Verbs:           []string{"*"},
-			},
-		},
+		Rules: GetClusterRoleRules(config),
 	}
 }
 
 // Create the cluster role binding
 func NewClusterRoleBinding(config FluxOperatorConfig) *rbacv1.ClusterRoleBinding {
-	if config.DisableRBAC {
+	if config.DisableRBAC || config.Namespaced {
 		return nil
 	}
 
@@ -346,32 +714,211 @@ func NewClusterRoleBinding(config FluxOperatorConfig) *rbacv1.ClusterRoleBinding
 	}
 }
 
-// Create a flux-operator and all dependent resources.
-func NewFluxOperator(config FluxOperatorConfig) []runtime.Object {
+// Create a role with the rules flux-operator needs in namespace, for namespaced mode.
+func NewRole(config FluxOperatorConfig, namespace string) *rbacv1.Role {
+	if config.DisableRBAC || config.ClusterRole != "" || !config.Namespaced {
+		return nil
+	}
+
+	return &rbacv1.Role{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Role",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetName(config),
+			Namespace: namespace,
+		},
+		Rules: GetRoleRules(config),
+	}
+}
+
+// Bind flux-operator's role, or the cluster role if one is set, in namespace for
+// namespaced mode.
+func NewRoleBinding(config FluxOperatorConfig, namespace string) *rbacv1.RoleBinding {
+	if config.DisableRBAC || !config.Namespaced {
+		return nil
+	}
+
+	roleRef := rbacv1.RoleRef{
+		APIGroup: "rbac.authorization.k8s.io",
+		Kind:     "Role",
+		Name:     GetName(config),
+	}
+
+	if config.ClusterRole != "" {
+		roleRef.Kind = "ClusterRole"
+		roleRef.Name = config.ClusterRole
+	}
+
+	return &rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "RoleBinding",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetName(config),
+			Namespace: namespace,
+		},
+		Subjects: []rbacv1.Subject{
+			rbacv1.Subject{
+				Kind:      "ServiceAccount",
+				Name:      GetServiceAccountName(config),
+				Namespace: GetNamespace(config),
+			},
+		},
+		RoleRef: roleRef,
+	}
+}
+
+// Create flux-operator's roles and role bindings in each namespace it watches
+// for namespaced mode.
+func NewNamespaceRoles(config FluxOperatorConfig) []runtime.Object {
+	objects := []runtime.Object{}
+	for _, namespace := range GetWatchNamespaces(config) {
+		objects = append(objects, NewRole(config, namespace), NewRoleBinding(config, namespace))
+	}
+	return objects
+}
+
+// Create a role allowing flux-operator to manage its leader election lock and
+// conversion webhook certificates and watch its config.
+func NewLeaderElectionRole(config FluxOperatorConfig) *rbacv1.Role {
+	if config.DisableRBAC {
+		return nil
+	}
+
+	return &rbacv1.Role{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Role",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetLeaderLockName(config),
+			Namespace: GetNamespace(config),
+		},
+		Rules: []rbacv1.PolicyRule{
+			rbacv1.PolicyRule{
+				APIGroups: []string{""},
+				Resources: []string{"configmaps"},
+				Verbs:     []string{"get", "list", "watch", "create", "update"},
+			},
+			rbacv1.PolicyRule{
+				APIGroups: []string{""},
+				Resources: []string{"secrets"},
+				Verbs:     []string{"get", "create", "update"},
+			},
+			rbacv1.PolicyRule{
+				APIGroups: []string{""},
+				Resources: []string{"events"},
+				Verbs:     []string{"create", "patch"},
+			},
+		},
+	}
+}
+
+// Bind the leader election role to the flux-operator service account.
+func NewLeaderElectionRoleBinding(config FluxOperatorConfig) *rbacv1.RoleBinding {
+	if config.DisableRBAC {
+		return nil
+	}
+
+	return &rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "RoleBinding",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetLeaderLockName(config),
+			Namespace: GetNamespace(config),
+		},
+		Subjects: []rbacv1.Subject{
+			rbacv1.Subject{
+				Kind:      "ServiceAccount",
+				Name:      GetServiceAccountName(config),
+				Namespace: GetNamespace(config),
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     GetLeaderLockName(config),
+		},
+	}
+}
+
+// Create a PodDisruptionBudget keeping at least one flux-operator available, only
+// if more than one replica is configured since it would otherwise block evictions.
+func NewPodDisruptionBudget(config FluxOperatorConfig) *policyv1beta1.PodDisruptionBudget {
+	if GetReplicas(config) < 2 {
+		return nil
+	}
+
+	return newPodDisruptionBudget(config)
+}
+
+// Create the PodDisruptionBudget regardless of the number of replicas, so that
+// one created with more replicas can be deleted. It is served as policy/v1, the
+// vendored k8s.io/api predates it but the fields set here are unchanged in it.
+func newPodDisruptionBudget(config FluxOperatorConfig) *policyv1beta1.PodDisruptionBudget {
+	minAvailable := intstr.FromInt(1)
+
+	return &policyv1beta1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: "policy/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetName(config),
+			Namespace: GetNamespace(config),
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "flux-operator",
+				},
+			},
+		},
+	}
+}
+
+// Create the CRDs used by flux-operator.
+func NewCRDs(config FluxOperatorConfig) []runtime.Object {
 	return []runtime.Object{
-		NewFluxCRD(config), NewFluxHelmReleaseCRD(config), NewServiceAccount(config),
-		NewClusterRole(config), NewClusterRoleBinding(config),
-		NewFluxOperatorDeployment(config),
+		NewFluxCRD(config), NewFluxPolicyCRD(config), NewFluxHelmReleaseCRD(config),
 	}
 }
 
-// Just print the YAML, don't create it in the API.
-func DryRun(config FluxOperatorConfig) {
-	encoder := json.NewYAMLSerializer(json.DefaultMetaFactory, nil, nil)
+// Create a flux-operator and all dependent resources. In namespaced mode the
+// CRDs are not included since creating them requires cluster wide permissions.
+func NewFluxOperator(config FluxOperatorConfig) []runtime.Object {
+	objects := []runtime.Object{}
+	if !config.Namespaced {
+		objects = append(objects, NewCRDs(config)...)
+	}
 
-	manifests := NewFluxOperator(config)
-	for index, manifest := range manifests {
-		if reflect.ValueOf(manifest).IsNil() {
-			continue
-		}
+	objects = append(objects,
+		NewServiceAccount(config),
+		NewClusterRole(config), NewClusterRoleBinding(config),
+	)
+	objects = append(objects, NewNamespaceRoles(config)...)
 
-		err := encoder.Encode(manifest, os.Stdout)
-		if err != nil {
-			panic(err)
-		}
+	return append(objects,
+		NewLeaderElectionRole(config), NewLeaderElectionRoleBinding(config),
+		NewOperatorConfigMap(config), NewWebhookService(config),
+		NewFluxOperatorDeployment(config), NewPodDisruptionBudget(config),
+	)
+}
 
-		if index+1 != len(manifests) {
-			fmt.Println("---")
-		}
+// Just print the YAML, don't create it in the API.
+func DryRun(config FluxOperatorConfig) {
+	PrintObjects(NewFluxOperator(config))
+}
+
+// Print objects as YAML documents, skipping nil objects.
+func PrintObjects(objects []runtime.Object) {
+	if err := render.Write(os.Stdout, objects); err != nil {
+		panic(err)
 	}
 }
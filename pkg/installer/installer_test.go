@@ -2,13 +2,18 @@ package installer
 
 import (
 	"fmt"
+	operatorconfig "github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/crd"
+	"github.com/justinbarrick/flux-operator/pkg/health"
 	"github.com/justinbarrick/flux-operator/pkg/utils"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	v1beta1 "k8s.io/api/extensions/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	rbacv1 "k8s.io/api/rbac/v1"
-	extensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -49,24 +54,78 @@ func TestGetFluxOperatorImage(t *testing.T) {
 
 func TestNewFluxCRD(t *testing.T) {
 	fluxCrd := NewFluxCRD(FluxOperatorConfig{})
-	assert.Equal(t, extensions.ResourceScope("Namespaced"), fluxCrd.Spec.Scope)
+	assert.Equal(t, "apiextensions.k8s.io/v1", fluxCrd.APIVersion)
+	assert.Equal(t, "fluxes.flux.codesink.net", fluxCrd.Name)
+	assert.Equal(t, "Namespaced", fluxCrd.Spec.Scope)
 	assert.Equal(t, "Flux", fluxCrd.Spec.Names.Kind)
 	assert.Equal(t, "fluxes", fluxCrd.Spec.Names.Plural)
-	assert.Equal(t, "v1alpha1", fluxCrd.Spec.Version)
 	assert.Equal(t, "flux.codesink.net", fluxCrd.Spec.Group)
+	assert.Equal(t, []string{"flx"}, fluxCrd.Spec.Names.ShortNames)
+	assert.Equal(t, []string{"all-gitops"}, fluxCrd.Spec.Names.Categories)
+
+	assert.Equal(t, 2, len(fluxCrd.Spec.Versions))
+	version := fluxCrd.StorageVersion()
+	assert.Equal(t, "v1alpha1", version.Name)
+	assert.True(t, version.Served)
+	assert.NotNil(t, version.Subresources.Status)
+
+	columns := []string{}
+	for _, column := range version.AdditionalPrinterColumns {
+		columns = append(columns, column.JSONPath)
+	}
+	assert.Equal(t, []string{".spec.gitUrl", ".spec.gitBranch", ".status.ready", ".status.fluxVersion", ".metadata.creationTimestamp"}, columns)
+
+	spec := version.Schema.OpenAPIV3Schema.Properties["spec"]
+	assert.Equal(t, []string{"gitUrl"}, spec.Required)
+	assert.Equal(t, "master", spec.Properties["gitBranch"].Default)
+	assert.Equal(t, "The URL to the Git repository (required).", spec.Properties["gitUrl"].Description)
+
+	roleRef := spec.Properties["roleRefs"].Items
+	assert.Equal(t, "ClusterRole", roleRef.Properties["kind"].Default)
+	assert.Equal(t, []interface{}{"Role", "ClusterRole"}, roleRef.Properties["kind"].Enum)
+
+	v1alpha2 := fluxCrd.Spec.Versions[1]
+	assert.Equal(t, "v1alpha2", v1alpha2.Name)
+	assert.True(t, v1alpha2.Served)
+	assert.False(t, v1alpha2.Storage)
+	assert.NotNil(t, v1alpha2.Subresources.Status)
+	assert.Equal(t, ".spec.git.url", v1alpha2.AdditionalPrinterColumns[0].JSONPath)
+
+	git := v1alpha2.Schema.OpenAPIV3Schema.Properties["spec"].Properties["git"]
+	assert.Equal(t, []string{"url"}, git.Required)
+	assert.Equal(t, "master", git.Properties["branch"].Default)
+
+	conversion := fluxCrd.Spec.Conversion
+	assert.Equal(t, "Webhook", conversion.Strategy)
+	assert.Equal(t, "default", conversion.Webhook.ClientConfig.Service.Namespace)
+	assert.Equal(t, "flux-operator-webhook", conversion.Webhook.ClientConfig.Service.Name)
+	assert.Equal(t, "/convert", conversion.Webhook.ClientConfig.Service.Path)
 
 	fluxCrd = NewFluxCRD(FluxOperatorConfig{Cluster: true})
-	assert.Equal(t, extensions.ResourceScope("Cluster"), fluxCrd.Spec.Scope)
+	assert.Equal(t, "Cluster", fluxCrd.Spec.Scope)
+}
+
+func TestNewFluxPolicyCRD(t *testing.T) {
+	policyCrd := NewFluxPolicyCRD(FluxOperatorConfig{})
+	assert.Equal(t, "Cluster", policyCrd.Spec.Scope)
+	assert.Equal(t, "FluxPolicy", policyCrd.Spec.Names.Kind)
+	assert.Equal(t, "fluxpolicies", policyCrd.Spec.Names.Plural)
+	assert.Equal(t, "flux.codesink.net", policyCrd.Spec.Group)
+
+	spec := policyCrd.StorageVersion().Schema.OpenAPIV3Schema.Properties["spec"]
+	assert.Equal(t, "integer", spec.Properties["maxFluxes"].Type)
+	assert.Equal(t, "object", spec.Properties["namespaceSelector"].Properties["matchLabels"].Type)
 }
 
 func TestNewFluxHelmReleaseCRD(t *testing.T) {
 	fluxCrd := NewFluxHelmReleaseCRD(FluxOperatorConfig{})
-	assert.Equal(t, extensions.ResourceScope("Namespaced"), fluxCrd.Spec.Scope)
+	assert.Equal(t, "Namespaced", fluxCrd.Spec.Scope)
 	assert.Equal(t, "FluxHelmRelease", fluxCrd.Spec.Names.Kind)
 	assert.Equal(t, "fluxhelmreleases", fluxCrd.Spec.Names.Plural)
 	assert.Equal(t, "FluxHelmReleaseList", fluxCrd.Spec.Names.ListKind)
-	assert.Equal(t, "v1alpha2", fluxCrd.Spec.Version)
+	assert.Equal(t, "v1alpha2", fluxCrd.StorageVersion().Name)
 	assert.Equal(t, "helm.integrations.flux.weave.works", fluxCrd.Spec.Group)
+	assert.True(t, fluxCrd.StorageVersion().Schema.OpenAPIV3Schema.PreserveUnknown)
 }
 
 func TestNewFluxOperatorDeploymentDefaults(t *testing.T) {
@@ -125,6 +184,44 @@ func TestNewFluxOperatorDeploymentDisableClusterRoles(t *testing.T) {
 	})
 }
 
+func TestNewFluxOperatorDeploymentNamespaced(t *testing.T) {
+	testFluxOperatorDeployment(t, FluxOperatorConfig{
+		Namespaced:    true,
+		FluxNamespace: "team-a,team-b",
+	})
+}
+
+func TestNewFluxOperatorDeploymentReplicas(t *testing.T) {
+	testFluxOperatorDeployment(t, FluxOperatorConfig{
+		Replicas: 3,
+	})
+}
+
+func TestNewFluxOperatorDeploymentLeaderElection(t *testing.T) {
+	testFluxOperatorDeployment(t, FluxOperatorConfig{
+		LeaseDuration: "30s",
+		RenewDeadline: "20s",
+		RetryPeriod:   "5s",
+	})
+}
+
+func TestGetWatchNamespaces(t *testing.T) {
+	assert.Equal(t, []string{}, GetWatchNamespaces(FluxOperatorConfig{}))
+	assert.Equal(t, []string{"team-a", "team-b"}, GetWatchNamespaces(FluxOperatorConfig{FluxNamespace: "team-a, team-b"}))
+	assert.Equal(t, []string{"flux"}, GetWatchNamespaces(FluxOperatorConfig{Namespaced: true, Namespace: "flux"}))
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, Validate(FluxOperatorConfig{}))
+	assert.Nil(t, Validate(FluxOperatorConfig{Namespaced: true}))
+	assert.NotNil(t, Validate(FluxOperatorConfig{Namespaced: true, Cluster: true}))
+}
+
+func TestGetReplicas(t *testing.T) {
+	assert.Equal(t, int32(1), GetReplicas(FluxOperatorConfig{}))
+	assert.Equal(t, int32(2), GetReplicas(FluxOperatorConfig{Replicas: 2}))
+}
+
 func testFluxOperatorDeployment(t *testing.T, config FluxOperatorConfig) {
 	fluxOp := NewFluxOperatorDeployment(config)
 
@@ -133,24 +230,41 @@ func testFluxOperatorDeployment(t *testing.T, config FluxOperatorConfig) {
 	}
 
 	envVars := fluxOp.Spec.Template.Spec.Containers[0].Env
-	assert.Equal(t, int32(1), *fluxOp.Spec.Replicas)
+	assert.Equal(t, GetReplicas(config), *fluxOp.Spec.Replicas)
 	assert.Equal(t, labels, fluxOp.Spec.Selector.MatchLabels)
 	assert.Equal(t, labels, fluxOp.Spec.Template.ObjectMeta.Labels)
 	assert.Equal(t, GetServiceAccountName(config), fluxOp.Spec.Template.Spec.ServiceAccountName)
 	assert.Equal(t, GetFluxOperatorImage(config), fluxOp.Spec.Template.Spec.Containers[0].Image)
 	assert.Equal(t, GetNamespace(config), getEnvVar("WATCH_NAMESPACE", envVars))
-	assert.Equal(t, config.GitSecret, getEnvVar("GIT_SECRET_NAME", envVars))
-	assert.Equal(t, config.FluxImage, getEnvVar("FLUX_IMAGE", envVars))
-	assert.Equal(t, config.FluxVersion, getEnvVar("FLUX_VERSION", envVars))
-	assert.Equal(t, config.HelmOperatorImage, getEnvVar("HELM_OPERATOR_IMAGE", envVars))
-	assert.Equal(t, config.HelmOperatorVersion, getEnvVar("HELM_OPERATOR_VERSION", envVars))
-	assert.Equal(t, config.MemcachedImage, getEnvVar("MEMCACHED_IMAGE", envVars))
-	assert.Equal(t, config.MemcachedVersion, getEnvVar("MEMCACHED_VERSION", envVars))
-	assert.Equal(t, config.TillerImage, getEnvVar("TILLER_IMAGE", envVars))
-	assert.Equal(t, config.TillerVersion, getEnvVar("TILLER_VERSION", envVars))
-	assert.Equal(t, config.FluxNamespace, getEnvVar("FLUX_NAMESPACE", envVars))
-	assert.Equal(t, strconv.FormatBool(config.DisableRoles), getEnvVar("DISABLE_ROLES", envVars))
-	assert.Equal(t, strconv.FormatBool(config.DisableClusterRoles), getEnvVar("DISABLE_CLUSTER_ROLES", envVars))
+	assert.Equal(t, GetConfigMapName(config), getEnvVar("OPERATOR_CONFIG", envVars))
+	assert.Equal(t, strings.Join(GetWatchNamespaces(config), ","), getEnvVar("FLUX_NAMESPACE", envVars))
+	assert.Equal(t, strconv.FormatBool(config.Namespaced), getEnvVar("NAMESPACED", envVars))
+	assert.Equal(t, "true", getEnvVar("LEADER_ELECTION", envVars))
+	assert.Equal(t, GetLeaderLockName(config), getEnvVar("LEADER_ELECTION_ID", envVars))
+	assert.Equal(t, config.LeaseDuration, getEnvVar("LEADER_ELECTION_LEASE_DURATION", envVars))
+	assert.Equal(t, config.RenewDeadline, getEnvVar("LEADER_ELECTION_RENEW_DEADLINE", envVars))
+	assert.Equal(t, config.RetryPeriod, getEnvVar("LEADER_ELECTION_RETRY_PERIOD", envVars))
+
+	data := NewOperatorConfigMap(config).Data
+	assert.Equal(t, config.GitSecret, data["GIT_SECRET_NAME"])
+	assert.Equal(t, config.FluxImage, data["FLUX_IMAGE"])
+	assert.Equal(t, config.FluxVersion, data["FLUX_VERSION"])
+	assert.Equal(t, config.HelmOperatorImage, data["HELM_OPERATOR_IMAGE"])
+	assert.Equal(t, config.HelmOperatorVersion, data["HELM_OPERATOR_VERSION"])
+	assert.Equal(t, config.MemcachedImage, data["MEMCACHED_IMAGE"])
+	assert.Equal(t, config.MemcachedVersion, data["MEMCACHED_VERSION"])
+	assert.Equal(t, config.TillerImage, data["TILLER_IMAGE"])
+	assert.Equal(t, config.TillerVersion, data["TILLER_VERSION"])
+	assert.Equal(t, strconv.FormatBool(config.DisableRoles), data["DISABLE_ROLES"])
+	assert.Equal(t, strconv.FormatBool(config.DisableClusterRoles || config.Namespaced), data["DISABLE_CLUSTER_ROLES"])
+	assert.Equal(t, strconv.FormatBool(config.PlanMode), data["PLAN_MODE"])
+
+	container := fluxOp.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, int32(health.Port), container.Ports[0].ContainerPort)
+	assert.Equal(t, health.LivenessPath, container.LivenessProbe.HTTPGet.Path)
+	assert.Equal(t, container.Ports[0].Name, container.LivenessProbe.HTTPGet.Port.String())
+	assert.Equal(t, health.ReadinessPath, container.ReadinessProbe.HTTPGet.Path)
+	assert.Equal(t, container.Ports[0].Name, container.ReadinessProbe.HTTPGet.Port.String())
 }
 
 func TestNewServiceAccount(t *testing.T) {
@@ -172,16 +286,76 @@ func TestNewServiceAccountAlreadyExists(t *testing.T) {
 	}))
 }
 
+// Return the verbs granted on a resource by rules.
+func grantedVerbs(rules []rbacv1.PolicyRule, group, resource string) []string {
+	verbs := []string{}
+	for _, rule := range rules {
+		for _, ruleGroup := range rule.APIGroups {
+			for _, ruleResource := range rule.Resources {
+				if ruleGroup == group && ruleResource == resource {
+					verbs = append(verbs, rule.Verbs...)
+				}
+			}
+		}
+	}
+	return verbs
+}
+
 func TestClusterRole(t *testing.T) {
 	config := FluxOperatorConfig{}
 	clusterRole := NewClusterRole(config)
 	assert.Equal(t, GetClusterRole(config), clusterRole.ObjectMeta.Name)
-	assert.Equal(t, []string{"*"}, clusterRole.Rules[0].APIGroups)
-	assert.Equal(t, []string{"*"}, clusterRole.Rules[0].Resources)
-	assert.Equal(t, []string{"*"}, clusterRole.Rules[0].Verbs)
 
-	assert.Equal(t, []string{"*"}, clusterRole.Rules[1].NonResourceURLs)
-	assert.Equal(t, []string{"*"}, clusterRole.Rules[1].Verbs)
+	for _, rule := range clusterRole.Rules {
+		assert.NotContains(t, rule.APIGroups, "*")
+		assert.NotContains(t, rule.Resources, "*")
+		assert.NotContains(t, rule.Verbs, "*")
+		assert.Empty(t, rule.NonResourceURLs)
+	}
+
+	managed := map[string][]string{
+		"":                          []string{"configmaps", "secrets", "serviceaccounts", "services"},
+		"extensions":                []string{"deployments"},
+		"rbac.authorization.k8s.io": []string{"clusterrolebindings", "clusterroles", "rolebindings", "roles"},
+	}
+	for group, resources := range managed {
+		for _, resource := range resources {
+			verbs := grantedVerbs(clusterRole.Rules, group, resource)
+			for _, verb := range []string{"get", "list", "create", "update", "delete"} {
+				assert.Contains(t, verbs, verb, "%s %s", resource, verb)
+			}
+		}
+	}
+
+	assert.Contains(t, grantedVerbs(clusterRole.Rules, "flux.codesink.net", "fluxes"), "update")
+	assert.Contains(t, grantedVerbs(clusterRole.Rules, "flux.codesink.net", "fluxes/status"), "update")
+	assert.Contains(t, grantedVerbs(clusterRole.Rules, "flux.codesink.net", "fluxpolicies"), "list")
+	assert.Contains(t, grantedVerbs(clusterRole.Rules, "", "namespaces"), "get")
+	assert.Contains(t, grantedVerbs(clusterRole.Rules, "", "events"), "create")
+	assert.Contains(t, grantedVerbs(clusterRole.Rules, "apiextensions.k8s.io", "customresourcedefinitions"), "update")
+}
+
+func TestClusterRoleRulesEscalation(t *testing.T) {
+	rules := GetClusterRoleRules(FluxOperatorConfig{})
+	assert.Subset(t, grantedVerbs(rules, "rbac.authorization.k8s.io", "roles"), []string{"bind", "escalate"})
+	assert.Subset(t, grantedVerbs(rules, "rbac.authorization.k8s.io", "clusterroles"), []string{"bind", "escalate"})
+
+	rules = GetClusterRoleRules(FluxOperatorConfig{DisableClusterRoles: true})
+	assert.Subset(t, grantedVerbs(rules, "rbac.authorization.k8s.io", "roles"), []string{"bind", "escalate"})
+	assert.Contains(t, grantedVerbs(rules, "rbac.authorization.k8s.io", "clusterroles"), "bind")
+	assert.NotContains(t, grantedVerbs(rules, "rbac.authorization.k8s.io", "clusterroles"), "escalate")
+
+	rules = GetClusterRoleRules(FluxOperatorConfig{DisableRoles: true})
+	assert.NotContains(t, grantedVerbs(rules, "rbac.authorization.k8s.io", "roles"), "bind")
+	assert.NotContains(t, grantedVerbs(rules, "rbac.authorization.k8s.io", "roles"), "escalate")
+	assert.Subset(t, grantedVerbs(rules, "rbac.authorization.k8s.io", "clusterroles"), []string{"bind", "escalate"})
+
+	rules = GetClusterRoleRules(FluxOperatorConfig{DisableRoles: true, DisableClusterRoles: true})
+	for _, resource := range []string{"roles", "clusterroles"} {
+		verbs := grantedVerbs(rules, "rbac.authorization.k8s.io", resource)
+		assert.NotContains(t, verbs, "bind")
+		assert.NotContains(t, verbs, "escalate")
+	}
 }
 
 func TestClusterRoleWithClusterRoleSet(t *testing.T) {
@@ -228,14 +402,83 @@ func testClusterRoleBinding(t *testing.T, config FluxOperatorConfig) {
 	assert.Equal(t, GetNamespace(config), clusterRoleBinding.Subjects[0].Namespace)
 }
 
+func TestWebhookService(t *testing.T) {
+	config := FluxOperatorConfig{Name: "example", Namespace: "flux"}
+	service := NewWebhookService(config)
+	assert.Equal(t, "example-webhook", service.Name)
+	assert.Equal(t, "flux", service.Namespace)
+	assert.Equal(t, int32(443), service.Spec.Ports[0].Port)
+	assert.Equal(t, "webhook", service.Spec.Ports[0].TargetPort.String())
+
+	deployment := NewFluxOperatorDeployment(config)
+	assert.Equal(t, service.Spec.Selector, deployment.Spec.Template.Labels)
+	assert.Equal(t, "example-webhook", getEnvVar("WEBHOOK_NAME", deployment.Spec.Template.Spec.Containers[0].Env))
+	assert.Equal(t, "example-webhook", NewFluxCRD(config).Spec.Conversion.Webhook.ClientConfig.Service.Name)
+}
+
+func TestLeaderElectionRole(t *testing.T) {
+	config := FluxOperatorConfig{}
+	role := NewLeaderElectionRole(config)
+	assert.Equal(t, GetLeaderLockName(config), role.ObjectMeta.Name)
+	assert.Equal(t, GetNamespace(config), role.ObjectMeta.Namespace)
+	assert.Equal(t, []string{"configmaps"}, role.Rules[0].Resources)
+	assert.Equal(t, []string{"secrets"}, role.Rules[1].Resources)
+	assert.Equal(t, []string{"events"}, role.Rules[2].Resources)
+}
+
+func TestLeaderElectionRoleWithRBACDisabled(t *testing.T) {
+	assert.Nil(t, NewLeaderElectionRole(FluxOperatorConfig{
+		DisableRBAC: true,
+	}))
+	assert.Nil(t, NewLeaderElectionRoleBinding(FluxOperatorConfig{
+		DisableRBAC: true,
+	}))
+}
+
+func TestLeaderElectionRoleBinding(t *testing.T) {
+	config := FluxOperatorConfig{ServiceAccount: "hello"}
+	roleBinding := NewLeaderElectionRoleBinding(config)
+	assert.Equal(t, GetLeaderLockName(config), roleBinding.ObjectMeta.Name)
+	assert.Equal(t, GetNamespace(config), roleBinding.ObjectMeta.Namespace)
+	assert.Equal(t, "Role", roleBinding.RoleRef.Kind)
+	assert.Equal(t, GetLeaderLockName(config), roleBinding.RoleRef.Name)
+	assert.Equal(t, "hello", roleBinding.Subjects[0].Name)
+}
+
+func TestNewOperatorConfigMap(t *testing.T) {
+	config := FluxOperatorConfig{Name: "hello", Namespace: "flux", FluxVersion: "1.9.0"}
+	configMap := NewOperatorConfigMap(config)
+	assert.Equal(t, "hello-config", configMap.ObjectMeta.Name)
+	assert.Equal(t, "flux", configMap.ObjectMeta.Namespace)
+
+	loaded, err := operatorconfig.Load(configMap.Data)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.9.0", loaded.FluxVersion)
+	assert.Equal(t, utils.FluxImage, loaded.FluxImage)
+}
+
+func TestPodDisruptionBudget(t *testing.T) {
+	assert.Nil(t, NewPodDisruptionBudget(FluxOperatorConfig{}))
+
+	pdb := NewPodDisruptionBudget(FluxOperatorConfig{Replicas: 2})
+	assert.Equal(t, 1, pdb.Spec.MinAvailable.IntValue())
+	assert.Equal(t, NewFluxOperatorDeployment(FluxOperatorConfig{}).Spec.Selector, pdb.Spec.Selector)
+}
+
 func TestNewFluxOperator(t *testing.T) {
-	objs := NewFluxOperator(FluxOperatorConfig{})
-	_ = objs[0].(*extensions.CustomResourceDefinition)
-	_ = objs[1].(*extensions.CustomResourceDefinition)
-	_ = objs[2].(*corev1.ServiceAccount)
-	_ = objs[3].(*rbacv1.ClusterRole)
-	_ = objs[4].(*rbacv1.ClusterRoleBinding)
-	_ = objs[5].(*v1beta1.Deployment)
+	objs := NewFluxOperator(FluxOperatorConfig{Replicas: 2})
+	_ = objs[0].(*crd.CustomResourceDefinition)
+	_ = objs[1].(*crd.CustomResourceDefinition)
+	_ = objs[2].(*crd.CustomResourceDefinition)
+	_ = objs[3].(*corev1.ServiceAccount)
+	_ = objs[4].(*rbacv1.ClusterRole)
+	_ = objs[5].(*rbacv1.ClusterRoleBinding)
+	_ = objs[6].(*rbacv1.Role)
+	_ = objs[7].(*rbacv1.RoleBinding)
+	_ = objs[8].(*corev1.ConfigMap)
+	_ = objs[9].(*corev1.Service)
+	_ = objs[10].(*appsv1.Deployment)
+	_ = objs[11].(*policyv1beta1.PodDisruptionBudget)
 }
 
 func getEnvVar(name string, vars []corev1.EnvVar) string {
@@ -247,3 +490,58 @@ func getEnvVar(name string, vars []corev1.EnvVar) string {
 
 	return ""
 }
+
+func TestRoleRulesNamespaced(t *testing.T) {
+	rules := GetRoleRules(FluxOperatorConfig{Namespaced: true})
+	for _, resource := range []string{"clusterroles", "clusterrolebindings"} {
+		verbs := grantedVerbs(rules, "rbac.authorization.k8s.io", resource)
+		assert.NotContains(t, verbs, "create", resource)
+		assert.NotContains(t, verbs, "escalate", resource)
+	}
+	assert.Equal(t, []string{"bind"}, grantedVerbs(rules, "rbac.authorization.k8s.io", "clusterroles"))
+	assert.Subset(t, grantedVerbs(rules, "rbac.authorization.k8s.io", "roles"), []string{"create", "bind", "escalate"})
+	assert.Empty(t, grantedVerbs(rules, "", "namespaces"))
+	assert.Empty(t, grantedVerbs(rules, "flux.codesink.net", "fluxpolicies"))
+	assert.Empty(t, grantedVerbs(rules, "apiextensions.k8s.io", "customresourcedefinitions"))
+	assert.Contains(t, grantedVerbs(rules, "flux.codesink.net", "fluxes"), "watch")
+}
+
+func TestNamespaceRoles(t *testing.T) {
+	assert.Equal(t, []runtime.Object{}, NewNamespaceRoles(FluxOperatorConfig{}))
+
+	config := FluxOperatorConfig{Namespaced: true, Namespace: "flux", FluxNamespace: "team-a,team-b"}
+	objects := NewNamespaceRoles(config)
+	assert.Equal(t, 4, len(objects))
+
+	role := objects[2].(*rbacv1.Role)
+	assert.Equal(t, "team-b", role.ObjectMeta.Namespace)
+	assert.Equal(t, GetRoleRules(config), role.Rules)
+
+	roleBinding := objects[3].(*rbacv1.RoleBinding)
+	assert.Equal(t, "team-b", roleBinding.ObjectMeta.Namespace)
+	assert.Equal(t, "Role", roleBinding.RoleRef.Kind)
+	assert.Equal(t, role.ObjectMeta.Name, roleBinding.RoleRef.Name)
+	assert.Equal(t, "flux", roleBinding.Subjects[0].Namespace)
+
+	config.ClusterRole = "my-role"
+	objects = NewNamespaceRoles(config)
+	assert.Nil(t, objects[0].(*rbacv1.Role))
+	assert.Equal(t, "ClusterRole", objects[1].(*rbacv1.RoleBinding).RoleRef.Kind)
+	assert.Equal(t, "my-role", objects[1].(*rbacv1.RoleBinding).RoleRef.Name)
+}
+
+func TestNewFluxOperatorNamespaced(t *testing.T) {
+	objs := NewFluxOperator(FluxOperatorConfig{Namespaced: true})
+	_ = objs[0].(*corev1.ServiceAccount)
+	assert.Nil(t, objs[1].(*rbacv1.ClusterRole))
+	assert.Nil(t, objs[2].(*rbacv1.ClusterRoleBinding))
+	assert.Equal(t, "default", objs[3].(*rbacv1.Role).ObjectMeta.Namespace)
+	assert.Equal(t, "default", objs[4].(*rbacv1.RoleBinding).ObjectMeta.Namespace)
+	_ = objs[5].(*rbacv1.Role)
+	_ = objs[6].(*rbacv1.RoleBinding)
+	_ = objs[7].(*corev1.ConfigMap)
+	_ = objs[8].(*corev1.Service)
+	_ = objs[9].(*appsv1.Deployment)
+
+	assert.Equal(t, 3, len(NewCRDs(FluxOperatorConfig{Namespaced: true})))
+}
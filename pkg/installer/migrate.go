@@ -0,0 +1,106 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/migrate"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Read the Deployments, Services and ConfigMaps of an existing flux install in
+// namespace, along with any tiller in kube-system that helm-operator may use.
+func (c *Client) ReadInstall(namespace string) (*migrate.Install, error) {
+	deployments := &v1beta1.DeploymentList{}
+	if err := c.list(collectionPath("extensions/v1beta1", "deployments", namespace), "", deployments); err != nil {
+		return nil, err
+	}
+
+	if namespace != "kube-system" {
+		tillers := &v1beta1.DeploymentList{}
+		if err := c.list(collectionPath("extensions/v1beta1", "deployments", "kube-system"), "app=helm,name=tiller", tillers); err != nil {
+			return nil, err
+		}
+		deployments.Items = append(deployments.Items, tillers.Items...)
+	}
+
+	services := &corev1.ServiceList{}
+	if err := c.list(collectionPath("v1", "services", namespace), "", services); err != nil {
+		return nil, err
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	if err := c.list(collectionPath("v1", "configmaps", namespace), "", configMaps); err != nil {
+		return nil, err
+	}
+
+	// Listed objects have no kind, it is needed to label them.
+	for index := range deployments.Items {
+		deployments.Items[index].TypeMeta = metav1.TypeMeta{Kind: "Deployment", APIVersion: "extensions/v1beta1"}
+	}
+
+	for index := range services.Items {
+		services.Items[index].TypeMeta = metav1.TypeMeta{Kind: "Service", APIVersion: "v1"}
+	}
+
+	return &migrate.Install{
+		Deployments: deployments.Items,
+		Services:    services.Items,
+		ConfigMaps:  configMaps.Items,
+	}, nil
+}
+
+// Add labels to the existing obj, keeping its other labels.
+func (c *Client) Label(obj runtime.Object, labels map[string]string) error {
+	collection, name, err := objectPath(obj)
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]interface{}{}
+	if err := c.get(collection+"/"+name, &existing); err != nil {
+		return err
+	}
+
+	metadata, _ := existing["metadata"].(map[string]interface{})
+	if metadata == nil {
+		return fmt.Errorf("%s has no metadata", objectName(obj))
+	}
+
+	existingLabels, _ := metadata["labels"].(map[string]interface{})
+	if existingLabels == nil {
+		existingLabels = map[string]interface{}{}
+	}
+
+	for key, value := range labels {
+		existingLabels[key] = value
+	}
+	metadata["labels"] = existingLabels
+
+	body, err := json.Marshal(existing)
+	if err != nil {
+		return err
+	}
+
+	return c.rest.Put().AbsPath(collection, name).SetHeader("Content-Type", "application/json").Body(body).Do().Error()
+}
+
+// Label the objects of an existing install as owned by the Flux migrated from
+// it, so that the operator deletes them once it has created their replacements.
+// The git secret is left alone so that the Flux keeps its deploy key.
+func (c *Client) Adopt(cr *v1alpha1.Flux, objects []runtime.Object) error {
+	for _, obj := range objects {
+		logrus.Infof("Labeling %s as owned by Flux %s", objectName(obj), cr.Name)
+
+		if err := c.Label(obj, utils.FluxLabels(cr)); err != nil {
+			return fmt.Errorf("failed to label %s: %v", objectName(obj), err)
+		}
+	}
+
+	return nil
+}
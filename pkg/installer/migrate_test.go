@@ -0,0 +1,56 @@
+package installer
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/migrate"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReadInstallAndAdopt(t *testing.T) {
+	fake := newFakeAPIServer()
+	defer fake.server.Close()
+	client := newTestClient(t, fake)
+
+	container := map[string]interface{}{"name": "flux", "image": "quay.io/weaveworks/flux:1.8.1", "args": []interface{}{"--git-url=git@example.com:repo"}}
+	fake.store("/apis/extensions/v1beta1/namespaces/flux/deployments/flux", map[string]interface{}{
+		"kind":     "Deployment",
+		"metadata": map[string]interface{}{"name": "flux", "namespace": "flux", "labels": map[string]interface{}{"name": "flux"}},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"name": "flux"}},
+				"spec":     map[string]interface{}{"containers": []interface{}{container}},
+			},
+		},
+	})
+	fake.store("/api/v1/namespaces/flux/services/flux", map[string]interface{}{
+		"kind":     "Service",
+		"metadata": map[string]interface{}{"name": "flux", "namespace": "flux"},
+		"spec":     map[string]interface{}{"selector": map[string]interface{}{"name": "flux"}},
+	})
+	fake.store("/api/v1/namespaces/other/services/flux", map[string]interface{}{
+		"kind":     "Service",
+		"metadata": map[string]interface{}{"name": "flux", "namespace": "other"},
+	})
+
+	install, err := client.ReadInstall("flux")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(install.Deployments))
+	assert.Equal(t, 1, len(install.Services))
+
+	result, err := migrate.Migrate(install, migrate.Options{})
+	assert.Nil(t, err)
+	assert.Equal(t, "git@example.com:repo", result.Flux.Spec.GitUrl)
+	assert.Equal(t, 2, len(result.Replaced))
+
+	assert.Nil(t, client.Adopt(result.Flux, result.Replaced))
+
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	labels := fake.objects["/apis/extensions/v1beta1/namespaces/flux/deployments/flux"]["metadata"].(map[string]interface{})["labels"]
+	assert.Equal(t, map[string]interface{}{"name": "flux", utils.FLUX_LABEL: "flux-flux"}, labels)
+
+	labels = fake.objects["/api/v1/namespaces/flux/services/flux"]["metadata"].(map[string]interface{})["labels"]
+	assert.Equal(t, map[string]interface{}{utils.FLUX_LABEL: "flux-flux"}, labels)
+}
@@ -0,0 +1,287 @@
+package installer
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/ghodss/yaml"
+	"github.com/justinbarrick/flux-operator/pkg/render"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A file to write, its path is relative to the output directory.
+type File struct {
+	Path     string
+	Contents []byte
+}
+
+// Write files to dir, which must not exist or be empty so that files left over
+// from a previous run can not end up in the output.
+func WriteFiles(dir string, files []File) error {
+	existing, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return err
+	}
+
+	if len(existing) > 0 {
+		return fmt.Errorf("output directory %s is not empty", dir)
+	}
+
+	for _, file := range files {
+		path := filepath.Join(dir, file.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(path, file.Contents, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Return the file name of obj, its lower case kind and name.
+func objectFileName(obj runtime.Object) string {
+	objectMeta, _ := meta.Accessor(obj)
+	kind := strings.ToLower(obj.GetObjectKind().GroupVersionKind().Kind)
+	return fmt.Sprintf("%s-%s.yaml", kind, objectMeta.GetName())
+}
+
+// Return obj as a YAML document.
+func objectYAML(obj runtime.Object) ([]byte, error) {
+	contents := &bytes.Buffer{}
+	err := render.Write(contents, []runtime.Object{obj})
+	return contents.Bytes(), err
+}
+
+// Return a Kustomize base of flux-operator: one file per object and a
+// kustomization.yaml listing them in the order they are installed.
+func KustomizeFiles(config FluxOperatorConfig) ([]File, error) {
+	files := []File{}
+	resources := []string{}
+
+	for _, obj := range installObjects(config) {
+		contents, err := objectYAML(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		name := objectFileName(obj)
+		resources = append(resources, name)
+		files = append(files, File{Path: name, Contents: contents})
+	}
+
+	kustomization, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  resources,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(files, File{Path: "kustomization.yaml", Contents: kustomization}), nil
+}
+
+// A setting of the Helm chart exposed in values.yaml.
+type chartValue struct {
+	// The key in values.yaml.
+	Key string
+	// The comment describing it in values.yaml.
+	Description string
+	// The default, from the config the chart was generated with.
+	Default interface{}
+}
+
+// Stands in for the replicas value while rendering the chart's templates.
+const replicasPlaceholder = 1000003
+
+// Matches the placeholders standing in for values while rendering the chart's templates.
+var placeholderPattern = regexp.MustCompile(`__VALUE_([A-Za-z]+)__`)
+
+// Splits a YAML line into its key or list item prefix and its value.
+var yamlLinePattern = regexp.MustCompile(`^(\s*(?:- )?(?:[^\s'"][^:]*: )?)(.*)$`)
+
+// Return the placeholder for the value called key.
+func placeholder(key string) string {
+	return fmt.Sprintf("__VALUE_%s__", key)
+}
+
+// Return the values of the chart, with their defaults taken from config.
+func chartValues(config FluxOperatorConfig) []chartValue {
+	fluxOperatorImage, fluxOperatorVersion := getFluxOperatorImageAndVersion(config)
+
+	values := []chartValue{
+		{"name", "Prefix to use for any resources created.", GetName(config)},
+		{"namespace", "Namespace to deploy flux-operator into.", GetNamespace(config)},
+		{"replicas", "Number of flux-operator replicas to run, only the elected leader reconciles.", GetReplicas(config)},
+		{"fluxOperatorImage", "Flux operator image name.", fluxOperatorImage},
+		{"fluxOperatorVersion", "Flux operator image version.", fluxOperatorVersion},
+		{"fluxImage", "Default flux image name.", config.FluxImage},
+		{"fluxVersion", "Default flux image version.", config.FluxVersion},
+		{"helmOperatorImage", "Default helm-operator image name.", config.HelmOperatorImage},
+		{"helmOperatorVersion", "Default helm-operator image version.", config.HelmOperatorVersion},
+		{"memcachedImage", "Default memcached image name.", config.MemcachedImage},
+		{"memcachedVersion", "Default memcached image version.", config.MemcachedVersion},
+		{"tillerImage", "Default tiller image name.", config.TillerImage},
+		{"tillerVersion", "Default tiller image version.", config.TillerVersion},
+		{"gitSecret", "Default git secret name to use.", config.GitSecret},
+		{"planMode", "Only report the changes flux-operator would make to each Flux's status instead of applying them.", config.PlanMode},
+		{"leaderElectionLeaseDuration", "How long standby replicas wait before taking over the leader lock (default: 15s).", config.LeaseDuration},
+		{"leaderElectionRenewDeadline", "How long the leader retries renewing the leader lock before giving it up (default: 10s).", config.RenewDeadline},
+		{"leaderElectionRetryPeriod", "How long replicas wait between attempts to acquire or renew the leader lock (default: 2s).", config.RetryPeriod},
+	}
+
+	// In namespaced mode a role is created in each watched namespace, so the
+	// namespaces are fixed when the chart is generated.
+	if !config.Namespaced {
+		values = append(values, chartValue{
+			"watchNamespace", "The comma separated namespaces to watch for Fluxes, all namespaces if empty.", config.FluxNamespace,
+		})
+	}
+
+	return values
+}
+
+// Return config with each value of the chart replaced by its placeholder.
+func placeholderConfig(config FluxOperatorConfig) FluxOperatorConfig {
+	config.Name = placeholder("name")
+	config.Namespace = placeholder("namespace")
+	config.Replicas = replicasPlaceholder
+	config.FluxOperatorImage = placeholder("fluxOperatorImage")
+	config.FluxOperatorVersion = placeholder("fluxOperatorVersion")
+	config.FluxImage = placeholder("fluxImage")
+	config.FluxVersion = placeholder("fluxVersion")
+	config.HelmOperatorImage = placeholder("helmOperatorImage")
+	config.HelmOperatorVersion = placeholder("helmOperatorVersion")
+	config.MemcachedImage = placeholder("memcachedImage")
+	config.MemcachedVersion = placeholder("memcachedVersion")
+	config.TillerImage = placeholder("tillerImage")
+	config.TillerVersion = placeholder("tillerVersion")
+	config.GitSecret = placeholder("gitSecret")
+	config.LeaseDuration = placeholder("leaderElectionLeaseDuration")
+	config.RenewDeadline = placeholder("leaderElectionRenewDeadline")
+	config.RetryPeriod = placeholder("leaderElectionRetryPeriod")
+
+	if !config.Namespaced {
+		config.FluxNamespace = placeholder("watchNamespace")
+	}
+
+	return config
+}
+
+// Replace the placeholders in a rendered object with references to the chart's
+// values. Values are quoted so that they are always strings, except replicas.
+func templateObject(contents []byte) []byte {
+	lines := strings.Split(string(contents), "\n")
+
+	for index, line := range lines {
+		parts := yamlLinePattern.FindStringSubmatch(line)
+		prefix, value := parts[1], parts[2]
+
+		if value == strconv.Itoa(replicasPlaceholder) {
+			lines[index] = prefix + "{{ .Values.replicas }}"
+		} else if match := placeholderPattern.FindStringSubmatch(value); match != nil && match[0] == value {
+			lines[index] = fmt.Sprintf("%s{{ .Values.%s | quote }}", prefix, match[1])
+		} else if match != nil {
+			lines[index] = fmt.Sprintf(`%s"%s"`, prefix, placeholderPattern.ReplaceAllString(value, "{{ .Values.$1 }}"))
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// Return the Helm chart version for config, its flux-operator version if that
+// is a semantic version.
+func chartVersion(config FluxOperatorConfig) string {
+	version, err := semver.NewVersion(config.FluxOperatorVersion)
+	if err != nil {
+		return "0.0.0"
+	}
+	return version.String()
+}
+
+// Return values.yaml for the chart.
+func valuesFile(config FluxOperatorConfig) ([]byte, error) {
+	values := &bytes.Buffer{}
+	fmt.Fprintln(values, "# Settings that change which objects are created, such as -cluster, -namespaced,")
+	fmt.Fprintln(values, "# -service-account, -cluster-role and the -disable-* flags, are fixed when the")
+	fmt.Fprintln(values, "# chart is generated by fluxopctl. Regenerate the chart to change them.")
+
+	for _, value := range chartValues(config) {
+		encoded, err := yaml.Marshal(map[string]interface{}{value.Key: value.Default})
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(values, "\n# %s\n%s", value.Description, encoded)
+	}
+
+	return values.Bytes(), nil
+}
+
+// Return a Helm chart of flux-operator with the settings that do not change
+// which objects are created exposed as values, defaulting to those in config.
+func HelmChartFiles(config FluxOperatorConfig) ([]File, error) {
+	_, appVersion := getFluxOperatorImageAndVersion(config)
+
+	chart, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion":  "v1",
+		"name":        "flux-operator",
+		"description": "An operator for managing Flux, helm-operator and Tiller instances.",
+		"version":     chartVersion(config),
+		"appVersion":  appVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := valuesFile(config)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []File{
+		{Path: "Chart.yaml", Contents: chart},
+		{Path: "values.yaml", Contents: values},
+	}
+
+	defaults := []string{}
+	for _, value := range chartValues(config) {
+		defaults = append(defaults, placeholder(value.Key), fmt.Sprintf("%v", value.Default))
+	}
+	names := strings.NewReplacer(defaults...)
+
+	for _, obj := range installObjects(placeholderConfig(config)) {
+		// The operator config in the ConfigMap is rendered from a boolean.
+		if configMap, ok := obj.(*corev1.ConfigMap); ok {
+			configMap.Data["PLAN_MODE"] = placeholder("planMode")
+		}
+
+		contents, err := objectYAML(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		contents = templateObject(contents)
+		if obj.GetObjectKind().GroupVersionKind().Kind == "PodDisruptionBudget" {
+			contents = []byte(fmt.Sprintf("{{- if gt (int .Values.replicas) 1 }}\n%s{{- end }}\n", contents))
+		}
+
+		files = append(files, File{
+			Path:     filepath.Join("templates", names.Replace(objectFileName(obj))),
+			Contents: contents,
+		})
+	}
+
+	return files, nil
+}
@@ -0,0 +1,198 @@
+package installer
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// Return the contents of files by path.
+func filesByPath(files []File) map[string]string {
+	byPath := map[string]string{}
+	for _, file := range files {
+		byPath[file.Path] = string(file.Contents)
+	}
+	return byPath
+}
+
+// Remove empty env var values, which the chart always sets, from a decoded object.
+func dropEmptyValues(object interface{}) interface{} {
+	switch value := object.(type) {
+	case map[string]interface{}:
+		if value["value"] == "" {
+			delete(value, "value")
+		}
+		for key, item := range value {
+			value[key] = dropEmptyValues(item)
+		}
+	case []interface{}:
+		for index, item := range value {
+			value[index] = dropEmptyValues(item)
+		}
+	}
+	return object
+}
+
+// Render the chart's templates with values.yaml and overrides, using stand ins
+// for the template functions Helm provides.
+func renderChart(t *testing.T, files []File, overrides map[string]interface{}) []interface{} {
+	byPath := filesByPath(files)
+
+	values := map[string]interface{}{}
+	assert.Nil(t, yaml.Unmarshal([]byte(byPath["values.yaml"]), &values))
+	for key, value := range overrides {
+		values[key] = value
+	}
+
+	functions := template.FuncMap{
+		"quote": func(value interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(value)) },
+		"int":   func(value interface{}) int { return int(value.(float64)) },
+	}
+
+	objects := []interface{}{}
+	for _, file := range files {
+		if !strings.HasPrefix(file.Path, "templates/") {
+			continue
+		}
+
+		tmpl, err := template.New(file.Path).Funcs(functions).Parse(string(file.Contents))
+		assert.Nil(t, err)
+
+		rendered := &bytes.Buffer{}
+		assert.Nil(t, tmpl.Execute(rendered, map[string]interface{}{"Values": values}))
+
+		if strings.TrimSpace(rendered.String()) == "" {
+			continue
+		}
+
+		var object interface{}
+		assert.Nil(t, yaml.Unmarshal(rendered.Bytes(), &object), rendered.String())
+		objects = append(objects, dropEmptyValues(object))
+	}
+
+	return objects
+}
+
+// Return the objects of a flux-operator decoded from YAML.
+func decodedObjects(t *testing.T, config FluxOperatorConfig) []interface{} {
+	objects := []interface{}{}
+	for _, obj := range installObjects(config) {
+		contents, err := objectYAML(obj)
+		assert.Nil(t, err)
+
+		var object interface{}
+		assert.Nil(t, yaml.Unmarshal(contents, &object))
+		objects = append(objects, dropEmptyValues(object))
+	}
+	return objects
+}
+
+func TestKustomizeFiles(t *testing.T) {
+	files, err := KustomizeFiles(FluxOperatorConfig{Namespace: "flux"})
+	assert.Nil(t, err)
+
+	byPath := filesByPath(files)
+	assert.Equal(t, len(installObjects(FluxOperatorConfig{})), len(files)-1)
+	assert.Contains(t, byPath["deployment-flux-operator.yaml"], "namespace: flux")
+	assert.Contains(t, byPath, "customresourcedefinition-fluxes.flux.codesink.net.yaml")
+
+	kustomization := map[string]interface{}{}
+	assert.Nil(t, yaml.Unmarshal([]byte(byPath["kustomization.yaml"]), &kustomization))
+	assert.Equal(t, "customresourcedefinition-fluxes.flux.codesink.net.yaml", kustomization["resources"].([]interface{})[0])
+
+	again, err := KustomizeFiles(FluxOperatorConfig{Namespace: "flux"})
+	assert.Nil(t, err)
+	assert.Equal(t, files, again)
+}
+
+func TestHelmChartFilesDefaults(t *testing.T) {
+	config := FluxOperatorConfig{
+		Namespace:           "flux",
+		FluxOperatorVersion: "v0.1.0",
+		FluxImage:           "quay.io/weaveworks/flux",
+		FluxVersion:         "1.8.1",
+		LeaseDuration:       "30s",
+	}
+
+	files, err := HelmChartFiles(config)
+	assert.Nil(t, err)
+
+	byPath := filesByPath(files)
+	assert.Contains(t, byPath["Chart.yaml"], "version: 0.1.0")
+	assert.Contains(t, byPath["Chart.yaml"], "appVersion: v0.1.0")
+	assert.Contains(t, byPath, "templates/clusterrole-flux-operator.yaml")
+	assert.Contains(t, byPath, "templates/poddisruptionbudget-flux-operator.yaml")
+
+	assert.Equal(t, decodedObjects(t, config), renderChart(t, files, nil))
+
+	again, err := HelmChartFiles(config)
+	assert.Nil(t, err)
+	assert.Equal(t, files, again)
+}
+
+func TestHelmChartFilesOverrides(t *testing.T) {
+	files, err := HelmChartFiles(FluxOperatorConfig{})
+	assert.Nil(t, err)
+
+	config := FluxOperatorConfig{
+		Name:           "my-operator",
+		Namespace:      "flux",
+		Replicas:       3,
+		FluxNamespace:  "team-a,team-b",
+		PlanMode:       true,
+		TillerVersion:  "v2.10.0",
+		RetryPeriod:    "5s",
+		FluxImage:      "",
+		GitSecret:      "my-secret",
+		MemcachedImage: "memcached",
+	}
+
+	rendered := renderChart(t, files, map[string]interface{}{
+		"name":                      "my-operator",
+		"namespace":                 "flux",
+		"replicas":                  float64(3),
+		"watchNamespace":            "team-a,team-b",
+		"planMode":                  true,
+		"tillerVersion":             "v2.10.0",
+		"leaderElectionRetryPeriod": "5s",
+		"gitSecret":                 "my-secret",
+		"memcachedImage":            "memcached",
+	})
+
+	assert.Equal(t, decodedObjects(t, config), rendered)
+}
+
+func TestHelmChartFilesNamespaced(t *testing.T) {
+	config := FluxOperatorConfig{Namespaced: true, FluxNamespace: "team-a,team-b"}
+
+	files, err := HelmChartFiles(config)
+	assert.Nil(t, err)
+
+	byPath := filesByPath(files)
+	assert.NotContains(t, byPath["values.yaml"], "watchNamespace")
+	assert.Contains(t, byPath, "templates/role-flux-operator.yaml")
+
+	assert.Equal(t, decodedObjects(t, config), renderChart(t, files, nil))
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fluxopctl")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	files := []File{{Path: "templates/a.yaml", Contents: []byte("a")}}
+	assert.Nil(t, WriteFiles(filepath.Join(dir, "chart"), files))
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "chart", "templates", "a.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, "a", string(contents))
+
+	assert.NotNil(t, WriteFiles(filepath.Join(dir, "chart"), files))
+}
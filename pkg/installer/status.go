@@ -0,0 +1,195 @@
+package installer
+
+import (
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"io"
+	"k8s.io/api/extensions/v1beta1"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// The state of a Deployment of one of a Flux's components.
+type ComponentStatus struct {
+	// The name of the Deployment.
+	Name string `json:"name"`
+	// The number of available replicas.
+	Ready int32 `json:"ready"`
+	// The number of desired replicas.
+	Replicas int32 `json:"replicas"`
+	// The images of the Deployment's containers.
+	Images []string `json:"images"`
+}
+
+// A summary of a Flux, its status and its components.
+type FluxSummary struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	GitUrl    string `json:"gitUrl"`
+	GitBranch string `json:"gitBranch"`
+	// The Deployments owned by the Flux, sorted by name.
+	Components       []ComponentStatus `json:"components"`
+	Suspended        bool              `json:"suspended,omitempty"`
+	PolicyViolations []string          `json:"policyViolations,omitempty"`
+	MissingRoles     []string          `json:"missingRoles,omitempty"`
+	LastError        string            `json:"lastError,omitempty"`
+	DeployKey        string            `json:"deployKey,omitempty"`
+}
+
+// Return true if every component has all of its replicas available.
+func (f FluxSummary) Ready() bool {
+	for _, component := range f.Components {
+		if component.Ready < component.Replicas {
+			return false
+		}
+	}
+	return len(f.Components) > 0
+}
+
+// Return the Deployments in namespace matching the label selector.
+func (c *Client) deployments(namespace, selector string) ([]v1beta1.Deployment, error) {
+	deployments := &v1beta1.DeploymentList{}
+	if err := c.list(collectionPath("extensions/v1beta1", "deployments", namespace), selector, deployments); err != nil {
+		return nil, err
+	}
+
+	return deployments.Items, nil
+}
+
+// Summarize a Flux from its status and the Deployments labeled as owned by it.
+func (c *Client) summarize(cr *v1alpha1.Flux) (FluxSummary, error) {
+	branch := cr.Spec.GitBranch
+	if branch == "" {
+		branch = "master"
+	}
+
+	summary := FluxSummary{
+		Namespace:        utils.FluxNamespace(cr),
+		Name:             cr.Name,
+		GitUrl:           cr.Spec.GitUrl,
+		GitBranch:        branch,
+		Components:       []ComponentStatus{},
+		Suspended:        cr.Status.Suspended,
+		PolicyViolations: cr.Status.PolicyViolations,
+		MissingRoles:     cr.Status.MissingRoles,
+		LastError:        cr.Status.LastError,
+		DeployKey:        cr.Status.DeployKey,
+	}
+
+	deployments, err := c.deployments(summary.Namespace, utils.ListOptionsForFlux(cr).LabelSelector)
+	if err != nil {
+		return summary, err
+	}
+
+	for _, deployment := range deployments {
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+
+		images := []string{}
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			images = append(images, container.Image)
+		}
+
+		summary.Components = append(summary.Components, ComponentStatus{
+			Name:     deployment.Name,
+			Ready:    deployment.Status.AvailableReplicas,
+			Replicas: replicas,
+			Images:   images,
+		})
+	}
+
+	sort.Slice(summary.Components, func(i, j int) bool {
+		return summary.Components[i].Name < summary.Components[j].Name
+	})
+
+	return summary, nil
+}
+
+// Summarize the Fluxes whose resources are in namespace, or in every namespace
+// if it is empty, optionally only the Flux called name. Fluxes are listed
+// across the cluster so that both namespaced and cluster scoped Fluxes are
+// found.
+func (c *Client) FluxStatuses(namespace, name string) ([]FluxSummary, error) {
+	fluxes := &v1alpha1.FluxList{}
+	if err := c.get(collectionPath("flux.codesink.net/v1alpha1", "fluxes", ""), fluxes); err != nil {
+		return nil, err
+	}
+
+	summaries := []FluxSummary{}
+	for index := range fluxes.Items {
+		cr := &fluxes.Items[index]
+		if (namespace != "" && utils.FluxNamespace(cr) != namespace) || (name != "" && cr.Name != name) {
+			continue
+		}
+
+		summary, err := c.summarize(cr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize Flux %s/%s: %v", summary.Namespace, cr.Name, err)
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Namespace != summaries[j].Namespace {
+			return summaries[i].Namespace < summaries[j].Namespace
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+
+	return summaries, nil
+}
+
+// Write the summaries in a human readable form.
+func PrintFluxStatuses(writer io.Writer, summaries []FluxSummary) error {
+	for index, summary := range summaries {
+		if index > 0 {
+			fmt.Fprintln(writer)
+		}
+
+		state := "Ready"
+		if summary.Suspended {
+			state = "Suspended"
+		} else if !summary.Ready() {
+			state = "NotReady"
+		}
+
+		lastError := summary.LastError
+		if lastError == "" {
+			lastError = "<none>"
+		}
+
+		deployKey := summary.DeployKey
+		if deployKey == "" {
+			deployKey = "<pending>"
+		}
+
+		fmt.Fprintf(writer, "%s/%s (%s)\n", summary.Namespace, summary.Name, state)
+		fmt.Fprintf(writer, "  Git:         %s (%s)\n", summary.GitUrl, summary.GitBranch)
+		for _, violation := range summary.PolicyViolations {
+			fmt.Fprintf(writer, "  Violation:   %s\n", violation)
+		}
+		for _, missing := range summary.MissingRoles {
+			fmt.Fprintf(writer, "  Missing:     %s\n", missing)
+		}
+		fmt.Fprintf(writer, "  Last error:  %s\n", lastError)
+		fmt.Fprintf(writer, "  Deploy key:  %s\n", deployKey)
+		fmt.Fprintf(writer, "  Components:\n")
+
+		tabs := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+		for _, component := range summary.Components {
+			fmt.Fprintf(tabs, "    %s\t%d/%d\t%s\n", component.Name, component.Ready, component.Replicas,
+				strings.Join(component.Images, ", "))
+		}
+
+		if err := tabs.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
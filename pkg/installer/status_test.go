@@ -0,0 +1,96 @@
+package installer
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/justinbarrick/flux-operator/pkg/desired"
+	"github.com/justinbarrick/flux-operator/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"testing"
+)
+
+// Store obj in the fake API server at its path.
+func storeObject(t *testing.T, fake *fakeAPIServer, path string, obj runtime.Object) {
+	encoded, err := json.Marshal(obj)
+	assert.Nil(t, err)
+
+	decoded := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(encoded, &decoded))
+
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+	fake.store(path, decoded)
+}
+
+func TestFluxStatuses(t *testing.T) {
+	fake := newFakeAPIServer()
+	defer fake.server.Close()
+	client := newTestClient(t, fake)
+
+	cr := test_utils.NewFlux()
+	cr.Kind = "Flux"
+	cr.Spec.Tiller.Enabled = true
+	cr.Status.LastError = "flux instance 'example' violates policy"
+	cr.Status.DeployKey = "ssh-rsa AAAA"
+	storeObject(t, fake, "/apis/flux.codesink.net/v1alpha1/namespaces/default/fluxes/example", cr)
+
+	other := test_utils.NewFlux()
+	other.Name = "other"
+	other.Namespace = "other"
+	storeObject(t, fake, "/apis/flux.codesink.net/v1alpha1/namespaces/other/fluxes/other", other)
+
+	objects, err := desired.FluxObjects(cr, false, nil)
+	assert.Nil(t, err)
+
+	for _, obj := range objects {
+		if obj.GetObjectKind().GroupVersionKind().Kind != "Deployment" {
+			continue
+		}
+
+		collection, name, err := objectPath(obj)
+		assert.Nil(t, err)
+		storeObject(t, fake, collection+"/"+name, obj)
+	}
+
+	fake.lock.Lock()
+	fake.objects["/apis/extensions/v1beta1/namespaces/default/deployments/flux-example-memcached"]["status"] = map[string]interface{}{}
+	fake.lock.Unlock()
+
+	summaries, err := client.FluxStatuses("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(summaries))
+	assert.Equal(t, "other", summaries[1].Name)
+	assert.Equal(t, 0, len(summaries[1].Components))
+	assert.False(t, summaries[1].Ready())
+
+	summary := summaries[0]
+	assert.Equal(t, "default", summary.Namespace)
+	assert.Equal(t, "git@github.com:justinbarrick/manifests", summary.GitUrl)
+	assert.Equal(t, "master", summary.GitBranch)
+	assert.Equal(t, "ssh-rsa AAAA", summary.DeployKey)
+	assert.Equal(t, "flux instance 'example' violates policy", summary.LastError)
+	assert.False(t, summary.Ready())
+
+	names := []string{}
+	for _, component := range summary.Components {
+		names = append(names, component.Name)
+	}
+	assert.Equal(t, []string{"flux-example", "flux-example-memcached", "flux-example-tiller-deploy"}, names)
+	assert.Equal(t, int32(0), summary.Components[1].Ready)
+	assert.Equal(t, int32(1), summary.Components[0].Ready)
+	assert.Contains(t, summary.Components[0].Images[0], "quay.io/weaveworks/flux:")
+
+	summaries, err = client.FluxStatuses("other", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(summaries))
+
+	summaries, err = client.FluxStatuses("", "example")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(summaries))
+
+	output := &bytes.Buffer{}
+	assert.Nil(t, PrintFluxStatuses(output, summaries))
+	assert.Contains(t, output.String(), "default/example (NotReady)")
+	assert.Contains(t, output.String(), "  flux-example-memcached      0/1  memcached:")
+}
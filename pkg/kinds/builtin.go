@@ -0,0 +1,81 @@
+package kinds
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// The built in kinds that flux-operator creates for Fluxes. Service accounts
+// come first, then the roles granted to them and their bindings, then the
+// ConfigMaps and Secrets that pods mount and finally Services and Deployments.
+var (
+	ConfigMap = Kind{
+		Kind:       "ConfigMap",
+		APIVersion: "v1",
+		Resource:   "configmaps",
+		Order:      40,
+		NewList:    func() runtime.Object { return &corev1.ConfigMapList{} },
+	}
+	Secret = Kind{
+		Kind:       "Secret",
+		APIVersion: "v1",
+		Resource:   "secrets",
+		Order:      40,
+		NewList:    func() runtime.Object { return &corev1.SecretList{} },
+	}
+	Service = Kind{
+		Kind:       "Service",
+		APIVersion: "v1",
+		Resource:   "services",
+		Order:      50,
+		NewList:    func() runtime.Object { return &corev1.ServiceList{} },
+	}
+	ServiceAccount = Kind{
+		Kind:       "ServiceAccount",
+		APIVersion: "v1",
+		Resource:   "serviceaccounts",
+		Order:      10,
+		NewList:    func() runtime.Object { return &corev1.ServiceAccountList{} },
+	}
+	Deployment = Kind{
+		Kind:       "Deployment",
+		APIVersion: "extensions/v1beta1",
+		Resource:   "deployments",
+		Order:      60,
+		NewList:    func() runtime.Object { return &extensions.DeploymentList{} },
+	}
+	Role = Kind{
+		Kind:             "Role",
+		APIVersion:       "rbac.authorization.k8s.io/v1",
+		Resource:         "roles",
+		TargetNamespaces: true,
+		Order:            20,
+		NewList:          func() runtime.Object { return &rbacv1.RoleList{} },
+	}
+	RoleBinding = Kind{
+		Kind:             "RoleBinding",
+		APIVersion:       "rbac.authorization.k8s.io/v1",
+		Resource:         "rolebindings",
+		TargetNamespaces: true,
+		Order:            30,
+		NewList:          func() runtime.Object { return &rbacv1.RoleBindingList{} },
+	}
+	ClusterRole = Kind{
+		Kind:          "ClusterRole",
+		APIVersion:    "rbac.authorization.k8s.io/v1",
+		Resource:      "clusterroles",
+		ClusterScoped: true,
+		Order:         20,
+		NewList:       func() runtime.Object { return &rbacv1.ClusterRoleList{} },
+	}
+	ClusterRoleBinding = Kind{
+		Kind:          "ClusterRoleBinding",
+		APIVersion:    "rbac.authorization.k8s.io/v1",
+		Resource:      "clusterrolebindings",
+		ClusterScoped: true,
+		Order:         30,
+		NewList:       func() runtime.Object { return &rbacv1.ClusterRoleBindingList{} },
+	}
+)
@@ -0,0 +1,155 @@
+package kinds
+
+import (
+	"fmt"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"math"
+	"sort"
+	"sync"
+)
+
+// A kind of object that flux-operator creates for Fluxes. Objects of every
+// registered kind are listed to find the objects that exist for a Flux, so that
+// they are updated and garbage collected, and flux-operator is granted full
+// control of them.
+type Kind struct {
+	// The kind, e.g. `Deployment`.
+	Kind string
+	// The API version, e.g. `extensions/v1beta1`.
+	APIVersion string
+	// The plural resource name used in API paths and RBAC rules, e.g. `deployments`.
+	Resource string
+	// Whether the kind is cluster scoped.
+	ClusterScoped bool
+	// Whether objects of the kind are also created in a Flux's target namespaces
+	// and must be looked for outside of its namespace.
+	TargetNamespaces bool
+	// Objects are created and updated in ascending order and deleted in
+	// descending order, so that they are created after the objects they depend
+	// on and deleted before them.
+	Order int
+	// Return an empty list of the kind.
+	NewList func() runtime.Object
+}
+
+// Return the API group of the kind.
+func (k Kind) Group() string {
+	return k.GroupVersionKind().Group
+}
+
+// Return the group, version and kind.
+func (k Kind) GroupVersionKind() schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(k.APIVersion, k.Kind)
+}
+
+// Return an empty list of the kind with its kind and API version set, as
+// sdk.List expects.
+func (k Kind) List() runtime.Object {
+	list := k.NewList()
+	list.GetObjectKind().SetGroupVersionKind(k.GroupVersionKind())
+	return list
+}
+
+// A set of registered kinds by group, version and kind.
+type registry struct {
+	lock  sync.RWMutex
+	kinds map[string]Kind
+}
+
+func newRegistry() *registry {
+	return &registry{kinds: map[string]Kind{}}
+}
+
+// The kinds registered by the packages that create objects for Fluxes.
+var defaultRegistry = newRegistry()
+
+// Register kinds that a package creates for Fluxes, usually from its init
+// function. A kind may be registered by more than one package, but only with
+// the same settings.
+func Register(kinds ...Kind) {
+	defaultRegistry.register(kinds...)
+}
+
+func (r *registry) register(kinds ...Kind) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, kind := range kinds {
+		key := kind.GroupVersionKind().String()
+
+		if existing, ok := r.kinds[key]; ok {
+			if existing.Resource != kind.Resource || existing.Order != kind.Order || existing.ClusterScoped != kind.ClusterScoped || existing.TargetNamespaces != kind.TargetNamespaces {
+				panic(fmt.Sprintf("kind %s registered twice with different settings", key))
+			}
+			continue
+		}
+
+		r.kinds[key] = kind
+	}
+}
+
+// Return every registered kind, sorted by API group and kind.
+func All() []Kind {
+	return defaultRegistry.all()
+}
+
+func (r *registry) all() []Kind {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	all := []Kind{}
+	for _, kind := range r.kinds {
+		all = append(all, kind)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Group() != all[j].Group() {
+			return all[i].Group() < all[j].Group()
+		}
+		return all[i].Kind < all[j].Kind
+	})
+
+	return all
+}
+
+// Return the order of an object's kind, objects of unregistered kinds are
+// created last.
+func (r *registry) order(object runtime.Object) int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	kind, ok := r.kinds[object.GetObjectKind().GroupVersionKind().String()]
+	if !ok {
+		return math.MaxInt32
+	}
+	return kind.Order
+}
+
+// Return a copy of objects sorted in the order they are created in, keeping
+// objects of the same order in their original order.
+func Sort(objects []runtime.Object) []runtime.Object {
+	return defaultRegistry.sort(objects)
+}
+
+func (r *registry) sort(objects []runtime.Object) []runtime.Object {
+	sorted := append([]runtime.Object{}, objects...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return r.order(sorted[i]) < r.order(sorted[j])
+	})
+	return sorted
+}
+
+// Return a copy of objects sorted in the order they are deleted in, the reverse
+// of Sort.
+func SortForDeletion(objects []runtime.Object) []runtime.Object {
+	return defaultRegistry.sortForDeletion(objects)
+}
+
+func (r *registry) sortForDeletion(objects []runtime.Object) []runtime.Object {
+	sorted := append([]runtime.Object{}, objects...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return r.order(sorted[i]) > r.order(sorted[j])
+	})
+	return sorted
+}
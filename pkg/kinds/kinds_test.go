@@ -0,0 +1,57 @@
+package kinds
+
+import (
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	registry := newRegistry()
+	registry.register(Deployment, ConfigMap, Role)
+	registry.register(ConfigMap)
+
+	names := []string{}
+	for _, kind := range registry.all() {
+		names = append(names, kind.Kind)
+	}
+	assert.Equal(t, []string{"ConfigMap", "Deployment", "Role"}, names)
+}
+
+func TestRegisterConflict(t *testing.T) {
+	conflicting := ConfigMap
+	conflicting.ClusterScoped = true
+
+	assert.Panics(t, func() {
+		newRegistry().register(ConfigMap, conflicting)
+	})
+}
+
+func TestList(t *testing.T) {
+	list := Secret.List()
+	assert.IsType(t, &corev1.SecretList{}, list)
+	assert.Equal(t, schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, list.GetObjectKind().GroupVersionKind())
+	assert.Equal(t, "rbac.authorization.k8s.io", ClusterRole.Group())
+}
+
+func TestSort(t *testing.T) {
+	registry := newRegistry()
+	registry.register(Deployment, ConfigMap, ServiceAccount)
+
+	deployment := Deployment.NewList()
+	deployment.GetObjectKind().SetGroupVersionKind(Deployment.GroupVersionKind())
+	configMap := &corev1.ConfigMap{}
+	configMap.GetObjectKind().SetGroupVersionKind(ConfigMap.GroupVersionKind())
+	serviceAccount := &corev1.ServiceAccount{}
+	serviceAccount.GetObjectKind().SetGroupVersionKind(ServiceAccount.GroupVersionKind())
+	unregistered := &corev1.Pod{}
+	unregistered.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "Pod"})
+
+	objects := []runtime.Object{unregistered, deployment, configMap, serviceAccount}
+
+	assert.Equal(t, []runtime.Object{serviceAccount, configMap, deployment, unregistered}, registry.sort(objects))
+	assert.Equal(t, []runtime.Object{unregistered, deployment, configMap, serviceAccount}, registry.sortForDeletion(objects))
+	assert.Equal(t, unregistered, objects[0])
+}
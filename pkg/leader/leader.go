@@ -0,0 +1,207 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	DefaultLockName      = "flux-operator-leader"
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRenewDeadline = 10 * time.Second
+	DefaultRetryPeriod   = 2 * time.Second
+)
+
+// Returned by Run if the lease was lost while leading.
+var ErrLostLeadership = errors.New("lost leadership")
+
+// Settings for leader election.
+type Config struct {
+	// If false, Run starts leading immediately.
+	Enabled bool
+	// The namespace to create the lock in.
+	Namespace string
+	// The name of the lock.
+	Name string
+	// The identity of this candidate, must be unique among all replicas.
+	Identity string
+	// How long non-leaders wait after the last renewal before taking over.
+	LeaseDuration time.Duration
+	// How long the leader retries renewing before giving up leadership.
+	RenewDeadline time.Duration
+	// How long candidates wait between attempts to acquire or renew.
+	RetryPeriod time.Duration
+}
+
+// Parse a duration from an environment variable, returning value if it is unset.
+func durationEnv(name string, value time.Duration) (time.Duration, error) {
+	env := os.Getenv(name)
+	if env == "" {
+		return value, nil
+	}
+
+	duration, err := time.ParseDuration(env)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", name, err)
+	}
+
+	return duration, nil
+}
+
+// Load the leader election settings from the environment.
+func ConfigFromEnv() (config Config, err error) {
+	hostname, _ := os.Hostname()
+
+	config = Config{
+		Enabled:   utils.BoolEnv("LEADER_ELECTION"),
+		Namespace: utils.Getenv("POD_NAMESPACE", "default"),
+		Name:      utils.Getenv("LEADER_ELECTION_ID", DefaultLockName),
+		Identity:  utils.Getenv("POD_NAME", hostname),
+	}
+
+	config.LeaseDuration, err = durationEnv("LEADER_ELECTION_LEASE_DURATION", DefaultLeaseDuration)
+	if err != nil {
+		return
+	}
+
+	config.RenewDeadline, err = durationEnv("LEADER_ELECTION_RENEW_DEADLINE", DefaultRenewDeadline)
+	if err != nil {
+		return
+	}
+
+	config.RetryPeriod, err = durationEnv("LEADER_ELECTION_RETRY_PERIOD", DefaultRetryPeriod)
+	if err != nil {
+		return
+	}
+
+	err = config.Validate()
+	return
+}
+
+// Return an error if the lease timings are inconsistent.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Identity == "" {
+		return errors.New("leader election identity must be set")
+	}
+
+	if c.LeaseDuration <= c.RenewDeadline {
+		return fmt.Errorf("lease duration (%s) must be greater than renew deadline (%s)",
+			c.LeaseDuration, c.RenewDeadline)
+	}
+
+	if c.RenewDeadline <= time.Duration(leaderelection.JitterFactor*float64(c.RetryPeriod)) {
+		return fmt.Errorf("renew deadline (%s) must be greater than retry period (%s) * %.1f",
+			c.RenewDeadline, c.RetryPeriod, leaderelection.JitterFactor)
+	}
+
+	return nil
+}
+
+// Block until this replica is elected leader and then call run with a context
+// that is cancelled if ctx is cancelled or leadership is lost. Returns once run
+// has returned, or immediately if ctx is cancelled before being elected.
+func Run(ctx context.Context, client kubernetes.Interface, config Config, run func(context.Context)) error {
+	if !config.Enabled {
+		run(ctx)
+		return nil
+	}
+
+	broadcaster := record.NewBroadcaster()
+	sink := broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: client.CoreV1().Events(config.Namespace),
+	})
+	defer sink.Stop()
+
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{
+		Component: "flux-operator",
+	})
+
+	// The lock is a ConfigMap rather than a coordination.k8s.io Lease: the
+	// client-go flux-operator is built with predates Lease locks. Switch to
+	// resourcelock.LeasesResourceLock, and the leader election role to leases,
+	// once client-go is upgraded.
+	lock, err := resourcelock.New(resourcelock.ConfigMapsResourceLock, config.Namespace,
+		config.Name, client.CoreV1(), resourcelock.ResourceLockConfig{
+			Identity:      config.Identity,
+			EventRecorder: recorder,
+		})
+	if err != nil {
+		return err
+	}
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	finished := make(chan struct{})
+	lost := make(chan struct{})
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: config.LeaseDuration,
+		RenewDeadline: config.RenewDeadline,
+		RetryPeriod:   config.RetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(stop <-chan struct{}) {
+				logrus.Infof("Acquired leader lock %s/%s as %s.", config.Namespace, config.Name, config.Identity)
+
+				go func() {
+					select {
+					case <-stop:
+						close(lost)
+						cancel()
+					case <-leaderCtx.Done():
+					}
+				}()
+
+				run(leaderCtx)
+				close(finished)
+			},
+			OnStoppedLeading: func() {
+				logrus.Warnf("Lost leader lock %s/%s.", config.Namespace, config.Name)
+				cancel()
+			},
+			OnNewLeader: func(identity string) {
+				logrus.Infof("Current leader is %s.", identity)
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Waiting to acquire leader lock %s/%s as %s.", config.Namespace, config.Name, config.Identity)
+	go elector.Run()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		if !elector.IsLeader() {
+			return nil
+		}
+		<-finished
+	}
+
+	select {
+	case <-lost:
+		return ErrLostLeadership
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,69 @@
+package leader
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnvDefaults(t *testing.T) {
+	config, err := ConfigFromEnv()
+	assert.Nil(t, err)
+	assert.False(t, config.Enabled)
+	assert.Equal(t, DefaultLockName, config.Name)
+	assert.Equal(t, DefaultLeaseDuration, config.LeaseDuration)
+	assert.Equal(t, DefaultRenewDeadline, config.RenewDeadline)
+	assert.Equal(t, DefaultRetryPeriod, config.RetryPeriod)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	os.Setenv("LEADER_ELECTION", "true")
+	os.Setenv("POD_NAME", "flux-operator-abc")
+	os.Setenv("POD_NAMESPACE", "flux")
+	os.Setenv("LEADER_ELECTION_LEASE_DURATION", "1m")
+	config, err := ConfigFromEnv()
+	os.Setenv("LEADER_ELECTION", "")
+	os.Setenv("POD_NAME", "")
+	os.Setenv("POD_NAMESPACE", "")
+	os.Setenv("LEADER_ELECTION_LEASE_DURATION", "")
+
+	assert.Nil(t, err)
+	assert.True(t, config.Enabled)
+	assert.Equal(t, "flux-operator-abc", config.Identity)
+	assert.Equal(t, "flux", config.Namespace)
+	assert.Equal(t, time.Minute, config.LeaseDuration)
+}
+
+func TestConfigFromEnvInvalidDuration(t *testing.T) {
+	os.Setenv("LEADER_ELECTION_RETRY_PERIOD", "soon")
+	_, err := ConfigFromEnv()
+	os.Setenv("LEADER_ELECTION_RETRY_PERIOD", "")
+
+	assert.NotNil(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	config := Config{
+		Enabled:       true,
+		Identity:      "me",
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+	}
+	assert.Nil(t, config.Validate())
+
+	config.LeaseDuration = 10 * time.Second
+	assert.NotNil(t, config.Validate())
+
+	config.LeaseDuration = 15 * time.Second
+	config.RetryPeriod = 10 * time.Second
+	assert.NotNil(t, config.Validate())
+
+	config.RetryPeriod = 2 * time.Second
+	config.Identity = ""
+	assert.NotNil(t, config.Validate())
+
+	config.Enabled = false
+	assert.Nil(t, config.Validate())
+}
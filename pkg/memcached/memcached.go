@@ -3,6 +3,8 @@ package memcached
 import (
 	"fmt"
 	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/kinds"
 	"github.com/justinbarrick/flux-operator/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
@@ -11,6 +13,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// Register the kinds of objects this package creates for Fluxes.
+func init() {
+	kinds.Register(kinds.Deployment, kinds.Service)
+}
+
 // Generate memcached name
 func MemcachedName(cr *v1alpha1.Flux) string {
 	return fmt.Sprintf("flux-%s-memcached", cr.ObjectMeta.Name)
@@ -40,8 +47,8 @@ func NewMemcachedService(cr *v1alpha1.Flux) *corev1.Service {
 
 // NewMemcachedDeployment creates a new memcached deployment
 func NewMemcachedDeployment(cr *v1alpha1.Flux) *extensions.Deployment {
-	memcachedImage := utils.Getenv("MEMCACHED_IMAGE", utils.MemcachedImage)
-	memcachedVersion := utils.Getenv("MEMCACHED_VERSION", utils.MemcachedVersion)
+	memcachedImage := config.Get().MemcachedImage
+	memcachedVersion := config.Get().MemcachedVersion
 
 	labels := map[string]string{
 		"name": MemcachedName(cr),
@@ -0,0 +1,342 @@
+package migrate
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The args that the operator sets itself, they are dropped from the Flux's args.
+var managedFluxArgs = map[string]bool{
+	"memcached-hostname": true,
+	"memcached-port":     true,
+	"memcached-service":  true,
+	"ssh-keygen-dir":     true,
+	// The operator mounts the git secret at the default path.
+	"k8s-secret-volume-mount-path": true,
+}
+
+// Parse command line flags into their values by name, a flag without a value
+// is `true`. Flags may be repeated and use either `--name=value` or
+// `--name value`.
+func parseArgs(args []string) map[string][]string {
+	parsed := map[string][]string{}
+
+	for index := 0; index < len(args); index++ {
+		if !strings.HasPrefix(args[index], "-") {
+			continue
+		}
+
+		name := strings.TrimLeft(args[index], "-")
+		value := "true"
+
+		if parts := strings.SplitN(name, "=", 2); len(parts) == 2 {
+			name, value = parts[0], parts[1]
+		} else if index+1 < len(args) && !strings.HasPrefix(args[index+1], "-") {
+			value = args[index+1]
+			index++
+		}
+
+		parsed[name] = append(parsed[name], value)
+	}
+
+	return parsed
+}
+
+// Return the sorted names of the parsed args.
+func argNames(args map[string][]string) []string {
+	names := []string{}
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Return the last value of a flag, which is the one that takes effect.
+func last(values []string) string {
+	return values[len(values)-1]
+}
+
+// Return true if two durations are equal, e.g. `5m` and `5m00s`.
+func sameDuration(first, second string) bool {
+	firstDuration, err := time.ParseDuration(first)
+	if err != nil {
+		return first == second
+	}
+
+	secondDuration, err := time.ParseDuration(second)
+	if err != nil {
+		return first == second
+	}
+
+	return firstDuration == secondDuration
+}
+
+// Return true if a path within the git repository is its root.
+func isRootPath(gitPath string) bool {
+	return gitPath == "" || gitPath == "." || gitPath == "./"
+}
+
+// Split an image into its name and tag, the tag is empty if it has none.
+func splitImage(image string) (string, string) {
+	index := strings.LastIndex(image, ":")
+	if index < 0 || strings.Contains(image[index:], "/") {
+		return image, ""
+	}
+	return image[:index], image[index+1:]
+}
+
+// Return the last path component of an image's name, e.g. `flux` for
+// `quay.io/weaveworks/flux:1.8.1`.
+func imageBase(image string) string {
+	name, _ := splitImage(image)
+	return path.Base(name)
+}
+
+// Return the first container of a Deployment running an image named component.
+func container(deployment *extensions.Deployment, component string) *corev1.Container {
+	containers := deployment.Spec.Template.Spec.Containers
+	for index := range containers {
+		if imageBase(containers[index].Image) == component {
+			return &containers[index]
+		}
+	}
+	return nil
+}
+
+// Return the first volume of a Deployment mounted into container at a path
+// accepted by match, and its mount.
+func mountedVolume(deployment *extensions.Deployment, container *corev1.Container, match func(string) bool) (*corev1.Volume, *corev1.VolumeMount) {
+	for _, mount := range container.VolumeMounts {
+		if !match(mount.MountPath) {
+			continue
+		}
+
+		for _, volume := range deployment.Spec.Template.Spec.Volumes {
+			if volume.Name == mount.Name {
+				volume, mount := volume, mount
+				return &volume, &mount
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// Return the resources of container, nil if it has none.
+func resources(container *corev1.Container) *corev1.ResourceRequirements {
+	if len(container.Resources.Limits) == 0 && len(container.Resources.Requests) == 0 {
+		return nil
+	}
+	return container.Resources.DeepCopy()
+}
+
+// Migrate the settings of the flux Deployment to the Flux.
+func migrateFlux(result *Result, install *Install, options Options, deployment *extensions.Deployment) {
+	spec := &result.Flux.Spec
+	flux := container(deployment, "flux")
+
+	spec.FluxImage, spec.FluxVersion = splitImage(flux.Image)
+	spec.Resources = resources(flux)
+
+	args := parseArgs(flux.Args)
+	syncTag := "flux-sync"
+
+	for _, name := range argNames(args) {
+		value := last(args[name])
+
+		switch name {
+		case "git-url":
+			spec.GitUrl = value
+		case "git-branch":
+			if value != "master" {
+				spec.GitBranch = value
+			}
+		case "git-path":
+			if !isRootPath(value) {
+				spec.GitPath = value
+			}
+		case "git-poll-interval":
+			if !sameDuration(value, "5m") {
+				spec.GitPollInterval = value
+			}
+		case "sync-interval":
+			if !sameDuration(value, "5m") {
+				spec.SyncInterval = value
+			}
+		case "git-sync-tag":
+			syncTag = value
+		case "k8s-secret-name":
+			spec.GitSecret = value
+		case "k8s-namespace-whitelist":
+			for _, value := range args[name] {
+				for _, namespace := range strings.Split(value, ",") {
+					if namespace = strings.TrimSpace(namespace); namespace != "" {
+						spec.TargetNamespaces = append(spec.TargetNamespaces, namespace)
+					}
+				}
+			}
+		default:
+			if managedFluxArgs[name] {
+				continue
+			}
+
+			if len(args[name]) > 1 {
+				result.warn("--%s is repeated, only its last value %s is kept", name, value)
+			}
+
+			if spec.Args == nil {
+				spec.Args = map[string]string{}
+			}
+			spec.Args[name] = value
+		}
+	}
+
+	if spec.GitUrl == "" {
+		result.warn("flux has no --git-url, set spec.gitUrl")
+	}
+
+	// Keep the tag flux has been syncing so that it does not start over.
+	if syncTag != fmt.Sprintf("flux-sync-%s", result.Flux.Name) {
+		if spec.Args == nil {
+			spec.Args = map[string]string{}
+		}
+		spec.Args["git-sync-tag"] = syncTag
+	}
+
+	mountPath := "/etc/fluxd/ssh"
+	if args["k8s-secret-volume-mount-path"] != nil {
+		mountPath = last(args["k8s-secret-volume-mount-path"])
+	}
+
+	migrateGitSecret(result, deployment, flux, mountPath)
+	migrateKnownHosts(result, install, options, deployment, flux)
+}
+
+// Find the secret holding flux's SSH key, mounted at mountPath, so that the
+// Flux keeps using it instead of generating a new deploy key.
+func migrateGitSecret(result *Result, deployment *extensions.Deployment, flux *corev1.Container, mountPath string) {
+	spec := &result.Flux.Spec
+	if spec.GitSecret != "" {
+		return
+	}
+
+	volume, _ := mountedVolume(deployment, flux, func(path string) bool {
+		return strings.TrimSuffix(path, "/") == mountPath
+	})
+
+	if volume != nil && volume.Secret != nil {
+		spec.GitSecret = volume.Secret.SecretName
+	} else {
+		// The name flux uses when --k8s-secret-name is not set.
+		spec.GitSecret = "flux-git-deploy"
+	}
+}
+
+// Copy the known_hosts mounted into flux from a ConfigMap into the Flux.
+func migrateKnownHosts(result *Result, install *Install, options Options, deployment *extensions.Deployment, flux *corev1.Container) {
+	volume, mount := mountedVolume(deployment, flux, func(path string) bool {
+		path = strings.TrimSuffix(path, "/")
+		return path == "/root/.ssh" || strings.HasSuffix(path, "/known_hosts")
+	})
+
+	if volume == nil {
+		return
+	}
+
+	if volume.ConfigMap == nil {
+		result.warn("known_hosts is not mounted from a ConfigMap, set spec.knownHosts")
+		return
+	}
+
+	key := "known_hosts"
+	if mount.SubPath != "" {
+		key = mount.SubPath
+	}
+
+	namespace := options.namespaceOf(deployment.ObjectMeta)
+	for _, configMap := range install.ConfigMaps {
+		if configMap.Name == volume.ConfigMap.Name && options.namespaceOf(configMap.ObjectMeta) == namespace {
+			if configMap.Data[key] == "" {
+				break
+			}
+
+			result.Flux.Spec.KnownHosts = configMap.Data[key]
+			return
+		}
+	}
+
+	result.warn("known_hosts %s not found in ConfigMap %s, set spec.knownHosts", key, volume.ConfigMap.Name)
+}
+
+// Migrate the settings of the helm-operator Deployment to the Flux.
+func migrateHelmOperator(result *Result, deployment *extensions.Deployment) {
+	spec := &result.Flux.Spec
+	helmOperator := container(deployment, "helm-operator")
+
+	spec.HelmOperator.Enabled = true
+	spec.HelmOperator.HelmOperatorImage, spec.HelmOperator.HelmOperatorVersion = splitImage(helmOperator.Image)
+	spec.HelmOperator.Resources = resources(helmOperator)
+
+	args := parseArgs(helmOperator.Args)
+
+	// helm-operator's own defaults, used when the args are not set.
+	chartPath, pollInterval, syncInterval := "charts", "5m", "3m"
+
+	for _, name := range argNames(args) {
+		value := last(args[name])
+
+		switch name {
+		case "git-url":
+			if value != spec.GitUrl {
+				spec.HelmOperator.GitUrl = value
+			}
+		case "git-charts-path":
+			chartPath = value
+		case "git-poll-interval":
+			pollInterval = value
+		case "charts-sync-interval":
+			syncInterval = value
+		case "tiller-namespace", "tiller-ip", "tiller-port":
+			// The operator points helm-operator at the Flux's tiller.
+		default:
+			result.warn("helm-operator --%s is not supported by the Flux and is dropped", name)
+		}
+	}
+
+	if !isRootPath(chartPath) {
+		spec.HelmOperator.ChartPath = chartPath
+	}
+
+	// The operator defaults to the Flux's intervals, or 3m.
+	defaultPoll := spec.GitPollInterval
+	if defaultPoll == "" {
+		defaultPoll = "3m"
+	}
+
+	if !sameDuration(pollInterval, defaultPoll) {
+		spec.HelmOperator.GitPollInterval = pollInterval
+	}
+
+	defaultSync := spec.SyncInterval
+	if defaultSync == "" {
+		defaultSync = "3m"
+	}
+
+	if !sameDuration(syncInterval, defaultSync) {
+		spec.HelmOperator.ChartsSyncInterval = syncInterval
+	}
+}
+
+// Migrate the settings of the tiller Deployment to the Flux.
+func migrateTiller(result *Result, deployment *extensions.Deployment) {
+	tiller := container(deployment, "tiller")
+
+	result.Flux.Spec.Tiller.Enabled = true
+	result.Flux.Spec.Tiller.TillerImage, result.Flux.Spec.Tiller.TillerVersion = splitImage(tiller.Image)
+}
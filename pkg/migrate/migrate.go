@@ -0,0 +1,289 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sort"
+	"strings"
+)
+
+// The objects of an existing flux install, e.g. one applied from the upstream
+// example manifests.
+type Install struct {
+	Deployments []extensions.Deployment
+	Services    []corev1.Service
+	ConfigMaps  []corev1.ConfigMap
+}
+
+// Add the Deployments, Services and ConfigMaps in a stream of YAML or JSON
+// documents to the install, other kinds are skipped.
+func (i *Install) Parse(reader io.Reader) error {
+	decoder := k8syaml.NewYAMLOrJSONDecoder(reader, 4096)
+
+	for document := 1; ; document++ {
+		raw := map[string]interface{}{}
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("document %d: %v", document, err)
+		}
+
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("document %d: %v", document, err)
+		}
+
+		switch raw["kind"] {
+		case "Deployment":
+			deployment := extensions.Deployment{}
+			err = json.Unmarshal(encoded, &deployment)
+			i.Deployments = append(i.Deployments, deployment)
+		case "Service":
+			service := corev1.Service{}
+			err = json.Unmarshal(encoded, &service)
+			i.Services = append(i.Services, service)
+		case "ConfigMap":
+			configMap := corev1.ConfigMap{}
+			err = json.Unmarshal(encoded, &configMap)
+			i.ConfigMaps = append(i.ConfigMaps, configMap)
+		}
+
+		if err != nil {
+			return fmt.Errorf("document %d: %v", document, err)
+		}
+	}
+}
+
+// How to migrate an install to a Flux.
+type Options struct {
+	// The name of the Flux (default: the name of the flux Deployment).
+	Name string
+	// The namespace of the install, objects without a namespace are assumed to
+	// be in it (default: `default`).
+	Namespace string
+	// The name of the flux Deployment, required if there is more than one.
+	Deployment string
+	// Create a Flux for the cluster scoped CRD, setting `spec.namespace` instead
+	// of its namespace.
+	Cluster bool
+}
+
+// A Flux migrated from an existing install.
+type Result struct {
+	Flux *v1alpha1.Flux
+	// The settings that could not be migrated and need to be reviewed.
+	Warnings []string
+	// The existing Deployments and Services that the Flux's objects replace.
+	// Once they are labeled as owned by the Flux, the operator deletes them after
+	// creating its own.
+	Replaced []runtime.Object
+}
+
+func (r *Result) warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Return the namespace of obj, or the default namespace if it has none.
+func (o Options) namespaceOf(obj metav1.ObjectMeta) string {
+	if obj.Namespace != "" {
+		return obj.Namespace
+	}
+
+	if o.Namespace != "" {
+		return o.Namespace
+	}
+
+	return "default"
+}
+
+// Return the Deployments in namespace running an image named component.
+func (o Options) components(install *Install, namespace, component string) []extensions.Deployment {
+	found := []extensions.Deployment{}
+	for _, deployment := range install.Deployments {
+		if namespace != "" && o.namespaceOf(deployment.ObjectMeta) != namespace {
+			continue
+		}
+
+		if container(&deployment, component) != nil {
+			found = append(found, deployment)
+		}
+	}
+	return found
+}
+
+// Return the flux Deployment to migrate.
+func (o Options) fluxDeployment(install *Install) (*extensions.Deployment, error) {
+	candidates := []extensions.Deployment{}
+	names := []string{}
+
+	for _, deployment := range o.components(install, o.Namespace, "flux") {
+		if o.Deployment == "" || deployment.Name == o.Deployment {
+			candidates = append(candidates, deployment)
+			names = append(names, fmt.Sprintf("%s/%s", o.namespaceOf(deployment.ObjectMeta), deployment.Name))
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no flux Deployment found")
+	} else if len(candidates) > 1 {
+		sort.Strings(names)
+		return nil, fmt.Errorf("found %d flux Deployments (%s), select one by name", len(candidates), strings.Join(names, ", "))
+	}
+
+	return &candidates[0], nil
+}
+
+// Create a Flux equivalent to the flux, helm-operator and tiller Deployments of
+// an install, the reverse of the operator's `MakeFluxArgs` and
+// `MakeHelmOperatorArgs`.
+func Migrate(install *Install, options Options) (*Result, error) {
+	fluxDeployment, err := options.fluxDeployment(install)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := options.namespaceOf(fluxDeployment.ObjectMeta)
+
+	name := options.Name
+	if name == "" {
+		name = fluxDeployment.Name
+	}
+
+	cr := &v1alpha1.Flux{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Flux",
+			APIVersion: "flux.codesink.net/v1alpha1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	if options.Cluster {
+		cr.ObjectMeta.Namespace = ""
+		cr.Spec.Namespace = namespace
+	}
+
+	result := &Result{Flux: cr}
+	replaced := []extensions.Deployment{*fluxDeployment}
+
+	migrateFlux(result, install, options, fluxDeployment)
+
+	helmOperators := options.components(install, namespace, "helm-operator")
+	if len(helmOperators) > 1 {
+		result.warn("found %d helm-operator Deployments in %s, only %s is migrated", len(helmOperators), namespace, helmOperators[0].Name)
+	}
+
+	tillerNamespace := namespace
+	if len(helmOperators) > 0 {
+		migrateHelmOperator(result, &helmOperators[0])
+		replaced = append(replaced, helmOperators[0])
+
+		if args := parseArgs(container(&helmOperators[0], "helm-operator").Args); args["tiller-namespace"] != nil {
+			tillerNamespace = last(args["tiller-namespace"])
+		} else {
+			tillerNamespace = "kube-system"
+		}
+	}
+
+	tillers := options.components(install, tillerNamespace, "tiller")
+	if len(tillers) > 0 {
+		migrateTiller(result, &tillers[0])
+
+		// A tiller in another namespace may be shared, so it is left running.
+		if tillerNamespace == namespace {
+			replaced = append(replaced, tillers[0])
+		} else {
+			result.warn("tiller in %s is left running, helm-operator uses a new tiller in %s", tillerNamespace, namespace)
+		}
+	} else if len(helmOperators) > 0 {
+		cr.Spec.Tiller.Enabled = true
+		result.warn("no tiller Deployment found in %s, the Flux deploys its own tiller in %s", tillerNamespace, namespace)
+	}
+
+	replaced = append(replaced, options.components(install, namespace, "memcached")...)
+
+	if fluxclouds := options.components(install, namespace, "fluxcloud"); len(fluxclouds) > 0 {
+		result.warn("fluxcloud is not migrated, configure spec.fluxCloud and delete Deployment %s once it works", fluxclouds[0].Name)
+	}
+
+	if account := fluxDeployment.Spec.Template.Spec.ServiceAccountName; account != "" {
+		result.warn("RBAC is not migrated, grant the Flux the roles bound to ServiceAccount %s with spec.role, spec.clusterRole or spec.roleRefs", account)
+	}
+
+	for _, deployment := range replaced {
+		deployment := deployment.DeepCopy()
+		deployment.Namespace = namespace
+		if deployment.APIVersion == "" {
+			deployment.TypeMeta = metav1.TypeMeta{Kind: "Deployment", APIVersion: "extensions/v1beta1"}
+		}
+		result.Replaced = append(result.Replaced, deployment)
+
+		for _, service := range install.Services {
+			if options.namespaceOf(service.ObjectMeta) != namespace || len(service.Spec.Selector) == 0 {
+				continue
+			}
+
+			if labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(deployment.Spec.Template.Labels)) {
+				service := service.DeepCopy()
+				service.Namespace = namespace
+				if service.APIVersion == "" {
+					service.TypeMeta = metav1.TypeMeta{Kind: "Service", APIVersion: "v1"}
+				}
+				result.Replaced = append(result.Replaced, service)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Remove the empty strings and objects from a decoded JSON object, such as the
+// structs in a Flux's spec that can not be omitted when empty.
+func dropEmpty(obj map[string]interface{}) {
+	for key, value := range obj {
+		if nested, ok := value.(map[string]interface{}); ok {
+			dropEmpty(nested)
+			if len(nested) == 0 {
+				delete(obj, key)
+			}
+		} else if value == "" || value == nil {
+			delete(obj, key)
+		}
+	}
+}
+
+// Write a migrated Flux as YAML, leaving out its status and empty settings.
+func Write(w io.Writer, cr *v1alpha1.Flux) error {
+	encoded, err := json.Marshal(cr)
+	if err != nil {
+		return err
+	}
+
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(encoded, &obj); err != nil {
+		return err
+	}
+
+	delete(obj, "status")
+	dropEmpty(obj)
+
+	contents, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(contents)
+	return err
+}
@@ -0,0 +1,146 @@
+package migrate
+
+import (
+	"bytes"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"os"
+	"strings"
+	"testing"
+)
+
+func readTestInstall(t *testing.T) *Install {
+	file, err := os.Open("testdata/upstream.yaml")
+	assert.Nil(t, err)
+	defer file.Close()
+
+	install := &Install{}
+	assert.Nil(t, install.Parse(file))
+	return install
+}
+
+func TestParse(t *testing.T) {
+	install := readTestInstall(t)
+	assert.Equal(t, 4, len(install.Deployments))
+	assert.Equal(t, 1, len(install.Services))
+	assert.Equal(t, 1, len(install.ConfigMaps))
+	assert.Equal(t, "apps/v1", install.Deployments[0].APIVersion)
+}
+
+func TestParseArgs(t *testing.T) {
+	args := parseArgs([]string{"--a=1", "--b", "2", "-c", "--d", "--a=3", "ignored"})
+	assert.Equal(t, map[string][]string{
+		"a": []string{"1", "3"},
+		"b": []string{"2"},
+		"c": []string{"true"},
+		"d": []string{"true"},
+	}, args)
+}
+
+func TestSplitImage(t *testing.T) {
+	image, tag := splitImage("quay.io/weaveworks/flux:1.8.1")
+	assert.Equal(t, "quay.io/weaveworks/flux", image)
+	assert.Equal(t, "1.8.1", tag)
+
+	image, tag = splitImage("localhost:5000/flux")
+	assert.Equal(t, "localhost:5000/flux", image)
+	assert.Equal(t, "", tag)
+
+	assert.Equal(t, "helm-operator", imageBase("quay.io/weaveworks/helm-operator:0.4.0"))
+}
+
+func TestMigrate(t *testing.T) {
+	result, err := Migrate(readTestInstall(t), Options{})
+	assert.Nil(t, err)
+
+	cr := result.Flux
+	assert.Equal(t, "flux", cr.Name)
+	assert.Equal(t, "flux", cr.Namespace)
+	assert.Equal(t, "", cr.Spec.Namespace)
+
+	spec := cr.Spec
+	assert.Equal(t, "quay.io/weaveworks/flux", spec.FluxImage)
+	assert.Equal(t, "1.8.1", spec.FluxVersion)
+	assert.Equal(t, "64Mi", spec.Resources.Requests.Memory().String())
+	assert.Equal(t, "git@git.example.com:example/cluster", spec.GitUrl)
+	assert.Equal(t, "", spec.GitBranch)
+	assert.Equal(t, "namespaces,workloads", spec.GitPath)
+	assert.Equal(t, "1m", spec.GitPollInterval)
+	assert.Equal(t, "", spec.SyncInterval)
+	assert.Equal(t, []string{"apps", "monitoring"}, spec.TargetNamespaces)
+	assert.Equal(t, map[string]string{"git-ci-skip": "true", "git-sync-tag": "flux-sync"}, spec.Args)
+	assert.Equal(t, "flux-git-deploy", spec.GitSecret)
+	assert.Equal(t, "git.example.com ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQC\n", spec.KnownHosts)
+
+	assert.True(t, spec.HelmOperator.Enabled)
+	assert.Equal(t, "quay.io/weaveworks/helm-operator", spec.HelmOperator.HelmOperatorImage)
+	assert.Equal(t, "0.4.0", spec.HelmOperator.HelmOperatorVersion)
+	assert.Equal(t, "", spec.HelmOperator.GitUrl)
+	assert.Equal(t, "charts", spec.HelmOperator.ChartPath)
+	assert.Equal(t, "5m", spec.HelmOperator.GitPollInterval)
+	assert.Equal(t, "5m", spec.HelmOperator.ChartsSyncInterval)
+
+	assert.True(t, spec.Tiller.Enabled)
+	assert.Equal(t, "v2.10.0", spec.Tiller.TillerVersion)
+
+	assert.Equal(t, 3, len(result.Warnings))
+	assert.Contains(t, strings.Join(result.Warnings, "\n"), "--log-release-diffs")
+	assert.Contains(t, strings.Join(result.Warnings, "\n"), "tiller in kube-system is left running")
+	assert.Contains(t, strings.Join(result.Warnings, "\n"), "ServiceAccount flux")
+
+	replaced := []string{}
+	for _, obj := range result.Replaced {
+		replaced = append(replaced, utils.ObjectName(obj))
+		objectMeta, _ := meta.Accessor(obj)
+		assert.Equal(t, "flux", objectMeta.GetNamespace())
+	}
+
+	assert.Equal(t, []string{
+		"flux:Deployment/flux",
+		"flux:Deployment/flux-helm-operator",
+		"flux:Deployment/memcached",
+		"flux:Service/memcached",
+	}, replaced)
+}
+
+func TestMigrateCluster(t *testing.T) {
+	result, err := Migrate(readTestInstall(t), Options{Name: "example", Cluster: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "example", result.Flux.Name)
+	assert.Equal(t, "", result.Flux.Namespace)
+	assert.Equal(t, "flux", result.Flux.Spec.Namespace)
+}
+
+func TestMigrateSelectsDeployment(t *testing.T) {
+	install := readTestInstall(t)
+	second := install.Deployments[0].DeepCopy()
+	second.Name = "flux-two"
+	install.Deployments = append(install.Deployments, *second)
+
+	_, err := Migrate(install, Options{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "flux/flux, flux/flux-two")
+
+	result, err := Migrate(install, Options{Deployment: "flux-two"})
+	assert.Nil(t, err)
+	assert.Equal(t, "flux-two", result.Flux.Name)
+
+	_, err = Migrate(install, Options{Namespace: "other"})
+	assert.NotNil(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	result, err := Migrate(readTestInstall(t), Options{})
+	assert.Nil(t, err)
+
+	output := &bytes.Buffer{}
+	assert.Nil(t, Write(output, result.Flux))
+
+	assert.Contains(t, output.String(), "kind: Flux\n")
+	assert.Contains(t, output.String(), "gitUrl: git@git.example.com:example/cluster\n")
+	assert.NotContains(t, output.String(), "status")
+	assert.NotContains(t, output.String(), "creationTimestamp")
+	assert.NotContains(t, output.String(), "fluxCloud")
+	assert.NotContains(t, output.String(), "role: {}")
+}
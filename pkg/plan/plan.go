@@ -0,0 +1,238 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// The action a reconcile would take on an object.
+type Action string
+
+const (
+	Create Action = "create"
+	Update Action = "update"
+	Delete Action = "delete"
+)
+
+// A single field that would be changed by an update.
+type FieldChange struct {
+	// The path to the field, e.g. `spec.replicas`.
+	Path string
+	// The current value of the field, JSON encoded.
+	Old string
+	// The value the field would be set to, JSON encoded.
+	New string
+}
+
+// A change that a reconcile would make to one object.
+type Change struct {
+	Action Action
+	// The human readable name of the object.
+	Object string
+	// For updates, the fields that would change.
+	Fields []FieldChange
+}
+
+// The changes a reconcile would make.
+type Plan struct {
+	Changes []Change
+}
+
+// Compute the changes that CreateOrUpdate and GarbageCollectResources would make
+// to move from existingObjs to desiredObjs.
+func NewPlan(existingObjs []runtime.Object, desiredObjs []runtime.Object) (plan Plan) {
+	for _, desired := range desiredObjs {
+		existing := utils.GetObject(desired, existingObjs)
+		if existing == nil {
+			plan.Changes = append(plan.Changes, Change{
+				Action: Create,
+				Object: utils.ObjectName(desired),
+			})
+			continue
+		}
+
+		if utils.GetObjectHash(existing) == utils.GetObjectHash(desired) {
+			continue
+		}
+
+		plan.Changes = append(plan.Changes, Change{
+			Action: Update,
+			Object: utils.ObjectName(desired),
+			Fields: DiffObjects(existing, desired),
+		})
+	}
+
+	for _, existing := range existingObjs {
+		if utils.GetObject(existing, desiredObjs) != nil {
+			continue
+		}
+
+		plan.Changes = append(plan.Changes, Change{
+			Action: Delete,
+			Object: utils.ObjectName(existing),
+		})
+	}
+
+	return
+}
+
+// Return true if the plan makes no changes.
+func (p Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Return a one line summary of the plan, e.g. `1 to create, 2 to update, 0 to delete`.
+func (p Plan) Summary() string {
+	counts := map[Action]int{}
+	for _, change := range p.Changes {
+		counts[change.Action]++
+	}
+
+	return fmt.Sprintf("%d to create, %d to update, %d to delete",
+		counts[Create], counts[Update], counts[Delete])
+}
+
+// Return a human readable description of every change in the plan.
+func (p Plan) String() string {
+	if p.Empty() {
+		return "No changes."
+	}
+
+	lines := []string{p.Summary()}
+	for _, change := range p.Changes {
+		lines = append(lines, fmt.Sprintf("%s %s", change.Action, change.Object))
+		for _, field := range change.Fields {
+			lines = append(lines, fmt.Sprintf("  %s: %s -> %s", field.Path, field.Old, field.New))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Convert an object to its generic JSON representation.
+func toMap(obj runtime.Object) map[string]interface{} {
+	out := map[string]interface{}{}
+
+	encoded, err := json.Marshal(obj)
+	if err != nil {
+		return out
+	}
+
+	json.Unmarshal(encoded, &out)
+	return out
+}
+
+// Encode a value for display, `<unset>` if it is not set.
+func encode(value interface{}) string {
+	if value == nil {
+		return "<unset>"
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(encoded)
+}
+
+// Return true if the field at path is managed by the API server or the operator
+// itself and should not be reported.
+func ignored(path []string) bool {
+	if len(path) == 1 {
+		return path[0] == "status"
+	}
+
+	if path[0] != "metadata" {
+		return false
+	}
+
+	if path[1] != "labels" && path[1] != "annotations" {
+		return true
+	}
+
+	return len(path) == 3 && path[1] == "annotations" && path[2] == "flux.codesink.net.hash"
+}
+
+// Return the fields set on desired that differ from existing. Fields that are not
+// set on desired are ignored since they are defaulted by the API server.
+func DiffObjects(existing runtime.Object, desired runtime.Object) (changes []FieldChange) {
+	diff([]string{}, toMap(existing), toMap(desired), &changes)
+	return
+}
+
+func formatPath(path []string) string {
+	formatted := ""
+	for _, part := range path {
+		if strings.HasPrefix(part, "[") {
+			formatted += part
+		} else if formatted == "" {
+			formatted = part
+		} else {
+			formatted += "." + part
+		}
+	}
+	return formatted
+}
+
+func diff(path []string, existing interface{}, desired interface{}, changes *[]FieldChange) {
+	if len(path) > 0 && ignored(path) {
+		return
+	}
+
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		existingValue, _ := existing.(map[string]interface{})
+
+		keys := []string{}
+		for key := range desiredValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			diff(append(path[:len(path):len(path)], key), existingValue[key], desiredValue[key], changes)
+		}
+	case []interface{}:
+		existingValue, ok := existing.([]interface{})
+		if !ok || len(existingValue) != len(desiredValue) || !containsMaps(desiredValue) {
+			if !reflect.DeepEqual(existing, desired) {
+				*changes = append(*changes, FieldChange{
+					Path: formatPath(path),
+					Old:  encode(existing),
+					New:  encode(desired),
+				})
+			}
+			return
+		}
+
+		for index := range desiredValue {
+			diff(append(path[:len(path):len(path)], fmt.Sprintf("[%d]", index)),
+				existingValue[index], desiredValue[index], changes)
+		}
+	default:
+		if !reflect.DeepEqual(existing, desired) {
+			*changes = append(*changes, FieldChange{
+				Path: formatPath(path),
+				Old:  encode(existing),
+				New:  encode(desired),
+			})
+		}
+	}
+}
+
+// Return true if any item in the list is an object.
+func containsMaps(list []interface{}) bool {
+	for _, item := range list {
+		if _, ok := item.(map[string]interface{}); ok {
+			return true
+		}
+	}
+	return false
+}
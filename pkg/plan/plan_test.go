@@ -0,0 +1,88 @@
+package plan
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/memcached"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/justinbarrick/flux-operator/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"testing"
+)
+
+func TestNewPlanNoChanges(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	existing := memcached.NewMemcachedDeployment(cr)
+	utils.SetObjectHash(existing)
+	desired := memcached.NewMemcachedDeployment(cr)
+	utils.SetObjectHash(desired)
+
+	plan := NewPlan([]runtime.Object{existing}, []runtime.Object{desired})
+	assert.True(t, plan.Empty())
+	assert.Equal(t, "No changes.", plan.String())
+}
+
+func TestNewPlanCreateAndDelete(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	service := memcached.NewMemcachedService(cr)
+	deployment := memcached.NewMemcachedDeployment(cr)
+
+	plan := NewPlan([]runtime.Object{service}, []runtime.Object{deployment})
+	assert.Equal(t, []Change{
+		{Action: Create, Object: utils.ObjectName(deployment)},
+		{Action: Delete, Object: utils.ObjectName(service)},
+	}, plan.Changes)
+	assert.Equal(t, "1 to create, 0 to update, 1 to delete", plan.Summary())
+}
+
+func TestNewPlanUpdate(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	existing := memcached.NewMemcachedDeployment(cr)
+	existing.ObjectMeta.ResourceVersion = "1234"
+	existing.Spec.Template.Spec.Containers[0].TerminationMessagePath = "/dev/termination-log"
+	utils.SetObjectHash(existing)
+
+	desired := memcached.NewMemcachedDeployment(cr)
+	desired.Spec.Template.Spec.Containers[0].Image = "memcached:1.5"
+	desired.Spec.Template.Spec.Containers[0].Args = []string{"-m 128"}
+	utils.SetObjectHash(desired)
+
+	plan := NewPlan([]runtime.Object{existing}, []runtime.Object{desired})
+	assert.Equal(t, []Change{
+		{
+			Action: Update,
+			Object: utils.ObjectName(desired),
+			Fields: []FieldChange{
+				{
+					Path: "spec.template.spec.containers[0].args",
+					Old:  `["-m 64","-p 11211","-vv"]`,
+					New:  `["-m 128"]`,
+				},
+				{
+					Path: "spec.template.spec.containers[0].image",
+					Old:  `"memcached:1.4.36-alpine"`,
+					New:  `"memcached:1.5"`,
+				},
+			},
+		},
+	}, plan.Changes)
+
+	assert.Equal(t, `0 to create, 1 to update, 0 to delete
+update `+utils.ObjectName(desired)+`
+  spec.template.spec.containers[0].args: ["-m 64","-p 11211","-vv"] -> ["-m 128"]
+  spec.template.spec.containers[0].image: "memcached:1.4.36-alpine" -> "memcached:1.5"`, plan.String())
+}
+
+func TestDiffObjectsLabels(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	existing := memcached.NewMemcachedService(cr)
+	desired := memcached.NewMemcachedService(cr)
+	desired.ObjectMeta.Labels = map[string]string{"new": "label"}
+
+	assert.Equal(t, []FieldChange{
+		{Path: "metadata.labels.new", Old: "<unset>", New: `"label"`},
+	}, DiffObjects(existing, desired))
+}
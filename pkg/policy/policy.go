@@ -0,0 +1,300 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/rbac"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Return true if the policy applies to Fluxes in namespace.
+func Matches(policy v1alpha1.FluxPolicy, namespace *corev1.Namespace) (bool, error) {
+	if policy.Spec.NamespaceSelector == nil {
+		return true, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespace selector in policy %s: %v", policy.Name, err)
+	}
+
+	return selector.Matches(labels.Set(namespace.Labels)), nil
+}
+
+// Return true if the policy allows the Fluxes it applies to to target namespace,
+// which is nil if the namespace does not exist.
+func AllowsNamespace(policy v1alpha1.FluxPolicy, namespace *corev1.Namespace) (bool, error) {
+	if policy.Spec.AllowedTargetNamespaces == nil {
+		return false, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(policy.Spec.AllowedTargetNamespaces)
+	if err != nil {
+		return false, fmt.Errorf("invalid allowed target namespaces in policy %s: %v", policy.Name, err)
+	}
+
+	namespaceLabels := labels.Set{}
+	if namespace != nil {
+		namespaceLabels = labels.Set(namespace.Labels)
+	}
+
+	return selector.Matches(namespaceLabels), nil
+}
+
+// Return the namespaces other than its own that a Flux creates roles or binds
+// roles in: its target namespaces and the namespaces of its role references.
+func ForeignNamespaces(cr *v1alpha1.Flux) []string {
+	namespaces := rbac.RoleNamespaces(cr)
+	for _, ref := range cr.Spec.RoleRefs {
+		if rbac.ValidateRoleRef(ref) == nil {
+			namespaces = append(namespaces, rbac.RoleRefNamespace(cr, ref))
+		}
+	}
+
+	foreign := []string{}
+	seen := map[string]bool{"": true, utils.FluxNamespace(cr): true}
+
+	for _, namespace := range namespaces {
+		if !seen[namespace] {
+			seen[namespace] = true
+			foreign = append(foreign, namespace)
+		}
+	}
+
+	return foreign
+}
+
+// Return the names of the roles a Flux binds in every namespace with a
+// ClusterRoleBinding, none if cluster roles are disabled.
+func ClusterRoleRefs(cr *v1alpha1.Flux) []string {
+	names := []string{}
+	if config.Get().DisableClusterRoles {
+		return names
+	}
+
+	for _, ref := range cr.Spec.RoleRefs {
+		if rbac.ValidateRoleRef(ref) == nil && rbac.RoleRefNamespace(cr, ref) == "" {
+			names = append(names, ref.Name)
+		}
+	}
+
+	return names
+}
+
+// Return the namespaces other than its own that a namespaced Flux targets or
+// binds roles in, and the roles it binds in every namespace, without a policy
+// allowing it. policies are the policies that apply to the Flux and namespaces
+// are the foreign namespaces that exist by name. Fluxes that are not namespaced
+// can only be created by cluster admins and may target any namespace.
+func NamespaceViolations(cr *v1alpha1.Flux, policies []v1alpha1.FluxPolicy, namespaces map[string]*corev1.Namespace) ([]string, error) {
+	violations := []string{}
+	if cr.Namespace == "" {
+		return violations, nil
+	}
+
+	for _, name := range ForeignNamespaces(cr) {
+		allowed := false
+
+		for _, policy := range policies {
+			var err error
+			allowed, err = AllowsNamespace(policy, namespaces[name])
+			if err != nil {
+				return nil, err
+			}
+
+			if allowed {
+				break
+			}
+		}
+
+		if !allowed {
+			violations = append(violations, fmt.Sprintf("namespace %s is not allowed by any policy", name))
+		}
+	}
+
+	allowClusterRoles := false
+	for _, policy := range policies {
+		if policy.Spec.AllowClusterRoles {
+			allowClusterRoles = true
+		}
+	}
+
+	if !allowClusterRoles {
+		for _, name := range ClusterRoleRefs(cr) {
+			violations = append(violations, fmt.Sprintf("binding cluster role %s in every namespace is not allowed by any policy", name))
+		}
+	}
+
+	return violations, nil
+}
+
+// Return true if value fully matches any of patterns.
+func matchesAny(policy v1alpha1.FluxPolicy, patterns []string, value string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := regexp.MatchString(fmt.Sprintf("^(?:%s)$", pattern), value)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern %q in policy %s: %v", pattern, policy.Name, err)
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Return the git URLs used by a Flux.
+func gitUrls(cr *v1alpha1.Flux) []string {
+	urls := []string{cr.Spec.GitUrl}
+	if cr.Spec.HelmOperator.Enabled && cr.Spec.HelmOperator.GitUrl != "" {
+		urls = append(urls, cr.Spec.HelmOperator.GitUrl)
+	}
+	return urls
+}
+
+// Return the images of every container in the desired Deployments.
+func images(desiredObjs []runtime.Object) []string {
+	images := []string{}
+
+	for _, obj := range desiredObjs {
+		deployment, ok := obj.(*extensions.Deployment)
+		if !ok {
+			continue
+		}
+
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			images = append(images, container.Image)
+		}
+	}
+
+	return images
+}
+
+// Return the roles, cluster roles and their bindings among objects. They are
+// deleted when a Flux violates a policy, revoking any access it forbids until
+// the Flux complies again.
+func RoleObjects(objects []runtime.Object) []runtime.Object {
+	roles := []runtime.Object{}
+
+	for _, object := range objects {
+		switch object.(type) {
+		case *rbacv1.Role, *rbacv1.RoleBinding, *rbacv1.ClusterRole, *rbacv1.ClusterRoleBinding:
+			roles = append(roles, object)
+		}
+	}
+
+	return roles
+}
+
+// Return true if the Flux is among the oldest max Fluxes in its namespace.
+func withinMaxFluxes(cr *v1alpha1.Flux, fluxes []v1alpha1.Flux, max int32) bool {
+	sorted := append([]v1alpha1.Flux{}, fluxes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		first := sorted[i].CreationTimestamp
+		second := sorted[j].CreationTimestamp
+		if !first.Equal(&second) {
+			return first.Before(&second)
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	for index, flux := range sorted {
+		if int32(index) >= max {
+			break
+		}
+
+		if flux.Name == cr.Name && flux.Namespace == cr.Namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Return the ways in which a Flux violates a policy. desiredObjs are the Flux's
+// desired resources and fluxes are all of the Fluxes in its namespace.
+func Violations(policy v1alpha1.FluxPolicy, cr *v1alpha1.Flux, desiredObjs []runtime.Object, fluxes []v1alpha1.Flux) ([]string, error) {
+	violations := []string{}
+	spec := policy.Spec
+
+	if len(spec.AllowedGitUrls) > 0 {
+		for _, url := range gitUrls(cr) {
+			matched, err := matchesAny(policy, spec.AllowedGitUrls, url)
+			if err != nil {
+				return nil, err
+			}
+
+			if !matched {
+				violations = append(violations, fmt.Sprintf("%s: git URL %s is not allowed", policy.Name, url))
+			}
+		}
+	}
+
+	if len(spec.AllowedImages) > 0 {
+		for _, image := range images(desiredObjs) {
+			matched, err := matchesAny(policy, spec.AllowedImages, image)
+			if err != nil {
+				return nil, err
+			}
+
+			if !matched {
+				violations = append(violations, fmt.Sprintf("%s: image %s is not allowed", policy.Name, image))
+			}
+		}
+	}
+
+	clusterRoles := cr.Spec.ClusterRole.Enabled && !config.Get().DisableClusterRoles
+	if clusterRoles && !spec.AllowClusterRoles {
+		violations = append(violations, fmt.Sprintf("%s: cluster roles are not allowed", policy.Name))
+	}
+
+	if !spec.AllowClusterRoles && !config.Get().DisableClusterRoles {
+		for _, ref := range cr.Spec.RoleRefs {
+			if rbac.RoleRefNamespace(cr, ref) == "" {
+				violations = append(violations, fmt.Sprintf("%s: binding cluster role %s in every namespace is not allowed", policy.Name, ref.Name))
+			}
+		}
+	}
+
+	if spec.MaxRoleRules != nil {
+		roles := map[string]v1alpha1.FluxRole{}
+		if cr.Spec.Role.Enabled && !config.Get().DisableRoles {
+			roles["role"] = cr.Spec.Role
+		}
+		if clusterRoles {
+			roles["cluster role"] = cr.Spec.ClusterRole
+		}
+
+		for _, kind := range []string{"role", "cluster role"} {
+			role, ok := roles[kind]
+			if !ok {
+				continue
+			}
+
+			if len(role.Rules) == 0 {
+				violations = append(violations, fmt.Sprintf("%s: %s grants full access, rules must be set", policy.Name, kind))
+			} else if len(role.Rules) > int(*spec.MaxRoleRules) {
+				violations = append(violations, fmt.Sprintf("%s: %s has %d rules, at most %d are allowed",
+					policy.Name, kind, len(role.Rules), *spec.MaxRoleRules))
+			}
+		}
+	}
+
+	if spec.MaxFluxes != nil && !withinMaxFluxes(cr, fluxes, *spec.MaxFluxes) {
+		violations = append(violations, fmt.Sprintf("%s: at most %d Fluxes are allowed in the namespace",
+			policy.Name, *spec.MaxFluxes))
+	}
+
+	return violations, nil
+}
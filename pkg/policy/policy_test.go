@@ -0,0 +1,303 @@
+package policy
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/memcached"
+	"github.com/justinbarrick/flux-operator/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"os"
+	"testing"
+	"time"
+)
+
+func newPolicy(spec v1alpha1.FluxPolicySpec) v1alpha1.FluxPolicy {
+	return v1alpha1.FluxPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "tenants",
+		},
+		Spec: spec,
+	}
+}
+
+func int32Ptr(value int32) *int32 {
+	return &value
+}
+
+func TestMatches(t *testing.T) {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "team-a",
+			Labels: map[string]string{"tenant": "true"},
+		},
+	}
+
+	matches, err := Matches(newPolicy(v1alpha1.FluxPolicySpec{}), namespace)
+	assert.Nil(t, err)
+	assert.True(t, matches)
+
+	matches, err = Matches(newPolicy(v1alpha1.FluxPolicySpec{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"tenant": "true"},
+		},
+	}), namespace)
+	assert.Nil(t, err)
+	assert.True(t, matches)
+
+	matches, err = Matches(newPolicy(v1alpha1.FluxPolicySpec{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"tenant": "false"},
+		},
+	}), namespace)
+	assert.Nil(t, err)
+	assert.False(t, matches)
+}
+
+func TestViolationsNone(t *testing.T) {
+	violations, err := Violations(newPolicy(v1alpha1.FluxPolicySpec{}), test_utils.NewFlux(), nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, violations)
+}
+
+func TestViolationsGitUrl(t *testing.T) {
+	policy := newPolicy(v1alpha1.FluxPolicySpec{
+		AllowedGitUrls: []string{`git@github\.com:myorg/.*`},
+	})
+
+	cr := test_utils.NewFlux()
+	violations, err := Violations(policy, cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tenants: git URL git@github.com:justinbarrick/manifests is not allowed"}, violations)
+
+	cr.Spec.GitUrl = "git@github.com:myorg/manifests"
+	violations, err = Violations(policy, cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, violations)
+
+	cr.Spec.HelmOperator.Enabled = true
+	cr.Spec.HelmOperator.GitUrl = "git@evil.com:myorg/charts"
+	violations, err = Violations(policy, cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tenants: git URL git@evil.com:myorg/charts is not allowed"}, violations)
+}
+
+func TestViolationsInvalidPattern(t *testing.T) {
+	_, err := Violations(newPolicy(v1alpha1.FluxPolicySpec{
+		AllowedGitUrls: []string{"("},
+	}), test_utils.NewFlux(), nil, nil)
+	assert.NotNil(t, err)
+}
+
+func TestViolationsImages(t *testing.T) {
+	cr := test_utils.NewFlux()
+	desired := []runtime.Object{memcached.NewMemcachedDeployment(cr)}
+
+	violations, err := Violations(newPolicy(v1alpha1.FluxPolicySpec{
+		AllowedImages: []string{`quay\.io/weaveworks/.*`},
+	}), cr, desired, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tenants: image memcached:1.4.36-alpine is not allowed"}, violations)
+
+	violations, err = Violations(newPolicy(v1alpha1.FluxPolicySpec{
+		AllowedImages: []string{`quay\.io/weaveworks/.*`, `memcached:.*`},
+	}), cr, desired, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, violations)
+}
+
+func TestViolationsClusterRoles(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.ClusterRole.Enabled = true
+
+	violations, err := Violations(newPolicy(v1alpha1.FluxPolicySpec{}), cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tenants: cluster roles are not allowed"}, violations)
+
+	violations, err = Violations(newPolicy(v1alpha1.FluxPolicySpec{
+		AllowClusterRoles: true,
+	}), cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, violations)
+
+	os.Setenv("DISABLE_CLUSTER_ROLES", "true")
+	defer os.Setenv("DISABLE_CLUSTER_ROLES", "")
+
+	violations, err = Violations(newPolicy(v1alpha1.FluxPolicySpec{}), cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, violations)
+}
+
+func TestViolationsClusterRoleRefs(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.RoleRefs = []v1alpha1.FluxRoleRef{
+		v1alpha1.FluxRoleRef{Name: "view"},
+		v1alpha1.FluxRoleRef{Name: "edit", Namespace: "default"},
+		v1alpha1.FluxRoleRef{Kind: "Role", Name: "deployer"},
+	}
+
+	violations, err := Violations(newPolicy(v1alpha1.FluxPolicySpec{}), cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tenants: binding cluster role view in every namespace is not allowed"}, violations)
+
+	violations, err = Violations(newPolicy(v1alpha1.FluxPolicySpec{
+		AllowClusterRoles: true,
+	}), cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, violations)
+}
+
+func TestViolationsMaxRoleRules(t *testing.T) {
+	policy := newPolicy(v1alpha1.FluxPolicySpec{
+		AllowClusterRoles: true,
+		MaxRoleRules:      int32Ptr(1),
+	})
+
+	cr := test_utils.NewFlux()
+	cr.Spec.Role.Enabled = true
+	cr.Spec.ClusterRole.Enabled = true
+	cr.Spec.ClusterRole.Rules = []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+		{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+	}
+
+	violations, err := Violations(policy, cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"tenants: role grants full access, rules must be set",
+		"tenants: cluster role has 2 rules, at most 1 are allowed",
+	}, violations)
+}
+
+func TestViolationsMaxFluxes(t *testing.T) {
+	policy := newPolicy(v1alpha1.FluxPolicySpec{
+		MaxFluxes: int32Ptr(1),
+	})
+
+	older := *test_utils.NewFlux()
+	older.ObjectMeta.Name = "older"
+	older.ObjectMeta.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+
+	cr := test_utils.NewFlux()
+	cr.ObjectMeta.CreationTimestamp = metav1.Now()
+
+	fluxes := []v1alpha1.Flux{*cr, older}
+
+	violations, err := Violations(policy, cr, nil, fluxes)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tenants: at most 1 Fluxes are allowed in the namespace"}, violations)
+
+	violations, err = Violations(policy, &older, nil, fluxes)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, violations)
+}
+
+func TestRoleObjects(t *testing.T) {
+	role := &rbacv1.Role{}
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{}
+
+	objects := []runtime.Object{&corev1.ServiceAccount{}, role, &corev1.Secret{}, clusterRoleBinding}
+	assert.Equal(t, []runtime.Object{role, clusterRoleBinding}, RoleObjects(objects))
+}
+
+func TestNamespaceViolations(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.Role.Enabled = true
+	cr.Spec.TargetNamespaces = []string{"default", "team-a", "kube-system"}
+
+	violations, err := NamespaceViolations(cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"namespace team-a is not allowed by any policy",
+		"namespace kube-system is not allowed by any policy",
+	}, violations)
+
+	policy := newPolicy(v1alpha1.FluxPolicySpec{
+		AllowedTargetNamespaces: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"team": "a"},
+		},
+	})
+	namespaces := map[string]*corev1.Namespace{
+		"team-a": &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}},
+		},
+		"kube-system": &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-system"},
+		},
+	}
+
+	violations, err = NamespaceViolations(cr, []v1alpha1.FluxPolicy{newPolicy(v1alpha1.FluxPolicySpec{}), policy}, namespaces)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"namespace kube-system is not allowed by any policy"}, violations)
+}
+
+func TestNamespaceViolationsClusterScoped(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.ObjectMeta.Namespace = ""
+	cr.Spec.Namespace = "default"
+	cr.Spec.TargetNamespaces = []string{"kube-system"}
+
+	violations, err := NamespaceViolations(cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, violations)
+}
+
+func TestAllowsNamespace(t *testing.T) {
+	allowed, err := AllowsNamespace(newPolicy(v1alpha1.FluxPolicySpec{}), &corev1.Namespace{})
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+
+	policy := newPolicy(v1alpha1.FluxPolicySpec{
+		AllowedTargetNamespaces: &metav1.LabelSelector{},
+	})
+
+	allowed, err = AllowsNamespace(policy, nil)
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+}
+
+func TestNamespaceViolationsRoleRefs(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.RoleRefs = []v1alpha1.FluxRoleRef{
+		{Kind: "ClusterRole", Name: "cluster-admin", Namespace: "kube-system"},
+		{Kind: "Role", Name: "deployer"},
+		{Name: "view"},
+	}
+
+	violations, err := NamespaceViolations(cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"namespace kube-system is not allowed by any policy",
+		"binding cluster role view in every namespace is not allowed by any policy",
+	}, violations)
+}
+
+func TestNamespaceViolationsClusterRoleRefs(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.RoleRefs = []v1alpha1.FluxRoleRef{
+		{Kind: "ClusterRole", Name: "cluster-admin"},
+	}
+
+	violations, err := NamespaceViolations(cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"binding cluster role cluster-admin in every namespace is not allowed by any policy",
+	}, violations)
+
+	violations, err = NamespaceViolations(cr, []v1alpha1.FluxPolicy{newPolicy(v1alpha1.FluxPolicySpec{})}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(violations))
+
+	policy := newPolicy(v1alpha1.FluxPolicySpec{AllowClusterRoles: true})
+	violations, err = NamespaceViolations(cr, []v1alpha1.FluxPolicy{policy}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, violations)
+
+	cr.ObjectMeta.Namespace = ""
+	cr.Spec.Namespace = "default"
+	violations, err = NamespaceViolations(cr, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, violations)
+}
@@ -2,7 +2,11 @@ package rbac
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/kinds"
 	"github.com/justinbarrick/flux-operator/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -10,6 +14,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// Register the kinds of objects this package creates for Fluxes.
+func init() {
+	kinds.Register(kinds.ServiceAccount, kinds.Role, kinds.RoleBinding, kinds.ClusterRole, kinds.ClusterRoleBinding)
+}
+
 func ServiceAccountName(cr *v1alpha1.Flux) string {
 	return fmt.Sprintf("flux-%s", cr.Name)
 }
@@ -24,7 +33,13 @@ func NewServiceAccount(cr *v1alpha1.Flux) *corev1.ServiceAccount {
 	}
 }
 
+// Create the Flux's cluster role, nil in namespaced mode since flux-operator can
+// not create cluster roles.
 func NewClusterRole(cr *v1alpha1.Flux) *rbacv1.ClusterRole {
+	if config.Namespaced() {
+		return nil
+	}
+
 	clusterRole := &rbacv1.ClusterRole{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "ClusterRole",
@@ -34,7 +49,7 @@ func NewClusterRole(cr *v1alpha1.Flux) *rbacv1.ClusterRole {
 	}
 	clusterRole.ObjectMeta.Namespace = ""
 
-	if cr.Spec.ClusterRole.Enabled == false || utils.BoolEnv("DISABLE_CLUSTER_ROLES") {
+	if cr.Spec.ClusterRole.Enabled == false || config.Get().DisableClusterRoles {
 		clusterRole.Rules = []rbacv1.PolicyRule{
 			rbacv1.PolicyRule{
 				APIGroups: []string{""},
@@ -61,7 +76,12 @@ func NewClusterRole(cr *v1alpha1.Flux) *rbacv1.ClusterRole {
 	return clusterRole
 }
 
+// Bind the Flux's cluster role to its service account, nil in namespaced mode.
 func NewClusterRoleBinding(cr *v1alpha1.Flux) *rbacv1.ClusterRoleBinding {
+	if config.Namespaced() {
+		return nil
+	}
+
 	serviceAccount := fmt.Sprintf("flux-%s", cr.Name)
 	meta := utils.NewObjectMeta(cr, fmt.Sprintf("flux-%s", cr.Name))
 	meta.Namespace = ""
@@ -87,8 +107,56 @@ func NewClusterRoleBinding(cr *v1alpha1.Flux) *rbacv1.ClusterRoleBinding {
 	}
 }
 
+// Return the namespaces that the Flux's role is created in: the Flux's own
+// namespace and its target namespaces.
+func RoleNamespaces(cr *v1alpha1.Flux) []string {
+	namespaces := []string{utils.FluxNamespace(cr)}
+
+	for _, namespace := range cr.Spec.TargetNamespaces {
+		found := false
+		for _, existing := range namespaces {
+			if existing == namespace {
+				found = true
+			}
+		}
+
+		if !found {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	return namespaces
+}
+
+// Return the name of the Flux's role in namespace, roles in target namespaces
+// include the Flux's namespace so that they are unique.
+func RoleName(cr *v1alpha1.Flux, namespace string) string {
+	if namespace == utils.FluxNamespace(cr) {
+		return fmt.Sprintf("flux-%s", cr.Name)
+	}
+
+	return fmt.Sprintf("flux-%s-%s", utils.FluxNamespace(cr), cr.Name)
+}
+
+// Return the metadata for a role or role binding in namespace. Owner references
+// can not cross namespaces, so objects in target namespaces are only labeled.
+func roleObjectMeta(cr *v1alpha1.Flux, namespace string) metav1.ObjectMeta {
+	meta := utils.NewObjectMeta(cr, RoleName(cr, namespace))
+	if namespace != utils.FluxNamespace(cr) {
+		meta.Namespace = namespace
+		meta.OwnerReferences = nil
+	}
+	return meta
+}
+
+// Create the Flux's role in its own namespace.
 func NewRole(cr *v1alpha1.Flux) *rbacv1.Role {
-	if cr.Spec.Role.Enabled == false || utils.BoolEnv("DISABLE_ROLES") {
+	return NewRoleInNamespace(cr, utils.FluxNamespace(cr))
+}
+
+// Create the Flux's role in namespace.
+func NewRoleInNamespace(cr *v1alpha1.Flux, namespace string) *rbacv1.Role {
+	if cr.Spec.Role.Enabled == false || config.Get().DisableRoles {
 		return nil
 	}
 
@@ -97,7 +165,7 @@ func NewRole(cr *v1alpha1.Flux) *rbacv1.Role {
 			Kind:       "Role",
 			APIVersion: "rbac.authorization.k8s.io/v1",
 		},
-		ObjectMeta: utils.NewObjectMeta(cr, ""),
+		ObjectMeta: roleObjectMeta(cr, namespace),
 	}
 
 	if len(cr.Spec.Role.Rules) > 0 {
@@ -115,12 +183,18 @@ func NewRole(cr *v1alpha1.Flux) *rbacv1.Role {
 	return role
 }
 
+// Bind the Flux's role in its own namespace to its service account.
 func NewRoleBinding(cr *v1alpha1.Flux) *rbacv1.RoleBinding {
-	if cr.Spec.Role.Enabled == false || utils.BoolEnv("DISABLE_ROLES") {
+	return NewRoleBindingInNamespace(cr, utils.FluxNamespace(cr))
+}
+
+// Bind the Flux's role in namespace to its service account.
+func NewRoleBindingInNamespace(cr *v1alpha1.Flux, namespace string) *rbacv1.RoleBinding {
+	if cr.Spec.Role.Enabled == false || config.Get().DisableRoles {
 		return nil
 	}
 
-	meta := utils.NewObjectMeta(cr, "")
+	meta := roleObjectMeta(cr, namespace)
 
 	return &rbacv1.RoleBinding{
 		TypeMeta: metav1.TypeMeta{
@@ -143,17 +217,156 @@ func NewRoleBinding(cr *v1alpha1.Flux) *rbacv1.RoleBinding {
 	}
 }
 
+// Return the kind of a role reference, `ClusterRole` if unset.
+func RoleRefKind(ref v1alpha1.FluxRoleRef) string {
+	if ref.Kind == "" {
+		return "ClusterRole"
+	}
+	return ref.Kind
+}
+
+// Return the namespace that a role reference is bound in, empty if it is bound
+// cluster wide. A Role is bound in the Flux's namespace unless one is set.
+func RoleRefNamespace(cr *v1alpha1.Flux, ref v1alpha1.FluxRoleRef) string {
+	if ref.Namespace == "" && RoleRefKind(ref) == "Role" {
+		return utils.FluxNamespace(cr)
+	}
+	return ref.Namespace
+}
+
+// Return the name of the binding for a role reference, which includes the Flux's
+// namespace and the kind of role so that it is unique.
+func RoleRefBindingName(cr *v1alpha1.Flux, ref v1alpha1.FluxRoleRef) string {
+	return fmt.Sprintf("flux-%s-%s-%s-%s", utils.FluxNamespace(cr), cr.Name,
+		strings.ToLower(RoleRefKind(ref)), ref.Name)
+}
+
+// Return an error if a role reference can not be bound.
+func ValidateRoleRef(ref v1alpha1.FluxRoleRef) error {
+	kind := RoleRefKind(ref)
+	if kind != "Role" && kind != "ClusterRole" {
+		return fmt.Errorf("role reference %s has invalid kind %s, must be Role or ClusterRole", ref.Name, kind)
+	}
+
+	if ref.Name == "" {
+		return fmt.Errorf("role reference of kind %s has no name", kind)
+	}
+
+	return nil
+}
+
+// Bind a referenced role to the Flux's service account, with a ClusterRoleBinding
+// if it is bound cluster wide and a RoleBinding otherwise. Returns nil if the
+// reference is invalid or the kind of binding is disabled. As with roles, owner
+// references can not cross namespaces, so bindings in other namespaces are only
+// labeled.
+func NewRoleRefBinding(cr *v1alpha1.Flux, ref v1alpha1.FluxRoleRef) runtime.Object {
+	if ValidateRoleRef(ref) != nil {
+		return nil
+	}
+
+	namespace := RoleRefNamespace(cr, ref)
+	meta := utils.NewObjectMeta(cr, RoleRefBindingName(cr, ref))
+	meta.Namespace = namespace
+	if namespace != "" && namespace != utils.FluxNamespace(cr) {
+		meta.OwnerReferences = nil
+	}
+
+	subjects := []rbacv1.Subject{
+		rbacv1.Subject{
+			Kind:      "ServiceAccount",
+			Name:      ServiceAccountName(cr),
+			Namespace: utils.FluxNamespace(cr),
+		},
+	}
+
+	roleRef := rbacv1.RoleRef{
+		APIGroup: "rbac.authorization.k8s.io",
+		Kind:     RoleRefKind(ref),
+		Name:     ref.Name,
+	}
+
+	if namespace == "" {
+		if config.Get().DisableClusterRoles {
+			return nil
+		}
+
+		return &rbacv1.ClusterRoleBinding{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "ClusterRoleBinding",
+				APIVersion: "rbac.authorization.k8s.io/v1",
+			},
+			ObjectMeta: meta,
+			Subjects:   subjects,
+			RoleRef:    roleRef,
+		}
+	}
+
+	if config.Get().DisableRoles {
+		return nil
+	}
+
+	return &rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "RoleBinding",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: meta,
+		Subjects:   subjects,
+		RoleRef:    roleRef,
+	}
+}
+
+// Return the reasons that the Flux can not be reconciled in namespaced mode, in
+// which flux-operator only has roles in the namespaces that it watches.
+func NamespacedErrors(cr *v1alpha1.Flux) []string {
+	errors := []string{}
+	if !config.Namespaced() {
+		return errors
+	}
+
+	if cr.Spec.ClusterRole.Enabled {
+		errors = append(errors, "clusterRole can not be enabled when flux-operator is namespaced")
+	}
+
+	for _, namespace := range RoleNamespaces(cr) {
+		if !config.Watches(namespace) {
+			errors = append(errors, fmt.Sprintf("namespace %s is not watched by flux-operator", namespace))
+		}
+	}
+
+	for _, ref := range cr.Spec.RoleRefs {
+		namespace := RoleRefNamespace(cr, ref)
+		if namespace == "" {
+			errors = append(errors, fmt.Sprintf("cluster role %s can not be bound in every namespace when flux-operator is namespaced", ref.Name))
+		} else if !config.Watches(namespace) {
+			errors = append(errors, fmt.Sprintf("role %s can not be bound in namespace %s, which is not watched by flux-operator", ref.Name, namespace))
+		}
+	}
+
+	return errors
+}
+
 func FluxRoles(cr *v1alpha1.Flux) (objects []runtime.Object) {
 	objects = append(objects, NewServiceAccount(cr))
 
-	role := NewRole(cr)
-	if role != nil {
-		objects = append(objects, role)
+	for _, namespace := range RoleNamespaces(cr) {
+		role := NewRoleInNamespace(cr, namespace)
+		if role != nil {
+			objects = append(objects, role)
+		}
+
+		roleBinding := NewRoleBindingInNamespace(cr, namespace)
+		if roleBinding != nil {
+			objects = append(objects, roleBinding)
+		}
 	}
 
-	roleBinding := NewRoleBinding(cr)
-	if roleBinding != nil {
-		objects = append(objects, roleBinding)
+	for _, ref := range cr.Spec.RoleRefs {
+		binding := NewRoleRefBinding(cr, ref)
+		if binding != nil {
+			objects = append(objects, binding)
+		}
 	}
 
 	clusterRole := NewClusterRole(cr)
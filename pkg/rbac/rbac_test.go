@@ -1,6 +1,7 @@
 package rbac
 
 import (
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
 	"github.com/justinbarrick/flux-operator/pkg/utils/test"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
@@ -242,3 +243,165 @@ func TestFluxRolesWithBoth(t *testing.T) {
 	_ = objects[3].(*rbacv1.ClusterRole)
 	_ = objects[4].(*rbacv1.ClusterRoleBinding)
 }
+
+func TestRoleNamespaces(t *testing.T) {
+	cr := test_utils.NewFlux()
+	assert.Equal(t, []string{"default"}, RoleNamespaces(cr))
+
+	cr.Spec.TargetNamespaces = []string{"team-a", "default", "team-b", "team-a"}
+	assert.Equal(t, []string{"default", "team-a", "team-b"}, RoleNamespaces(cr))
+}
+
+func TestRoleName(t *testing.T) {
+	cr := test_utils.NewFlux()
+	assert.Equal(t, "flux-example", RoleName(cr, "default"))
+	assert.Equal(t, "flux-default-example", RoleName(cr, "team-a"))
+}
+
+func TestNewRoleInNamespace(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.Role.Enabled = true
+
+	role := NewRoleInNamespace(cr, "team-a")
+	assert.Equal(t, "flux-default-example", role.ObjectMeta.Name)
+	assert.Equal(t, "team-a", role.ObjectMeta.Namespace)
+	assert.Nil(t, role.ObjectMeta.OwnerReferences)
+
+	roleBinding := NewRoleBindingInNamespace(cr, "team-a")
+	assert.Equal(t, "flux-default-example", roleBinding.ObjectMeta.Name)
+	assert.Equal(t, "team-a", roleBinding.ObjectMeta.Namespace)
+	assert.Equal(t, "flux-default-example", roleBinding.RoleRef.Name)
+	assert.Equal(t, "default", roleBinding.Subjects[0].Namespace)
+	assert.Nil(t, roleBinding.ObjectMeta.OwnerReferences)
+
+	assert.NotNil(t, NewRole(cr).ObjectMeta.OwnerReferences)
+}
+
+func TestFluxRolesWithTargetNamespaces(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.Role.Enabled = true
+	cr.Spec.TargetNamespaces = []string{"team-a"}
+	objects := FluxRoles(cr)
+	assert.Equal(t, len(objects), 7)
+	_ = objects[0].(*corev1.ServiceAccount)
+	assert.Equal(t, "default", objects[1].(*rbacv1.Role).Namespace)
+	assert.Equal(t, "default", objects[2].(*rbacv1.RoleBinding).Namespace)
+	assert.Equal(t, "team-a", objects[3].(*rbacv1.Role).Namespace)
+	assert.Equal(t, "team-a", objects[4].(*rbacv1.RoleBinding).Namespace)
+	_ = objects[5].(*rbacv1.ClusterRole)
+	_ = objects[6].(*rbacv1.ClusterRoleBinding)
+}
+
+func TestRoleRefDefaults(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	view := v1alpha1.FluxRoleRef{Name: "view"}
+	assert.Equal(t, "ClusterRole", RoleRefKind(view))
+	assert.Equal(t, "", RoleRefNamespace(cr, view))
+	assert.Equal(t, "flux-default-example-clusterrole-view", RoleRefBindingName(cr, view))
+
+	deployer := v1alpha1.FluxRoleRef{Kind: "Role", Name: "deployer"}
+	assert.Equal(t, "default", RoleRefNamespace(cr, deployer))
+	assert.Equal(t, "flux-default-example-role-deployer", RoleRefBindingName(cr, deployer))
+}
+
+func TestValidateRoleRef(t *testing.T) {
+	assert.Nil(t, ValidateRoleRef(v1alpha1.FluxRoleRef{Kind: "Role", Name: "deployer"}))
+	assert.NotNil(t, ValidateRoleRef(v1alpha1.FluxRoleRef{Kind: "Group", Name: "deployer"}))
+	assert.NotNil(t, ValidateRoleRef(v1alpha1.FluxRoleRef{}))
+}
+
+func TestNewRoleRefBinding(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	clusterRoleBinding := NewRoleRefBinding(cr, v1alpha1.FluxRoleRef{Name: "view"}).(*rbacv1.ClusterRoleBinding)
+	assert.Equal(t, "flux-default-example-clusterrole-view", clusterRoleBinding.ObjectMeta.Name)
+	assert.Equal(t, "", clusterRoleBinding.ObjectMeta.Namespace)
+	assert.NotNil(t, clusterRoleBinding.ObjectMeta.OwnerReferences)
+	assert.Equal(t, rbacv1.RoleRef{
+		APIGroup: "rbac.authorization.k8s.io",
+		Kind:     "ClusterRole",
+		Name:     "view",
+	}, clusterRoleBinding.RoleRef)
+	assert.Equal(t, "flux-example", clusterRoleBinding.Subjects[0].Name)
+	assert.Equal(t, "default", clusterRoleBinding.Subjects[0].Namespace)
+
+	roleBinding := NewRoleRefBinding(cr, v1alpha1.FluxRoleRef{Name: "edit", Namespace: "team-a"}).(*rbacv1.RoleBinding)
+	assert.Equal(t, "team-a", roleBinding.ObjectMeta.Namespace)
+	assert.Nil(t, roleBinding.ObjectMeta.OwnerReferences)
+	assert.Equal(t, "ClusterRole", roleBinding.RoleRef.Kind)
+	assert.Equal(t, "edit", roleBinding.RoleRef.Name)
+
+	roleBinding = NewRoleRefBinding(cr, v1alpha1.FluxRoleRef{Kind: "Role", Name: "deployer"}).(*rbacv1.RoleBinding)
+	assert.Equal(t, "default", roleBinding.ObjectMeta.Namespace)
+	assert.NotNil(t, roleBinding.ObjectMeta.OwnerReferences)
+	assert.Equal(t, "Role", roleBinding.RoleRef.Kind)
+
+	assert.Nil(t, NewRoleRefBinding(cr, v1alpha1.FluxRoleRef{Kind: "Group", Name: "deployer"}))
+}
+
+func TestNewRoleRefBindingDisabledByEnvironmentVariable(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	os.Setenv("DISABLE_CLUSTER_ROLES", "true")
+	assert.Nil(t, NewRoleRefBinding(cr, v1alpha1.FluxRoleRef{Name: "view"}))
+	assert.NotNil(t, NewRoleRefBinding(cr, v1alpha1.FluxRoleRef{Name: "view", Namespace: "default"}))
+	os.Setenv("DISABLE_CLUSTER_ROLES", "")
+
+	os.Setenv("DISABLE_ROLES", "true")
+	assert.NotNil(t, NewRoleRefBinding(cr, v1alpha1.FluxRoleRef{Name: "view"}))
+	assert.Nil(t, NewRoleRefBinding(cr, v1alpha1.FluxRoleRef{Name: "view", Namespace: "default"}))
+	os.Setenv("DISABLE_ROLES", "")
+}
+
+func TestFluxRolesWithRoleRefs(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.RoleRefs = []v1alpha1.FluxRoleRef{
+		v1alpha1.FluxRoleRef{Name: "view"},
+		v1alpha1.FluxRoleRef{Kind: "Role", Name: "deployer"},
+	}
+	objects := FluxRoles(cr)
+	assert.Equal(t, len(objects), 5)
+	_ = objects[0].(*corev1.ServiceAccount)
+	assert.Equal(t, "view", objects[1].(*rbacv1.ClusterRoleBinding).RoleRef.Name)
+	assert.Equal(t, "deployer", objects[2].(*rbacv1.RoleBinding).RoleRef.Name)
+	_ = objects[3].(*rbacv1.ClusterRole)
+	_ = objects[4].(*rbacv1.ClusterRoleBinding)
+}
+
+func TestFluxRolesNamespaced(t *testing.T) {
+	os.Setenv("NAMESPACED", "true")
+	defer os.Setenv("NAMESPACED", "")
+
+	cr := test_utils.NewFlux()
+	cr.Spec.Role.Enabled = true
+	objects := FluxRoles(cr)
+	assert.Equal(t, len(objects), 3)
+	_ = objects[0].(*corev1.ServiceAccount)
+	_ = objects[1].(*rbacv1.Role)
+	_ = objects[2].(*rbacv1.RoleBinding)
+}
+
+func TestNamespacedErrors(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.ClusterRole.Enabled = true
+	cr.Spec.TargetNamespaces = []string{"team-a", "team-b"}
+	cr.Spec.RoleRefs = []v1alpha1.FluxRoleRef{
+		v1alpha1.FluxRoleRef{Name: "view"},
+		v1alpha1.FluxRoleRef{Name: "edit", Namespace: "team-a"},
+		v1alpha1.FluxRoleRef{Name: "edit", Namespace: "team-c"},
+	}
+	assert.Equal(t, []string{}, NamespacedErrors(cr))
+
+	os.Setenv("NAMESPACED", "true")
+	os.Setenv("FLUX_NAMESPACE", "default,team-a")
+	defer os.Setenv("NAMESPACED", "")
+	defer os.Setenv("FLUX_NAMESPACE", "")
+
+	assert.Equal(t, []string{
+		"clusterRole can not be enabled when flux-operator is namespaced",
+		"namespace team-b is not watched by flux-operator",
+		"cluster role view can not be bound in every namespace when flux-operator is namespaced",
+		"role edit can not be bound in namespace team-c, which is not watched by flux-operator",
+	}, NamespacedErrors(cr))
+}
@@ -0,0 +1,102 @@
+package reconcile
+
+import (
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/events"
+	"github.com/justinbarrick/flux-operator/pkg/kinds"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Writes the objects of a Flux to the API.
+type Client interface {
+	Create(obj runtime.Object) error
+	Update(obj runtime.Object) error
+	Delete(obj runtime.Object) error
+}
+
+// Create or update desiredObjs based on the current state in existingObjs, in
+// the order of their kinds so that objects are created after the objects they
+// depend on. Objects that fail to apply do not stop the others from being
+// applied, but objects that reference a failed object (a Deployment's service
+// account, secrets and config maps or a binding's subjects and role) are
+// skipped. Their errors are returned together as utils.ObjectErrors.
+func Apply(client Client, cr *v1alpha1.Flux, existingObjs []runtime.Object, desiredObjs []runtime.Object) error {
+	errs := utils.ObjectErrors{}
+	failed := map[string]bool{}
+
+	for _, desired := range kinds.Sort(desiredObjs) {
+		name := utils.ReadableObjectName(cr, desired)
+
+		if dependency := failedDependency(desired, failed); dependency != "" {
+			err := fmt.Errorf("skipped because %s failed to apply", dependency)
+			logrus.Errorf("Skipped %s: %v", name, err)
+			events.Warningf(cr, events.ReasonDependencyFailed, "Skipped %s because %s failed to apply",
+				utils.ObjectName(desired), dependency)
+			errs = append(errs, utils.ObjectError{Action: "apply", Object: desired, Err: err})
+			failed[utils.ObjectName(desired)] = true
+			continue
+		}
+
+		existing := utils.GetObject(desired, existingObjs)
+		if existing == nil {
+			err := client.Create(desired)
+			if err != nil {
+				logrus.Errorf("Failed to create %s: %v", name, err)
+				events.Warningf(cr, events.ReasonCreateFailed, "Failed to create %s: %v",
+					utils.ObjectName(desired), err)
+				errs = append(errs, utils.ObjectError{Action: "create", Object: desired, Err: err})
+				failed[utils.ObjectName(desired)] = true
+				continue
+			}
+
+			logrus.Infof("Created %s", name)
+			events.Normalf(cr, events.ReasonCreated, "Created %s", utils.ObjectName(desired))
+			continue
+		}
+
+		if utils.GetObjectHash(existing) == utils.GetObjectHash(desired) {
+			continue
+		}
+
+		switch desired.(type) {
+		case *corev1.Service:
+			service := desired.(*corev1.Service)
+			service.Spec.ClusterIP = existing.(*corev1.Service).Spec.ClusterIP
+		}
+
+		existingMeta, _ := meta.Accessor(existing)
+		desiredMeta, _ := meta.Accessor(desired)
+		desiredMeta.SetResourceVersion(existingMeta.GetResourceVersion())
+
+		err := client.Update(desired)
+		if err != nil {
+			logrus.Errorf("Could not update %s: %v", name, err)
+			events.Warningf(cr, events.ReasonUpdateFailed, "Failed to update %s: %v",
+				utils.ObjectName(desired), err)
+			errs = append(errs, utils.ObjectError{Action: "update", Object: desired, Err: err})
+			failed[utils.ObjectName(desired)] = true
+			continue
+		}
+
+		logrus.Infof("Updated out of date %s != %s", name, utils.GetObjectHash(existing))
+		events.Normalf(cr, events.ReasonUpdated, "Updated out of date %s", utils.ObjectName(desired))
+	}
+
+	return errs.Err()
+}
+
+// Return the name of the first object that object depends on that is in
+// failed, an empty string if there is none.
+func failedDependency(object runtime.Object, failed map[string]bool) string {
+	for _, dependency := range dependencies(object) {
+		if failed[dependency] {
+			return dependency
+		}
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package reconcile
+
+import (
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/flux"
+	"github.com/justinbarrick/flux-operator/pkg/memcached"
+	"github.com/justinbarrick/flux-operator/pkg/rbac"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/justinbarrick/flux-operator/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"testing"
+)
+
+// A Client that records the calls made to it and fails those in failures.
+type fakeClient struct {
+	calls    []string
+	objects  []runtime.Object
+	failures map[string]error
+}
+
+func (f *fakeClient) call(action string, obj runtime.Object) error {
+	call := fmt.Sprintf("%s %s", action, utils.ObjectName(obj))
+	f.calls = append(f.calls, call)
+	f.objects = append(f.objects, obj)
+	return f.failures[call]
+}
+
+func (f *fakeClient) Create(obj runtime.Object) error {
+	return f.call("create", obj)
+}
+
+func (f *fakeClient) Update(obj runtime.Object) error {
+	return f.call("update", obj)
+}
+
+func (f *fakeClient) Delete(obj runtime.Object) error {
+	return f.call("delete", obj)
+}
+
+func TestApply(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	existing := memcached.NewMemcachedService(cr)
+	existing.ResourceVersion = "1234"
+	existing.Spec.ClusterIP = "10.0.0.1"
+	utils.SetObjectHash(existing)
+
+	service := memcached.NewMemcachedService(cr)
+	service.Spec.Ports[0].Port = 11212
+	utils.SetObjectHash(service)
+
+	deployment := memcached.NewMemcachedDeployment(cr)
+	utils.SetObjectHash(deployment)
+
+	client := &fakeClient{}
+	err := Apply(client, cr, []runtime.Object{existing}, []runtime.Object{deployment, service})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"update " + utils.ObjectName(service),
+		"create " + utils.ObjectName(deployment),
+	}, client.calls)
+
+	updated := client.objects[0].(*corev1.Service)
+	assert.Equal(t, "10.0.0.1", updated.Spec.ClusterIP)
+	assert.Equal(t, "1234", updated.ResourceVersion)
+}
+
+func TestApplyUnchanged(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	existing := memcached.NewMemcachedService(cr)
+	utils.SetObjectHash(existing)
+	desired := memcached.NewMemcachedService(cr)
+	utils.SetObjectHash(desired)
+
+	client := &fakeClient{}
+	assert.Nil(t, Apply(client, cr, []runtime.Object{existing}, []runtime.Object{desired}))
+	assert.Equal(t, 0, len(client.calls))
+}
+
+func TestApplyContinuesOnFailure(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	service := memcached.NewMemcachedService(cr)
+	deployment := memcached.NewMemcachedDeployment(cr)
+
+	client := &fakeClient{failures: map[string]error{
+		"create " + utils.ObjectName(service): fmt.Errorf("forbidden"),
+	}}
+
+	err := Apply(client, cr, nil, []runtime.Object{deployment, service})
+	assert.Equal(t, []string{
+		"create " + utils.ObjectName(service),
+		"create " + utils.ObjectName(deployment),
+	}, client.calls)
+
+	errs, ok := err.(utils.ObjectErrors)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, "create", errs[0].Action)
+	assert.Equal(t, service, errs[0].Object)
+}
+
+func TestApplySkipsDependentsOfFailures(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.Role.Enabled = true
+
+	serviceAccount := rbac.NewServiceAccount(cr)
+	role := rbac.NewRole(cr)
+	roleBinding := rbac.NewRoleBinding(cr)
+	sshKey := flux.NewFluxSSHKey(cr)
+	deployment := flux.NewFluxDeployment(cr)
+	memcachedDeployment := memcached.NewMemcachedDeployment(cr)
+
+	client := &fakeClient{failures: map[string]error{
+		"create " + utils.ObjectName(serviceAccount): fmt.Errorf("forbidden"),
+	}}
+
+	err := Apply(client, cr, nil, []runtime.Object{deployment, memcachedDeployment, sshKey, roleBinding, role, serviceAccount})
+	assert.Equal(t, []string{
+		"create " + utils.ObjectName(serviceAccount),
+		"create " + utils.ObjectName(role),
+		"create " + utils.ObjectName(sshKey),
+		"create " + utils.ObjectName(memcachedDeployment),
+	}, client.calls)
+
+	errs, ok := err.(utils.ObjectErrors)
+	assert.True(t, ok)
+	assert.Equal(t, 3, len(errs))
+	assert.Equal(t, serviceAccount, errs[0].Object)
+	assert.Equal(t, "apply", errs[1].Action)
+	assert.Equal(t, roleBinding, errs[1].Object)
+	assert.Equal(t, "apply", errs[2].Action)
+	assert.Equal(t, deployment, errs[2].Object)
+}
+
+func TestDependencies(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.Role.Enabled = true
+
+	deployment := flux.NewFluxDeployment(cr)
+	deps := dependencies(deployment)
+	assert.Contains(t, deps, utils.ObjectName(rbac.NewServiceAccount(cr)))
+	assert.Contains(t, deps, utils.ObjectName(flux.NewFluxSSHKey(cr)))
+
+	roleBinding := rbac.NewRoleBinding(cr)
+	assert.Equal(t, []string{
+		utils.ObjectName(rbac.NewServiceAccount(cr)),
+		utils.ObjectName(rbac.NewRole(cr)),
+	}, dependencies(roleBinding))
+
+	assert.Nil(t, dependencies(memcached.NewMemcachedService(cr)))
+}
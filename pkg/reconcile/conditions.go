@@ -0,0 +1,72 @@
+package reconcile
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition messages are truncated to this many bytes.
+const maxMessage = 1024
+
+// The reasons of the Applied and GarbageCollected conditions.
+const (
+	ReasonApplied          = "Applied"
+	ReasonApplyFailed      = "ApplyFailed"
+	ReasonGarbageCollected = "GarbageCollected"
+	ReasonDeleteFailed     = "DeleteFailed"
+	// Garbage collection did not run because some objects failed to apply.
+	ReasonSkipped = "Skipped"
+)
+
+// Return conditions with the condition of type conditionType set to status,
+// reason and message. LastTransitionTime is only changed when the status does.
+func SetCondition(conditions []v1alpha1.FluxCondition, conditionType string, status corev1.ConditionStatus, reason, message string) []v1alpha1.FluxCondition {
+	condition := v1alpha1.FluxCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            utils.Truncate(message, maxMessage),
+		LastTransitionTime: metav1.Now(),
+	}
+
+	updated := []v1alpha1.FluxCondition{}
+	for _, existing := range conditions {
+		if existing.Type != conditionType {
+			updated = append(updated, existing)
+			continue
+		}
+
+		if existing.Status == status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+	}
+
+	return append(updated, condition)
+}
+
+// Return the status, reason and message of a condition from the error of the
+// step it describes.
+func conditionFromError(err error, success, failure string) (corev1.ConditionStatus, string, string) {
+	if err != nil {
+		return corev1.ConditionFalse, failure, err.Error()
+	}
+	return corev1.ConditionTrue, success, ""
+}
+
+// Return conditions updated with the outcome of applying the objects of a Flux
+// and garbage collecting them. Garbage collection is skipped if applyErr is set,
+// which leaves it Unknown.
+func Conditions(conditions []v1alpha1.FluxCondition, applyErr, gcErr error) []v1alpha1.FluxCondition {
+	applied, reason, message := conditionFromError(applyErr, ReasonApplied, ReasonApplyFailed)
+	conditions = SetCondition(conditions, v1alpha1.FluxApplied, applied, reason, message)
+
+	if applyErr != nil {
+		return SetCondition(conditions, v1alpha1.FluxGarbageCollected, corev1.ConditionUnknown, ReasonSkipped,
+			"garbage collection is skipped until every object is applied")
+	}
+
+	collected, reason, message := conditionFromError(gcErr, ReasonGarbageCollected, ReasonDeleteFailed)
+	return SetCondition(conditions, v1alpha1.FluxGarbageCollected, collected, reason, message)
+}
@@ -0,0 +1,65 @@
+package reconcile
+
+import (
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Return the condition of type conditionType, nil if there is none.
+func findCondition(conditions []v1alpha1.FluxCondition, conditionType string) *v1alpha1.FluxCondition {
+	for index := range conditions {
+		if conditions[index].Type == conditionType {
+			return &conditions[index]
+		}
+	}
+	return nil
+}
+
+func TestSetCondition(t *testing.T) {
+	then := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	conditions := []v1alpha1.FluxCondition{
+		{Type: "Other", Status: corev1.ConditionTrue, LastTransitionTime: then},
+		{Type: v1alpha1.FluxApplied, Status: corev1.ConditionTrue, Reason: ReasonApplied, LastTransitionTime: then},
+	}
+
+	unchanged := SetCondition(conditions, v1alpha1.FluxApplied, corev1.ConditionTrue, ReasonApplied, "")
+	assert.Equal(t, 2, len(unchanged))
+	assert.Equal(t, then, findCondition(unchanged, v1alpha1.FluxApplied).LastTransitionTime)
+	assert.Equal(t, then, findCondition(unchanged, "Other").LastTransitionTime)
+
+	changed := SetCondition(conditions, v1alpha1.FluxApplied, corev1.ConditionFalse, ReasonApplyFailed, strings.Repeat("x", 2000))
+	condition := findCondition(changed, v1alpha1.FluxApplied)
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, ReasonApplyFailed, condition.Reason)
+	assert.Equal(t, maxMessage, len(condition.Message))
+	assert.True(t, condition.LastTransitionTime.After(then.Time))
+}
+
+func TestConditions(t *testing.T) {
+	conditions := Conditions(nil, nil, nil)
+	assert.Equal(t, corev1.ConditionTrue, findCondition(conditions, v1alpha1.FluxApplied).Status)
+	assert.Equal(t, ReasonGarbageCollected, findCondition(conditions, v1alpha1.FluxGarbageCollected).Reason)
+
+	conditions = Conditions(conditions, nil, fmt.Errorf("failed to delete"))
+	applied := findCondition(conditions, v1alpha1.FluxApplied)
+	assert.Equal(t, corev1.ConditionTrue, applied.Status)
+	collected := findCondition(conditions, v1alpha1.FluxGarbageCollected)
+	assert.Equal(t, corev1.ConditionFalse, collected.Status)
+	assert.Equal(t, ReasonDeleteFailed, collected.Reason)
+	assert.Equal(t, "failed to delete", collected.Message)
+
+	conditions = Conditions(conditions, fmt.Errorf("failed to create"), nil)
+	applied = findCondition(conditions, v1alpha1.FluxApplied)
+	assert.Equal(t, corev1.ConditionFalse, applied.Status)
+	assert.Equal(t, ReasonApplyFailed, applied.Reason)
+	assert.Equal(t, "failed to create", applied.Message)
+	collected = findCondition(conditions, v1alpha1.FluxGarbageCollected)
+	assert.Equal(t, corev1.ConditionUnknown, collected.Status)
+	assert.Equal(t, ReasonSkipped, collected.Reason)
+}
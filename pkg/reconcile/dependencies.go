@@ -0,0 +1,89 @@
+package reconcile
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Return the names of the objects that object references and can not work
+// without, in the format of utils.ObjectName.
+func dependencies(object runtime.Object) []string {
+	switch typed := object.(type) {
+	case *extensions.Deployment:
+		return podDependencies(typed.Namespace, typed.Spec.Template.Spec)
+	case *rbacv1.RoleBinding:
+		deps := subjectDependencies(typed.Subjects)
+		if typed.RoleRef.Kind == "ClusterRole" {
+			return append(deps, objectName("", "ClusterRole", typed.RoleRef.Name))
+		}
+		return append(deps, objectName(typed.Namespace, typed.RoleRef.Kind, typed.RoleRef.Name))
+	case *rbacv1.ClusterRoleBinding:
+		deps := subjectDependencies(typed.Subjects)
+		return append(deps, objectName("", typed.RoleRef.Kind, typed.RoleRef.Name))
+	}
+
+	return nil
+}
+
+// Return the service account, secrets and config maps a pod uses.
+func podDependencies(namespace string, spec corev1.PodSpec) []string {
+	deps := []string{}
+
+	if spec.ServiceAccountName != "" {
+		deps = append(deps, objectName(namespace, "ServiceAccount", spec.ServiceAccountName))
+	}
+
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil {
+			deps = append(deps, objectName(namespace, "Secret", volume.Secret.SecretName))
+		}
+		if volume.ConfigMap != nil {
+			deps = append(deps, objectName(namespace, "ConfigMap", volume.ConfigMap.Name))
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				deps = append(deps, objectName(namespace, "Secret", env.ValueFrom.SecretKeyRef.Name))
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				deps = append(deps, objectName(namespace, "ConfigMap", env.ValueFrom.ConfigMapKeyRef.Name))
+			}
+		}
+
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				deps = append(deps, objectName(namespace, "Secret", envFrom.SecretRef.Name))
+			}
+			if envFrom.ConfigMapRef != nil {
+				deps = append(deps, objectName(namespace, "ConfigMap", envFrom.ConfigMapRef.Name))
+			}
+		}
+	}
+
+	return deps
+}
+
+// Return the service accounts that are bound to a role.
+func subjectDependencies(subjects []rbacv1.Subject) []string {
+	deps := []string{}
+	for _, subject := range subjects {
+		if subject.Kind == "ServiceAccount" {
+			deps = append(deps, objectName(subject.Namespace, subject.Kind, subject.Name))
+		}
+	}
+	return deps
+}
+
+// Return the name of an object in the format of utils.ObjectName.
+func objectName(namespace, kind, name string) string {
+	return fmt.Sprintf("%s:%s/%s", namespace, kind, name)
+}
@@ -0,0 +1,39 @@
+package reconcile
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/events"
+	"github.com/justinbarrick/flux-operator/pkg/kinds"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Delete the objects in existingObjs that are not in desiredObjs, in the reverse
+// of the order they are created in. Objects that fail to delete do not stop the
+// others from being deleted, their errors are returned together as
+// utils.ObjectErrors.
+func GarbageCollect(client Client, cr *v1alpha1.Flux, existingObjs []runtime.Object, desiredObjs []runtime.Object) error {
+	errs := utils.ObjectErrors{}
+
+	for _, existing := range kinds.SortForDeletion(existingObjs) {
+		desired := utils.GetObject(existing, desiredObjs)
+		if desired != nil {
+			continue
+		}
+
+		logrus.Infof("Deleting unwanted resource from %s", utils.ReadableObjectName(cr, existing))
+		err := client.Delete(existing)
+		if err != nil {
+			logrus.Errorf("Failed to delete %s: %v", utils.ReadableObjectName(cr, existing), err)
+			events.Warningf(cr, events.ReasonDeleteFailed, "Failed to delete unwanted %s: %v",
+				utils.ObjectName(existing), err)
+			errs = append(errs, utils.ObjectError{Action: "delete", Object: existing, Err: err})
+			continue
+		}
+
+		events.Normalf(cr, events.ReasonDeleted, "Deleted unwanted %s", utils.ObjectName(existing))
+	}
+
+	return errs.Err()
+}
@@ -0,0 +1,46 @@
+package reconcile
+
+import (
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/memcached"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/justinbarrick/flux-operator/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"testing"
+)
+
+func TestGarbageCollect(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	service := memcached.NewMemcachedService(cr)
+	deployment := memcached.NewMemcachedDeployment(cr)
+
+	client := &fakeClient{}
+	err := GarbageCollect(client, cr, []runtime.Object{service, deployment}, []runtime.Object{service})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"delete " + utils.ObjectName(deployment)}, client.calls)
+}
+
+func TestGarbageCollectContinuesOnFailure(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	service := memcached.NewMemcachedService(cr)
+	deployment := memcached.NewMemcachedDeployment(cr)
+
+	client := &fakeClient{failures: map[string]error{
+		"delete " + utils.ObjectName(deployment): fmt.Errorf("forbidden"),
+	}}
+
+	err := GarbageCollect(client, cr, []runtime.Object{service, deployment}, nil)
+	assert.Equal(t, []string{
+		"delete " + utils.ObjectName(deployment),
+		"delete " + utils.ObjectName(service),
+	}, client.calls)
+
+	errs, ok := err.(utils.ObjectErrors)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, "delete", errs[0].Action)
+	assert.Equal(t, deployment, errs[0].Object)
+}
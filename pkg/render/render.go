@@ -0,0 +1,111 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/desired"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"io"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"reflect"
+)
+
+// Read every Flux from a stream of YAML or JSON documents, empty documents are
+// skipped and any other kind is an error.
+func ParseFluxes(reader io.Reader) ([]*v1alpha1.Flux, error) {
+	decoder := k8syaml.NewYAMLOrJSONDecoder(reader, 4096)
+	fluxes := []*v1alpha1.Flux{}
+
+	for document := 1; ; document++ {
+		raw := map[string]interface{}{}
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("document %d: %v", document, err)
+		}
+
+		if len(raw) == 0 {
+			continue
+		}
+
+		if raw["kind"] != "Flux" {
+			return nil, fmt.Errorf("document %d: expected a Flux, got %v", document, raw["kind"])
+		}
+
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %v", document, err)
+		}
+
+		cr := &v1alpha1.Flux{}
+		if err := json.Unmarshal(encoded, cr); err != nil {
+			return nil, fmt.Errorf("document %d: %v", document, err)
+		}
+
+		fluxes = append(fluxes, cr)
+	}
+
+	return fluxes, nil
+}
+
+// Return every object the operator would create for the Fluxes with the
+// current operator config, in the order it creates them. The cluster is not
+// consulted, so the SSH key secret is always included and objects in target
+// namespaces are included whether the namespaces exist or not. ConfigMaps and
+// Secrets that the operator does not manage are left out of the checksums of
+// the pod templates. The hash annotations are left out so that the output only
+// changes with the objects.
+func Objects(fluxes []*v1alpha1.Flux) ([]runtime.Object, error) {
+	objects := []runtime.Object{}
+
+	for _, cr := range fluxes {
+		fluxObjects, err := desired.FluxObjects(cr, true, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", cr.Name, err)
+		}
+
+		for _, object := range fluxObjects {
+			if reflect.ValueOf(object).IsNil() {
+				continue
+			}
+
+			utils.ClearObjectHash(object)
+			objects = append(objects, object)
+		}
+	}
+
+	return objects, nil
+}
+
+// Write the objects as YAML documents separated by `---`, skipping nils.
+func Write(writer io.Writer, objects []runtime.Object) error {
+	written := 0
+	for _, object := range objects {
+		if reflect.ValueOf(object).IsNil() {
+			continue
+		}
+
+		if written > 0 {
+			if _, err := fmt.Fprintln(writer, "---"); err != nil {
+				return err
+			}
+		}
+
+		encoded, err := yaml.Marshal(object)
+		if err != nil {
+			return err
+		}
+
+		if _, err := writer.Write(encoded); err != nil {
+			return err
+		}
+
+		written++
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Update the golden files in testdata.")
+
+func TestGolden(t *testing.T) {
+	defaults := config.Defaults()
+	config.Set(&defaults)
+	defer config.Set(nil)
+
+	inputs, err := filepath.Glob("testdata/*.yaml")
+	assert.Nil(t, err)
+
+	for _, input := range inputs {
+		if strings.HasSuffix(input, ".golden.yaml") {
+			continue
+		}
+
+		reader, err := os.Open(input)
+		assert.Nil(t, err)
+
+		fluxes, err := ParseFluxes(reader)
+		reader.Close()
+		assert.Nil(t, err)
+
+		objects, err := Objects(fluxes)
+		assert.Nil(t, err)
+
+		output := &bytes.Buffer{}
+		assert.Nil(t, Write(output, objects))
+
+		golden := strings.TrimSuffix(input, ".yaml") + ".golden.yaml"
+		if *update {
+			assert.Nil(t, ioutil.WriteFile(golden, output.Bytes(), 0644))
+		}
+
+		expected, err := ioutil.ReadFile(golden)
+		assert.Nil(t, err)
+		assert.Equal(t, string(expected), output.String(), input)
+	}
+}
+
+func TestParseFluxesSkipsEmptyDocuments(t *testing.T) {
+	fluxes, err := ParseFluxes(strings.NewReader("---\n---\nkind: Flux\nmetadata:\n  name: a\n---\n{\"kind\": \"Flux\", \"metadata\": {\"name\": \"b\"}}\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(fluxes))
+	assert.Equal(t, "a", fluxes[0].Name)
+	assert.Equal(t, "b", fluxes[1].Name)
+}
+
+func TestParseFluxesRejectsOtherKinds(t *testing.T) {
+	_, err := ParseFluxes(strings.NewReader("kind: Flux\n---\nkind: Deployment\n"))
+	assert.Equal(t, "document 2: expected a Flux, got Deployment", err.Error())
+}
@@ -0,0 +1,118 @@
+package stub
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Return true if configMap is the operator config ConfigMap.
+func IsOperatorConfig(configMap *corev1.ConfigMap) bool {
+	return config.ConfigMapName() != "" && configMap.Name == config.ConfigMapName() &&
+		configMap.Namespace == config.ConfigMapNamespace()
+}
+
+// Fetch the operator config ConfigMap and load it without reconciling, falling
+// back to the environment if it does not exist.
+func LoadConfig() error {
+	if config.ConfigMapName() == "" {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.ConfigMapName(),
+			Namespace: config.ConfigMapNamespace(),
+		},
+	}
+
+	err := sdk.Get(configMap)
+	if errors.IsNotFound(err) {
+		_, err = applyConfig(configMap, true)
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	_, err = applyConfig(configMap, false)
+	return err
+}
+
+// Load the operator config from configMap, returning true if it changed. An
+// invalid config is rejected and the current config is kept.
+func applyConfig(configMap *corev1.ConfigMap, deleted bool) (bool, error) {
+	data := configMap.Data
+	if deleted {
+		data = nil
+	}
+
+	newConfig, err := config.Load(data)
+	if err != nil {
+		logrus.Errorf("Not loading operator config %s/%s: %v", configMap.Namespace, configMap.Name, err)
+		return false, err
+	}
+
+	if newConfig == config.Get() {
+		return false, nil
+	}
+
+	logrus.Infof("Loaded operator config from %s/%s", configMap.Namespace, configMap.Name)
+	config.Set(&newConfig)
+	return true, nil
+}
+
+// Load the operator config from configMap and, if it changed, reconcile every
+// Flux with the new config. Fluxes whose resources are unaffected by the change
+// are left untouched since their desired state hashes are unchanged.
+func ReloadConfig(configMap *corev1.ConfigMap, deleted bool) error {
+	changed, err := applyConfig(configMap, deleted)
+	if err != nil || !changed {
+		return err
+	}
+
+	return SynchronizeAllFluxes()
+}
+
+// Reconcile every Flux watched by the operator, waiting for any reconcile of a
+// Flux that the Flux informer is already running.
+func SynchronizeAllFluxes() error {
+	namespaces := config.WatchNamespaces()
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var lastErr error
+	for _, namespace := range namespaces {
+		fluxes := &v1alpha1.FluxList{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Flux",
+				APIVersion: "flux.codesink.net/v1alpha1",
+			},
+		}
+
+		err := sdk.List(namespace, fluxes)
+		if err != nil {
+			logrus.Errorf("Failed to list Fluxes: %v", err)
+			lastErr = err
+			continue
+		}
+
+		for index := range fluxes.Items {
+			err = ReconcileFlux(&fluxes.Items[index])
+			if err != nil {
+				lastErr = err
+			}
+		}
+	}
+
+	return lastErr
+}
@@ -2,54 +2,36 @@ package stub
 
 import (
 	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
-	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/justinbarrick/flux-operator/pkg/reconcile"
 
 	"github.com/operator-framework/operator-sdk/pkg/sdk"
-	"github.com/sirupsen/logrus"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
-// Create or update desiredObjs based on the current state in existingObjs
-func CreateOrUpdate(cr *v1alpha1.Flux, existingObjs []runtime.Object, desiredObjs []runtime.Object) error {
-	for _, desired := range desiredObjs {
-		name := utils.ReadableObjectName(cr, desired)
-
-		existing := utils.GetObject(desired, existingObjs)
-		if existing == nil {
-			err := sdk.Create(desired)
-			if err != nil {
-				logrus.Errorf("Failed to create %s", name)
-				return err
-			}
-
-			logrus.Infof("Created %s", name)
-			continue
-		}
-
-		if utils.GetObjectHash(existing) == utils.GetObjectHash(desired) {
-			continue
-		}
-
-		switch desired.(type) {
-		case *corev1.Service:
-			service := desired.(*corev1.Service)
-			service.Spec.ClusterIP = existing.(*corev1.Service).Spec.ClusterIP
-		}
+// Writes objects through the operator-sdk. Deletes propagate in the background
+// so that deleting a Deployment does not wait for its pods.
+type sdkClient struct{}
 
-		existingMeta, _ := meta.Accessor(existing)
-		desiredMeta, _ := meta.Accessor(desired)
-		desiredMeta.SetResourceVersion(existingMeta.GetResourceVersion())
+func (sdkClient) Create(obj runtime.Object) error {
+	return sdk.Create(obj)
+}
 
-		err := sdk.Update(desired)
-		if err != nil {
-			logrus.Errorf("Could not update %s", name)
-			return err
-		}
+func (sdkClient) Update(obj runtime.Object) error {
+	return sdk.Update(obj)
+}
 
-		logrus.Infof("Updated out of date %s != %s", name, utils.GetObjectHash(existing))
-	}
+func (sdkClient) Delete(obj runtime.Object) error {
+	deletePropagation := metav1.DeletePropagationBackground
+	return sdk.Delete(obj, sdk.WithDeleteOptions(&metav1.DeleteOptions{
+		PropagationPolicy: &deletePropagation,
+	}))
+}
 
-	return nil
+// Create or update desiredObjs based on the current state in existingObjs, in
+// the order of their kinds so that objects are created after the objects they
+// depend on. Objects that fail to apply do not stop the others from being
+// applied, their errors are returned together as utils.ObjectErrors.
+func CreateOrUpdate(cr *v1alpha1.Flux, existingObjs []runtime.Object, desiredObjs []runtime.Object) error {
+	return reconcile.Apply(sdkClient{}, cr, existingObjs, desiredObjs)
 }
@@ -1,57 +1,66 @@
 package stub
 
 import (
+	"fmt"
+
 	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/desired"
 	"github.com/justinbarrick/flux-operator/pkg/flux"
-	"github.com/justinbarrick/flux-operator/pkg/fluxcloud"
-	"github.com/justinbarrick/flux-operator/pkg/helm-operator"
-	"github.com/justinbarrick/flux-operator/pkg/memcached"
-	"github.com/justinbarrick/flux-operator/pkg/rbac"
-	"github.com/justinbarrick/flux-operator/pkg/tiller"
 	"github.com/justinbarrick/flux-operator/pkg/utils"
 
 	"github.com/operator-framework/operator-sdk/pkg/sdk"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
-// Create flux, tiller, and helm-operator instances from a CR and return them
-// as a list of objects.
-func DesiredFluxObjects(cr *v1alpha1.Flux) ([]runtime.Object, error) {
-	objects := rbac.FluxRoles(cr)
-	dep := flux.NewFluxDeployment(cr)
-	objects = append(objects, dep)
-	objects = append(objects, memcached.NewMemcached(cr)...)
-	objects = append(objects, fluxcloud.NewFluxcloud(cr)...)
+// Look up a ConfigMap or Secret referenced by a Flux's pods, returning nil if it
+// does not exist.
+func LookupReference(kind, namespace, name string) (runtime.Object, error) {
+	var object runtime.Object
 
-	sshKey := flux.NewFluxSSHKey(cr)
-	err := sdk.Get(sshKey)
-	if err != nil || utils.OwnedByFlux(cr, sshKey) {
-		objects = append(objects, flux.NewFluxSSHKey(cr))
-	}
+	meta := metav1.ObjectMeta{Name: name, Namespace: namespace}
+	typeMeta := metav1.TypeMeta{Kind: kind, APIVersion: "v1"}
 
-	knownHosts := flux.NewFluxKnownHosts(cr)
-	if knownHosts != nil {
-		objects = append(objects, knownHosts)
+	switch kind {
+	case "ConfigMap":
+		object = &corev1.ConfigMap{TypeMeta: typeMeta, ObjectMeta: meta}
+	case "Secret":
+		object = &corev1.Secret{TypeMeta: typeMeta, ObjectMeta: meta}
+	default:
+		return nil, fmt.Errorf("unsupported kind %s", kind)
 	}
 
-	tillerObjects, err := tiller.NewTiller(cr)
-	if err != nil {
-		logrus.Errorf("Failed to create tiller instance: %v", err)
+	err := sdk.Get(object)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
 		return nil, err
 	}
 
-	objects = append(objects, tillerObjects...)
+	return object, nil
+}
 
-	helmOperator := helm_operator.NewHelmOperatorDeployment(cr)
-	if helmOperator != nil {
-		objects = append(objects, helmOperator)
+// Create flux, tiller, and helm-operator instances from a CR and return them
+// as a list of objects, leaving out the objects in namespaces that do not exist
+// and the SSH key secret if the user created it.
+func DesiredFluxObjects(cr *v1alpha1.Flux) ([]runtime.Object, error) {
+	sshKey := flux.NewFluxSSHKey(cr)
+	err := sdk.Get(sshKey)
+	manageSSHKey := err != nil || utils.OwnedByFlux(cr, sshKey)
+
+	objects, err := desired.FluxObjects(cr, manageSSHKey, LookupReference)
+	if err != nil {
+		logrus.Errorf("Failed to create flux objects: %v", err)
+		return nil, err
 	}
 
-	for index, object := range objects {
-		utils.SetObjectOwner(cr, object)
-		utils.SetObjectHash(object)
-		objects[index] = object
+	objects, err = WithoutMissingNamespaces(cr, objects)
+	if err != nil {
+		logrus.Errorf("Failed to look up target namespaces: %v", err)
+		return nil, err
 	}
 
 	return objects, nil
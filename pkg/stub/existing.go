@@ -2,78 +2,49 @@ package stub
 
 import (
 	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/desired"
 	"github.com/justinbarrick/flux-operator/pkg/utils"
 
 	"github.com/operator-framework/operator-sdk/pkg/sdk"
-	corev1 "k8s.io/api/core/v1"
-	extensions "k8s.io/api/extensions/v1beta1"
-	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
-// Find all resources that currently exist for the CR.
+// Find all resources that currently exist for the CR, of every kind that is
+// created for Fluxes.
 func ExistingFluxObjects(cr *v1alpha1.Flux) (existing []runtime.Object, err error) {
-	lists := []runtime.Object{
-		&extensions.DeploymentList{
-			TypeMeta: metav1.TypeMeta{
-				Kind:       "Deployment",
-				APIVersion: "extensions/v1beta1",
-			},
-		},
-		&corev1.ServiceList{
-			TypeMeta: metav1.TypeMeta{
-				Kind:       "Service",
-				APIVersion: "v1",
-			},
-		},
-		&corev1.SecretList{
-			TypeMeta: metav1.TypeMeta{
-				Kind:       "Secret",
-				APIVersion: "v1",
-			},
-		},
-		&corev1.ServiceAccountList{
-			TypeMeta: metav1.TypeMeta{
-				Kind:       "ServiceAccount",
-				APIVersion: "v1",
-			},
-		},
-		&rbacv1.ClusterRoleList{
-			TypeMeta: metav1.TypeMeta{
-				Kind:       "ClusterRole",
-				APIVersion: "rbac.authorization.k8s.io/v1",
-			},
-		},
-		&rbacv1.ClusterRoleBindingList{
-			TypeMeta: metav1.TypeMeta{
-				Kind:       "ClusterRoleBinding",
-				APIVersion: "rbac.authorization.k8s.io/v1",
-			},
-		},
-		&rbacv1.RoleList{
-			TypeMeta: metav1.TypeMeta{
-				Kind:       "Role",
-				APIVersion: "rbac.authorization.k8s.io/v1",
-			},
-		},
-		&rbacv1.RoleBindingList{
-			TypeMeta: metav1.TypeMeta{
-				Kind:       "RoleBinding",
-				APIVersion: "rbac.authorization.k8s.io/v1",
-			},
-		},
-	}
+	for _, kind := range desired.Kinds() {
+		// flux-operator has no cluster wide permissions in namespaced mode, so it
+		// does not create any cluster scoped objects.
+		if kind.ClusterScoped && config.Namespaced() {
+			continue
+		}
 
-	for _, list := range lists {
-		err = ListForFlux(cr, list)
-		if err != nil {
-			return
+		// Some objects are also created in the Flux's target namespaces, so look
+		// for them in every namespace to find objects in namespaces that are no
+		// longer targeted. In namespaced mode only the watched namespaces can be
+		// listed.
+		namespaces := []string{utils.FluxNamespace(cr)}
+		if kind.ClusterScoped || kind.TargetNamespaces {
+			namespaces = []string{""}
 		}
 
-		items, _ := meta.ExtractList(list)
-		existing = append(existing, items...)
+		if kind.TargetNamespaces && config.Namespaced() {
+			namespaces = config.WatchNamespaces()
+		}
+
+		for _, namespace := range namespaces {
+			list := kind.List()
+
+			err = ListForFluxInNamespace(cr, namespace, list)
+			if err != nil {
+				return
+			}
+
+			items, _ := meta.ExtractList(list)
+			existing = append(existing, items...)
+		}
 	}
 
 	return
@@ -81,9 +52,15 @@ func ExistingFluxObjects(cr *v1alpha1.Flux) (existing []runtime.Object, err erro
 
 // List all resources of a certain type for a CR.
 func ListForFlux(cr *v1alpha1.Flux, list sdk.Object) error {
+	return ListForFluxInNamespace(cr, utils.FluxNamespace(cr), list)
+}
+
+// List all resources of a certain type for a CR in namespace, or in all
+// namespaces if namespace is empty.
+func ListForFluxInNamespace(cr *v1alpha1.Flux, namespace string, list sdk.Object) error {
 	opts := sdk.WithListOptions(utils.ListOptionsForFlux(cr))
 
-	err := sdk.List(utils.FluxNamespace(cr), list, opts)
+	err := sdk.List(namespace, list, opts)
 	if err != nil {
 		return err
 	}
@@ -4,57 +4,210 @@ import (
 	"context"
 
 	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/events"
+	"github.com/justinbarrick/flux-operator/pkg/health"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
 
 	"github.com/operator-framework/operator-sdk/pkg/sdk"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 )
 
-func NewHandler() sdk.Handler {
-	return &Handler{}
+func NewHandler(checker *health.Checker) sdk.Handler {
+	return &Handler{
+		checker: checker,
+	}
 }
 
 type Handler struct {
-	// Fill me
+	// Tracks readiness and in-flight reconciles.
+	checker *health.Checker
 }
 
 func (h *Handler) Handle(ctx context.Context, event sdk.Event) (err error) {
 	switch o := event.Object.(type) {
 	case *v1alpha1.Flux:
+		// The informer only dispatches events once its cache has synced.
+		h.checker.SetReady(true)
+
+		done, ok := h.checker.StartReconcile()
+		if !ok {
+			logrus.Infof("Shutting down, skipping reconcile of %s", o.Name)
+			return
+		}
+		defer done()
+
 		if event.Deleted {
+			unlock := lockFlux(o)
+			defer unlock()
+
+			err = DeleteTargetNamespaceObjects(o)
+			if err != nil {
+				logrus.Errorf("Error deleting target namespace resources: %v", err)
+			}
 			return
 		}
 
-		err = SynchronizeFluxState(o)
-		if err != nil {
-			logrus.Errorf("Error synchronizing Flux state: %v", err)
+		err = ReconcileFlux(o)
+	case *corev1.ConfigMap:
+		if !IsOperatorConfig(o) {
+			return
 		}
+
+		done, ok := h.checker.StartReconcile()
+		if !ok {
+			return
+		}
+		defer done()
+
+		err = ReloadConfig(o, event.Deleted)
 	}
 	return
 }
 
+// Synchronize the state of cr and record the outcome in its status. Reconciles
+// of the same Flux are serialized and the operator config can not change while
+// one is running.
+func ReconcileFlux(cr *v1alpha1.Flux) error {
+	unlock := lockFlux(cr)
+	defer unlock()
+
+	release := config.Hold()
+	defer release()
+
+	err := SynchronizeFluxState(cr)
+	if err != nil {
+		logrus.Errorf("Error synchronizing Flux state: %v", err)
+	}
+
+	if statusErr := UpdateLastError(cr, err); statusErr != nil {
+		logrus.Errorf("Failed to update last error: %v", statusErr)
+	}
+
+	if statusErr := UpdateReady(cr, err); statusErr != nil {
+		logrus.Errorf("Failed to update readiness: %v", statusErr)
+	}
+
+	return err
+}
+
 // Create a flux and tiller with all of the proper RBAC settings.
 func SynchronizeFluxState(cr *v1alpha1.Flux) error {
-	desiredObjs, err := DesiredFluxObjects(cr)
+	if utils.FluxSuspended(cr) {
+		return SuspendFlux(cr)
+	}
+
+	err := ResumeFlux(cr)
 	if err != nil {
-		logrus.Errorf("Failed to determine desired flux state: %v", err)
+		logrus.Errorf("Failed to resume flux: %v", err)
+		return err
+	}
+
+	err = CheckNamespaced(cr)
+	if err != nil {
+		logrus.Errorf("%v", err)
+		events.Warningf(cr, events.ReasonUnsupported, "%v", err)
 		return err
 	}
 
 	existingObjs, err := ExistingFluxObjects(cr)
 	if err != nil {
 		logrus.Errorf("Failed to collect existing resources: %v", err)
+		events.Warningf(cr, events.ReasonReconcileFailed, "Failed to collect existing resources: %v", err)
+		return err
+	}
+
+	violations, err := CheckNamespacePolicies(cr)
+	if err != nil {
+		logrus.Errorf("Failed to check target namespaces: %v", err)
+		events.Warningf(cr, events.ReasonReconcileFailed, "Failed to check target namespaces: %v", err)
+		return err
+	}
+
+	if len(violations) > 0 {
+		err = UpdatePolicyViolations(cr, violations)
+		if err != nil {
+			logrus.Errorf("Failed to update policy violations: %v", err)
+			return err
+		}
+
+		return RejectFlux(cr, existingObjs)
+	}
+
+	desiredObjs, err := DesiredFluxObjects(cr)
+	if err != nil {
+		logrus.Errorf("Failed to determine desired flux state: %v", err)
+		events.Warningf(cr, events.ReasonReconcileFailed, "Failed to determine desired flux state: %v", err)
+		return err
+	}
+
+	missingRoles, err := MissingRoles(cr)
+	if err != nil {
+		logrus.Errorf("Failed to check referenced roles: %v", err)
+		events.Warningf(cr, events.ReasonReconcileFailed, "Failed to check referenced roles: %v", err)
+		return err
+	}
+
+	err = UpdateMissingRoles(cr, missingRoles)
+	if err != nil {
+		logrus.Errorf("Failed to update missing roles: %v", err)
+		return err
+	}
+
+	violations, err = CheckPolicies(cr, desiredObjs)
+	if err != nil {
+		logrus.Errorf("Failed to check flux policies: %v", err)
+		events.Warningf(cr, events.ReasonReconcileFailed, "Failed to check flux policies: %v", err)
+		return err
+	}
+
+	err = UpdatePolicyViolations(cr, violations)
+	if err != nil {
+		logrus.Errorf("Failed to update policy violations: %v", err)
 		return err
 	}
 
-	err = CreateOrUpdate(cr, existingObjs, desiredObjs)
+	if len(violations) > 0 {
+		return RejectFlux(cr, existingObjs)
+	}
+
+	if config.Get().PlanMode || utils.FluxPlanMode(cr) {
+		return PlanFlux(cr, existingObjs, desiredObjs)
+	}
+
+	err = ClearPlan(cr)
 	if err != nil {
-		logrus.Errorf("Error creating resources: %s", err)
+		logrus.Errorf("Failed to clear plan: %v", err)
+		return err
+	}
+
+	// Garbage collection is skipped if any object failed to apply, since the
+	// objects being replaced may still be needed until their replacements exist.
+	var gcErr error
+	applyErr := CreateOrUpdate(cr, existingObjs, desiredObjs)
+	if applyErr != nil {
+		logrus.Errorf("Error creating resources, skipping garbage collection: %s", applyErr)
+	} else {
+		gcErr = GarbageCollectResources(cr, existingObjs, desiredObjs)
+		if gcErr != nil {
+			logrus.Errorf("Error garbage collecting resources: %s", gcErr)
+		}
+	}
+
+	err = UpdateConditions(cr, applyErr, gcErr)
+	if err != nil {
+		logrus.Errorf("Failed to update conditions: %v", err)
+		return err
+	}
+
+	if err = utils.AggregateErrors(applyErr, gcErr); err != nil {
 		return err
 	}
 
-	err = GarbageCollectResources(cr, existingObjs, desiredObjs)
+	err = UpdateDeployKey(cr)
 	if err != nil {
-		logrus.Errorf("Error garbage collecting resources: %s", err)
+		logrus.Errorf("Failed to update deploy key: %v", err)
 		return err
 	}
 
@@ -0,0 +1,49 @@
+package stub
+
+import (
+	"sync"
+
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+)
+
+// A lock serializing the reconciles of one Flux, removed once it is unused.
+type fluxLock struct {
+	sync.Mutex
+	users int
+}
+
+var (
+	fluxLocksLock sync.Mutex
+	fluxLocks     = map[string]*fluxLock{}
+)
+
+// Block until no other reconcile of cr is running, returning a function that
+// must be called once the reconcile completes. Fluxes are reconciled by both
+// the Flux and the operator config informers, which must not create or delete
+// the same objects at once.
+func lockFlux(cr *v1alpha1.Flux) (unlock func()) {
+	key := cr.Namespace + "/" + cr.Name
+
+	fluxLocksLock.Lock()
+	lock := fluxLocks[key]
+	if lock == nil {
+		lock = &fluxLock{}
+		fluxLocks[key] = lock
+	}
+	lock.users++
+	fluxLocksLock.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		fluxLocksLock.Lock()
+		defer fluxLocksLock.Unlock()
+
+		lock.users--
+		if lock.users == 0 {
+			delete(fluxLocks, key)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package stub
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/events"
+	"github.com/justinbarrick/flux-operator/pkg/rbac"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Return the namespaces other than the Flux's own that objects are in and that
+// do not exist yet.
+func MissingNamespaces(cr *v1alpha1.Flux, objects []runtime.Object) (map[string]bool, error) {
+	missing := map[string]bool{}
+
+	// Namespaces can not be read in namespaced mode, the watched namespaces are
+	// assumed to exist and any others are reported by NamespacedErrors.
+	if config.Namespaced() {
+		return missing, nil
+	}
+	checked := map[string]bool{}
+
+	for _, object := range objects {
+		objectMeta, _ := meta.Accessor(object)
+		name := objectMeta.GetNamespace()
+		if name == "" || name == utils.FluxNamespace(cr) || checked[name] {
+			continue
+		}
+		checked[name] = true
+
+		namespace := &corev1.Namespace{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Namespace",
+				APIVersion: "v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		}
+
+		err := sdk.Get(namespace)
+		if errors.IsNotFound(err) {
+			missing[name] = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return missing, nil
+}
+
+// Remove the objects in namespaces that do not exist yet, they are created by a
+// later reconcile once the namespace has been created.
+func WithoutMissingNamespaces(cr *v1alpha1.Flux, objects []runtime.Object) ([]runtime.Object, error) {
+	missing, err := MissingNamespaces(cr, objects)
+	if err != nil || len(missing) == 0 {
+		return objects, err
+	}
+
+	filtered := []runtime.Object{}
+	warned := map[string]bool{}
+	for _, object := range objects {
+		objectMeta, _ := meta.Accessor(object)
+		namespace := objectMeta.GetNamespace()
+		if !missing[namespace] {
+			filtered = append(filtered, object)
+			continue
+		}
+
+		if !warned[namespace] {
+			warned[namespace] = true
+			logrus.Infof("Namespace %s of flux instance '%s' does not exist yet", namespace, cr.Name)
+			events.Warningf(cr, events.ReasonNamespaceMissing, "Namespace %s does not exist yet", namespace)
+		}
+	}
+
+	return filtered, nil
+}
+
+// Return an error describing what the Flux asks for that flux-operator can not
+// provide in namespaced mode, nil if it can be reconciled.
+func CheckNamespaced(cr *v1alpha1.Flux) error {
+	namespacedErrors := rbac.NamespacedErrors(cr)
+	if len(namespacedErrors) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("flux instance '%s' can not be reconciled in namespaced mode: %s",
+		cr.Name, strings.Join(namespacedErrors, "; "))
+}
+
+// Delete the Flux's objects in other namespaces, which can not be owned by the
+// Flux and so are not deleted by Kubernetes when the Flux is deleted.
+func DeleteTargetNamespaceObjects(cr *v1alpha1.Flux) error {
+	existingObjs, err := ExistingFluxObjects(cr)
+	if err != nil {
+		return err
+	}
+
+	foreign := []runtime.Object{}
+	for _, existing := range existingObjs {
+		objectMeta, _ := meta.Accessor(existing)
+		namespace := objectMeta.GetNamespace()
+		if namespace != "" && namespace != utils.FluxNamespace(cr) {
+			foreign = append(foreign, existing)
+		}
+	}
+
+	return GarbageCollectResources(cr, foreign, nil)
+}
@@ -0,0 +1,187 @@
+package stub
+
+import (
+	"fmt"
+
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/policy"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Return the FluxPolicies in the cluster, none if the FluxPolicy CRD is not installed.
+func ListPolicies() ([]v1alpha1.FluxPolicy, error) {
+	policies := &v1alpha1.FluxPolicyList{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "FluxPolicy",
+			APIVersion: "flux.codesink.net/v1alpha1",
+		},
+	}
+
+	err := sdk.List("", policies)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	return policies.Items, err
+}
+
+// Return the Fluxes that deploy to the same namespace as cr.
+func fluxesInNamespace(cr *v1alpha1.Flux) ([]v1alpha1.Flux, error) {
+	fluxes := &v1alpha1.FluxList{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Flux",
+			APIVersion: "flux.codesink.net/v1alpha1",
+		},
+	}
+
+	err := sdk.List(cr.Namespace, fluxes)
+	if err != nil {
+		return nil, err
+	}
+
+	inNamespace := []v1alpha1.Flux{}
+	for _, flux := range fluxes.Items {
+		if utils.FluxNamespace(&flux) == utils.FluxNamespace(cr) {
+			inNamespace = append(inNamespace, flux)
+		}
+	}
+
+	return inNamespace, nil
+}
+
+// Return the FluxPolicies that apply to cr's namespace. There are none in
+// namespaced mode, since FluxPolicies are cluster scoped and can not be read.
+func applicablePolicies(cr *v1alpha1.Flux) ([]v1alpha1.FluxPolicy, error) {
+	if config.Namespaced() {
+		return nil, nil
+	}
+
+	policies, err := ListPolicies()
+	if err != nil || len(policies) == 0 {
+		return nil, err
+	}
+
+	namespace, err := getNamespace(utils.FluxNamespace(cr))
+	if err != nil {
+		return nil, err
+	}
+
+	applicable := []v1alpha1.FluxPolicy{}
+	for _, fluxPolicy := range policies {
+		matches, err := policy.Matches(fluxPolicy, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if matches {
+			applicable = append(applicable, fluxPolicy)
+		}
+	}
+
+	return applicable, nil
+}
+
+// Fetch a namespace by name.
+func getNamespace(name string) (*corev1.Namespace, error) {
+	namespace := &corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Namespace",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+
+	return namespace, sdk.Get(namespace)
+}
+
+// Return the namespaces other than its own that cr targets, and the roles it
+// binds in every namespace, without a FluxPolicy allowing it. This is checked
+// before any of cr's objects are built, so that a Flux can not be granted access
+// to another namespace or the whole cluster.
+func CheckNamespacePolicies(cr *v1alpha1.Flux) ([]string, error) {
+	if cr.Namespace == "" || len(policy.ForeignNamespaces(cr))+len(policy.ClusterRoleRefs(cr)) == 0 {
+		return nil, nil
+	}
+
+	policies, err := applicablePolicies(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := map[string]*corev1.Namespace{}
+	if len(policies) > 0 {
+		for _, name := range policy.ForeignNamespaces(cr) {
+			namespace, err := getNamespace(name)
+			if errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			namespaces[name] = namespace
+		}
+	}
+
+	return policy.NamespaceViolations(cr, policies, namespaces)
+}
+
+// Return the ways in which cr violates the FluxPolicies that apply to its namespace.
+func CheckPolicies(cr *v1alpha1.Flux, desiredObjs []runtime.Object) ([]string, error) {
+	policies, err := applicablePolicies(cr)
+	if err != nil || len(policies) == 0 {
+		return nil, err
+	}
+
+	var fluxes []v1alpha1.Flux
+	violations := []string{}
+
+	for _, fluxPolicy := range policies {
+		if fluxPolicy.Spec.MaxFluxes != nil && fluxes == nil {
+			fluxes, err = fluxesInNamespace(cr)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		policyViolations, err := policy.Violations(fluxPolicy, cr, desiredObjs, fluxes)
+		if err != nil {
+			return nil, err
+		}
+
+		violations = append(violations, policyViolations...)
+	}
+
+	return violations, nil
+}
+
+// Delete the roles and role bindings of a Flux that violates a policy, so that
+// access a policy forbids is revoked rather than only reported. Nothing is
+// deleted in plan mode.
+func RevokeRoles(cr *v1alpha1.Flux, existingObjs []runtime.Object) error {
+	if config.Get().PlanMode || utils.FluxPlanMode(cr) {
+		return nil
+	}
+
+	return GarbageCollectResources(cr, policy.RoleObjects(existingObjs), nil)
+}
+
+// Revoke the roles of a Flux that violates a policy and return an error saying
+// that it does.
+func RejectFlux(cr *v1alpha1.Flux, existingObjs []runtime.Object) error {
+	err := RevokeRoles(cr, existingObjs)
+	if err != nil {
+		logrus.Errorf("Failed to revoke roles: %v", err)
+	}
+
+	return utils.AggregateErrors(fmt.Errorf("flux instance '%s' violates policy", cr.Name), err)
+}
@@ -0,0 +1,72 @@
+package stub
+
+import (
+	"fmt"
+
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/rbac"
+
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Return the object for the role that a role reference points to.
+func referencedRole(cr *v1alpha1.Flux, ref v1alpha1.FluxRoleRef) runtime.Object {
+	if rbac.RoleRefKind(ref) == "Role" {
+		return &rbacv1.Role{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Role",
+				APIVersion: "rbac.authorization.k8s.io/v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ref.Name,
+				Namespace: rbac.RoleRefNamespace(cr, ref),
+			},
+		}
+	}
+
+	return &rbacv1.ClusterRole{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ClusterRole",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ref.Name,
+		},
+	}
+}
+
+// Return a description of each role reference of the Flux that is invalid or
+// points to a role that does not exist.
+func MissingRoles(cr *v1alpha1.Flux) ([]string, error) {
+	missing := []string{}
+
+	for _, ref := range cr.Spec.RoleRefs {
+		if err := rbac.ValidateRoleRef(ref); err != nil {
+			missing = append(missing, err.Error())
+			continue
+		}
+
+		// Cluster roles can not be read in namespaced mode.
+		if config.Namespaced() && rbac.RoleRefKind(ref) == "ClusterRole" {
+			continue
+		}
+
+		err := sdk.Get(referencedRole(cr, ref))
+		if errors.IsNotFound(err) {
+			if rbac.RoleRefKind(ref) == "Role" {
+				missing = append(missing, fmt.Sprintf("Role %s/%s does not exist", rbac.RoleRefNamespace(cr, ref), ref.Name))
+			} else {
+				missing = append(missing, fmt.Sprintf("ClusterRole %s does not exist", ref.Name))
+			}
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return missing, nil
+}
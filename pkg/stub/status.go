@@ -0,0 +1,253 @@
+package stub
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/events"
+	"github.com/justinbarrick/flux-operator/pkg/flux"
+	"github.com/justinbarrick/flux-operator/pkg/plan"
+	"github.com/justinbarrick/flux-operator/pkg/reconcile"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+	"github.com/sirupsen/logrus"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// The API server rejects event messages longer than this.
+const maxEventMessage = 1024
+
+// Write status to the CR if it differs from the CR's current status.
+func UpdateStatus(cr *v1alpha1.Flux, status v1alpha1.FluxStatus) error {
+	if reflect.DeepEqual(cr.Status, status) {
+		return nil
+	}
+
+	cr.Status = status
+	return writeStatus(cr)
+}
+
+// Write the CR's status through the status subresource, or by updating the
+// whole CR if its CRD was installed without the subresource.
+func writeStatus(cr *v1alpha1.Flux) error {
+	path := []string{"/apis", v1alpha1.SchemeGroupVersion.String()}
+	if cr.Namespace != "" {
+		path = append(path, "namespaces", cr.Namespace)
+	}
+	path = append(path, "fluxes", cr.Name, "status")
+
+	body, err := json.Marshal(cr)
+	if err != nil {
+		return err
+	}
+
+	result, err := k8sclient.GetKubeClient().Discovery().RESTClient().Put().AbsPath(path...).
+		SetHeader("Content-Type", "application/json").Body(body).DoRaw()
+	if errors.IsNotFound(err) {
+		return sdk.Update(cr)
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(result, cr)
+}
+
+// Mark the CR as suspended, its resources are left untouched until it is resumed.
+func SuspendFlux(cr *v1alpha1.Flux) error {
+	if cr.Status.Suspended {
+		return nil
+	}
+
+	now := metav1.Now()
+	status := *cr.Status.DeepCopy()
+	status.Suspended = true
+	status.SuspendedSince = &now
+
+	logrus.Infof("Suspending reconciliation of flux instance '%s'", cr.Name)
+	events.Normalf(cr, events.ReasonSuspended, "Reconciliation suspended")
+	return UpdateStatus(cr, status)
+}
+
+// Clear the suspended status of a CR that is no longer suspended.
+func ResumeFlux(cr *v1alpha1.Flux) error {
+	if !cr.Status.Suspended {
+		return nil
+	}
+
+	status := *cr.Status.DeepCopy()
+	status.Suspended = false
+	status.SuspendedSince = nil
+
+	logrus.Infof("Resuming reconciliation of flux instance '%s'", cr.Name)
+	events.Normalf(cr, events.ReasonResumed, "Reconciliation resumed")
+	return UpdateStatus(cr, status)
+}
+
+// Record the changes a reconcile would make to the CR's status and as an event
+// without changing anything.
+func PlanFlux(cr *v1alpha1.Flux, existingObjs []runtime.Object, desiredObjs []runtime.Object) error {
+	fluxPlan := plan.NewPlan(existingObjs, desiredObjs)
+
+	status := *cr.Status.DeepCopy()
+	status.Plan = fluxPlan.String()
+	if status.Plan == cr.Status.Plan {
+		return nil
+	}
+
+	logrus.Infof("Planned flux instance '%s': %s", cr.Name, fluxPlan.Summary())
+	events.Normalf(cr, events.ReasonPlanned, "%s", utils.Truncate(status.Plan, maxEventMessage))
+	return UpdateStatus(cr, status)
+}
+
+// Clear the plan from the status of a CR that is no longer in plan mode.
+func ClearPlan(cr *v1alpha1.Flux) error {
+	if cr.Status.Plan == "" {
+		return nil
+	}
+
+	status := *cr.Status.DeepCopy()
+	status.Plan = ""
+	return UpdateStatus(cr, status)
+}
+
+// Record the FluxPolicy violations of a CR in its status and as an event.
+func UpdatePolicyViolations(cr *v1alpha1.Flux, violations []string) error {
+	if len(violations) == 0 {
+		violations = nil
+	}
+
+	if reflect.DeepEqual(cr.Status.PolicyViolations, violations) {
+		return nil
+	}
+
+	status := *cr.Status.DeepCopy()
+	status.PolicyViolations = violations
+
+	if len(violations) > 0 {
+		logrus.Warnf("Flux instance '%s' violates policy: %s", cr.Name, strings.Join(violations, "; "))
+		events.Warningf(cr, events.ReasonPolicyViolation, "%s", utils.Truncate(strings.Join(violations, "; "), maxEventMessage))
+	} else {
+		logrus.Infof("Flux instance '%s' no longer violates any policy", cr.Name)
+	}
+
+	return UpdateStatus(cr, status)
+}
+
+// Record the Flux's missing roles in its status, emitting a warning event if
+// there are any.
+func UpdateMissingRoles(cr *v1alpha1.Flux, missing []string) error {
+	if len(missing) == 0 {
+		missing = nil
+	}
+
+	if reflect.DeepEqual(cr.Status.MissingRoles, missing) {
+		return nil
+	}
+
+	status := *cr.Status.DeepCopy()
+	status.MissingRoles = missing
+
+	if len(missing) > 0 {
+		logrus.Warnf("Flux instance '%s' references missing roles: %s", cr.Name, strings.Join(missing, "; "))
+		events.Warningf(cr, events.ReasonRoleMissing, "%s", utils.Truncate(strings.Join(missing, "; "), maxEventMessage))
+	} else {
+		logrus.Infof("Flux instance '%s' no longer references missing roles", cr.Name)
+	}
+
+	return UpdateStatus(cr, status)
+}
+
+// Record the error that stopped the last reconcile in the CR's status, or clear
+// it if err is nil.
+func UpdateLastError(cr *v1alpha1.Flux, err error) error {
+	lastError := ""
+	if err != nil {
+		lastError = utils.Truncate(err.Error(), maxEventMessage)
+	}
+
+	if cr.Status.LastError == lastError {
+		return nil
+	}
+
+	status := *cr.Status.DeepCopy()
+	status.LastError = lastError
+	return UpdateStatus(cr, status)
+}
+
+// Record whether the last reconcile created or updated every object and deleted
+// every object that is no longer needed in the CR's conditions.
+func UpdateConditions(cr *v1alpha1.Flux, applyErr, gcErr error) error {
+	status := *cr.Status.DeepCopy()
+	status.Conditions = reconcile.Conditions(status.Conditions, applyErr, gcErr)
+	return UpdateStatus(cr, status)
+}
+
+// Record the public key of the SSH key flux generated in the CR's status so that
+// it can be added to the git repository. The key is only available once flux
+// has started and written it to the git secret.
+func UpdateDeployKey(cr *v1alpha1.Flux) error {
+	secret := flux.NewFluxSSHKey(cr)
+	err := sdk.Get(secret)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	deployKey, err := flux.DeployKey(cr, secret)
+	if err != nil {
+		return err
+	}
+
+	if cr.Status.DeployKey == deployKey {
+		return nil
+	}
+
+	status := *cr.Status.DeepCopy()
+	status.DeployKey = deployKey
+	return UpdateStatus(cr, status)
+}
+
+// Record whether the CR is ready, its last reconcile succeeded and each of its
+// Deployments has all of its replicas available, and the flux version it runs.
+func UpdateReady(cr *v1alpha1.Flux, reconcileErr error) error {
+	deployments := &extensions.DeploymentList{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "extensions/v1beta1",
+		},
+	}
+
+	if err := ListForFlux(cr, deployments); err != nil {
+		return err
+	}
+
+	ready := reconcileErr == nil && len(deployments.Items) > 0
+	for _, deployment := range deployments.Items {
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+
+		if deployment.Status.AvailableReplicas < replicas {
+			ready = false
+		}
+	}
+
+	_, fluxVersion := flux.FluxImage(cr)
+	if cr.Status.Ready == ready && cr.Status.FluxVersion == fluxVersion {
+		return nil
+	}
+
+	status := *cr.Status.DeepCopy()
+	status.Ready = ready
+	status.FluxVersion = fluxVersion
+	return UpdateStatus(cr, status)
+}
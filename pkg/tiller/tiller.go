@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/config"
+	"github.com/justinbarrick/flux-operator/pkg/kinds"
 	"github.com/justinbarrick/flux-operator/pkg/rbac"
 	"github.com/justinbarrick/flux-operator/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
@@ -14,6 +16,11 @@ import (
 	"k8s.io/helm/cmd/helm/installer"
 )
 
+// Register the kinds of objects this package creates for Fluxes.
+func init() {
+	kinds.Register(kinds.Deployment, kinds.Service)
+}
+
 // Decode a YAML manifest into `out`.
 func TillerManifest(asStr string, out interface{}) error {
 	err := yaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(asStr), len(asStr)).Decode(out)
@@ -24,18 +31,25 @@ func TillerManifest(asStr string, out interface{}) error {
 	return nil
 }
 
-// Create Tiller installation options from a CR.
-func TillerOptions(cr *v1alpha1.Flux) *installer.Options {
-	tillerImage := utils.Getenv("TILLER_IMAGE", utils.TillerImage)
+// Return the tiller image and version to run for the CR.
+func TillerImage(cr *v1alpha1.Flux) (string, string) {
+	tillerImage := config.Get().TillerImage
 	if cr.Spec.Tiller.TillerImage != "" {
 		tillerImage = cr.Spec.Tiller.TillerImage
 	}
 
-	tillerVersion := utils.Getenv("TILLER_VERSION", utils.TillerVersion)
+	tillerVersion := config.Get().TillerVersion
 	if cr.Spec.Tiller.TillerVersion != "" {
 		tillerVersion = cr.Spec.Tiller.TillerVersion
 	}
 
+	return tillerImage, tillerVersion
+}
+
+// Create Tiller installation options from a CR.
+func TillerOptions(cr *v1alpha1.Flux) *installer.Options {
+	tillerImage, tillerVersion := TillerImage(cr)
+
 	return &installer.Options{
 		Namespace:      utils.FluxNamespace(cr),
 		ServiceAccount: rbac.ServiceAccountName(cr),
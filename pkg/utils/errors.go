@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"k8s.io/apimachinery/pkg/runtime"
+	"strings"
+)
+
+// An error creating, updating or deleting one of a Flux's objects.
+type ObjectError struct {
+	// `create`, `update`, `delete` or `apply` for objects that were skipped.
+	Action string
+	// The object, nil if the error is not about a single object.
+	Object runtime.Object
+	Err    error
+}
+
+func (e ObjectError) Error() string {
+	if e.Object == nil {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("failed to %s %s: %v", e.Action, ObjectName(e.Object), e.Err)
+}
+
+// The errors of every object that could not be reconciled.
+type ObjectErrors []ObjectError
+
+func (e ObjectErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	messages := []string{}
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("%d errors: %s", len(e), strings.Join(messages, "; "))
+}
+
+// Return the errors as an error, or nil if there are none.
+func (e ObjectErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Combine errors into one, flattening ObjectErrors and leaving out nil errors.
+// Returns nil if every error is nil.
+func AggregateErrors(errs ...error) error {
+	aggregate := ObjectErrors{}
+
+	for _, err := range errs {
+		switch typed := err.(type) {
+		case nil:
+		case ObjectErrors:
+			aggregate = append(aggregate, typed...)
+		case ObjectError:
+			aggregate = append(aggregate, typed)
+		default:
+			aggregate = append(aggregate, ObjectError{Err: err})
+		}
+	}
+
+	return aggregate.Err()
+}
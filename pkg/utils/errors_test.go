@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestObjectErrors(t *testing.T) {
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "key", Namespace: "default"},
+	}
+
+	errs := ObjectErrors{}
+	assert.Nil(t, errs.Err())
+
+	errs = append(errs, ObjectError{Action: "create", Object: secret, Err: fmt.Errorf("forbidden")})
+	assert.Equal(t, fmt.Sprintf("failed to create %s: forbidden", ObjectName(secret)), errs.Err().Error())
+
+	errs = append(errs, ObjectError{Err: fmt.Errorf("timeout")})
+	assert.Equal(t, fmt.Sprintf("2 errors: failed to create %s: forbidden; timeout", ObjectName(secret)), errs.Error())
+}
+
+func TestAggregateErrors(t *testing.T) {
+	assert.Nil(t, AggregateErrors(nil, nil))
+
+	apply := ObjectErrors{{Action: "update", Err: fmt.Errorf("one")}, {Action: "create", Err: fmt.Errorf("two")}}
+	err := AggregateErrors(apply, nil, fmt.Errorf("three"))
+	assert.Len(t, err.(ObjectErrors), 3)
+	assert.Equal(t, "3 errors: one; two; three", err.Error())
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// The flux-operator version fluxopctl was built with, set at build time with
+// -ldflags "-X github.com/justinbarrick/flux-operator/pkg/utils.Version=v0.1.0".
+// It is empty in development builds.
+var Version = ""
+
+// How long a looked up release is cached for before looking it up again.
+const ReleaseCacheMaxAge = 24 * time.Hour
+
+// A release cached on disk.
+type cachedRelease struct {
+	Repo      string    `json:"repo"`
+	Version   string    `json:"version"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// Resolves the flux-operator version to install. The latest release is only
+// looked up the first time Version is called and is cached in CacheFile, so
+// that it is looked up at most once per MaxAge.
+type ReleaseResolver struct {
+	// The Github repository to look up the latest release of.
+	Repo string
+	// The version to use when offline, or when the latest release can not be
+	// looked up and none is cached.
+	Default string
+	// Never look up the latest release, only use Default.
+	Offline bool
+	// Where to cache the latest release, if empty it is not cached.
+	CacheFile string
+	// How long the cached release is used for.
+	MaxAge time.Duration
+	// Look up the latest release of a repository.
+	Lookup func(repo string) (string, error)
+	// Called with a warning when falling back to an older version.
+	Warn func(format string, args ...interface{})
+
+	once    sync.Once
+	version string
+	err     error
+}
+
+// Return a resolver for the latest release of repo, cached in the user's cache
+// directory and defaulting to the version fluxopctl was built with.
+func NewReleaseResolver(repo string, offline bool) *ReleaseResolver {
+	cacheFile := ""
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		cacheFile = filepath.Join(cacheDir, "fluxopctl", "latest-release.json")
+	}
+
+	return &ReleaseResolver{
+		Repo:      repo,
+		Default:   Version,
+		Offline:   offline,
+		CacheFile: cacheFile,
+		MaxAge:    ReleaseCacheMaxAge,
+		Lookup:    LatestRelease,
+	}
+}
+
+// Return the version to install, resolving it on the first call.
+func (r *ReleaseResolver) Version() (string, error) {
+	r.once.Do(func() {
+		r.version, r.err = r.resolve()
+	})
+	return r.version, r.err
+}
+
+func (r *ReleaseResolver) resolve() (string, error) {
+	if r.Offline {
+		if r.Default == "" {
+			return "", errors.New("fluxopctl was built without a flux-operator version, -flux-operator-version is required with -offline")
+		}
+		return r.Default, nil
+	}
+
+	cached := r.readCache()
+	if cached != nil && time.Since(cached.FetchedAt) < r.MaxAge {
+		return cached.Version, nil
+	}
+
+	version, err := r.Lookup(r.Repo)
+	if err == nil {
+		r.writeCache(version)
+		return version, nil
+	}
+
+	fallback := r.Default
+	if cached != nil {
+		fallback = cached.Version
+	}
+
+	if fallback == "" {
+		return "", fmt.Errorf("could not look up the latest flux-operator release, set -flux-operator-version: %v", err)
+	}
+
+	r.warn("could not look up the latest flux-operator release, using %s: %v", fallback, err)
+	return fallback, nil
+}
+
+func (r *ReleaseResolver) warn(format string, args ...interface{}) {
+	if r.Warn != nil {
+		r.Warn(format, args...)
+	}
+}
+
+// Return the release cached for the repository, nil if there is none.
+func (r *ReleaseResolver) readCache() *cachedRelease {
+	if r.CacheFile == "" {
+		return nil
+	}
+
+	contents, err := ioutil.ReadFile(r.CacheFile)
+	if err != nil {
+		return nil
+	}
+
+	cached := &cachedRelease{}
+	if err := json.Unmarshal(contents, cached); err != nil || cached.Repo != r.Repo || cached.Version == "" {
+		return nil
+	}
+
+	return cached
+}
+
+// Cache version as the latest release, failing to cache it is not an error
+// since it will be looked up again next time.
+func (r *ReleaseResolver) writeCache(version string) {
+	if r.CacheFile == "" {
+		return
+	}
+
+	contents, err := json.Marshal(cachedRelease{
+		Repo:      r.Repo,
+		Version:   version,
+		FetchedAt: time.Now(),
+	})
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.CacheFile), 0755); err != nil {
+		return
+	}
+
+	ioutil.WriteFile(r.CacheFile, contents, 0644)
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestResolver(t *testing.T, release string, err error) (*ReleaseResolver, *int, func()) {
+	dir, tmpErr := ioutil.TempDir("", "release")
+	assert.Nil(t, tmpErr)
+
+	lookups := 0
+	resolver := &ReleaseResolver{
+		Repo:      "justinbarrick/flux-operator",
+		Default:   "v0.1.0",
+		CacheFile: filepath.Join(dir, "fluxopctl", "latest-release.json"),
+		MaxAge:    time.Hour,
+		Lookup: func(repo string) (string, error) {
+			lookups++
+			return release, err
+		},
+	}
+
+	return resolver, &lookups, func() { os.RemoveAll(dir) }
+}
+
+func writeTestCache(t *testing.T, resolver *ReleaseResolver, version string, fetchedAt time.Time) {
+	contents, err := json.Marshal(cachedRelease{Repo: resolver.Repo, Version: version, FetchedAt: fetchedAt})
+	assert.Nil(t, err)
+	assert.Nil(t, os.MkdirAll(filepath.Dir(resolver.CacheFile), 0755))
+	assert.Nil(t, ioutil.WriteFile(resolver.CacheFile, contents, 0644))
+}
+
+func TestReleaseResolverLooksUpOnceAndCaches(t *testing.T) {
+	resolver, lookups, cleanup := newTestResolver(t, "v0.3.0", nil)
+	defer cleanup()
+
+	for i := 0; i < 2; i++ {
+		version, err := resolver.Version()
+		assert.Nil(t, err)
+		assert.Equal(t, "v0.3.0", version)
+	}
+	assert.Equal(t, 1, *lookups)
+
+	cached := resolver.readCache()
+	assert.NotNil(t, cached)
+	assert.Equal(t, "v0.3.0", cached.Version)
+}
+
+func TestReleaseResolverUsesFreshCache(t *testing.T) {
+	resolver, lookups, cleanup := newTestResolver(t, "v0.3.0", nil)
+	defer cleanup()
+
+	writeTestCache(t, resolver, "v0.2.0", time.Now())
+
+	version, err := resolver.Version()
+	assert.Nil(t, err)
+	assert.Equal(t, "v0.2.0", version)
+	assert.Equal(t, 0, *lookups)
+}
+
+func TestReleaseResolverRefreshesStaleCache(t *testing.T) {
+	resolver, lookups, cleanup := newTestResolver(t, "v0.3.0", nil)
+	defer cleanup()
+
+	writeTestCache(t, resolver, "v0.2.0", time.Now().Add(-2*time.Hour))
+
+	version, err := resolver.Version()
+	assert.Nil(t, err)
+	assert.Equal(t, "v0.3.0", version)
+	assert.Equal(t, 1, *lookups)
+}
+
+func TestReleaseResolverFallsBackToStaleCache(t *testing.T) {
+	resolver, _, cleanup := newTestResolver(t, "", errors.New("offline"))
+	defer cleanup()
+
+	warnings := 0
+	resolver.Warn = func(format string, args ...interface{}) { warnings++ }
+	writeTestCache(t, resolver, "v0.2.0", time.Now().Add(-2*time.Hour))
+
+	version, err := resolver.Version()
+	assert.Nil(t, err)
+	assert.Equal(t, "v0.2.0", version)
+	assert.Equal(t, 1, warnings)
+}
+
+func TestReleaseResolverFallsBackToDefault(t *testing.T) {
+	resolver, _, cleanup := newTestResolver(t, "", errors.New("offline"))
+	defer cleanup()
+
+	version, err := resolver.Version()
+	assert.Nil(t, err)
+	assert.Equal(t, "v0.1.0", version)
+}
+
+func TestReleaseResolverFailsWithoutFallback(t *testing.T) {
+	resolver, _, cleanup := newTestResolver(t, "", errors.New("offline"))
+	defer cleanup()
+
+	resolver.Default = ""
+
+	_, err := resolver.Version()
+	assert.NotNil(t, err)
+}
+
+func TestReleaseResolverOffline(t *testing.T) {
+	resolver, lookups, cleanup := newTestResolver(t, "v0.3.0", nil)
+	defer cleanup()
+
+	resolver.Offline = true
+	writeTestCache(t, resolver, "v0.2.0", time.Now())
+
+	version, err := resolver.Version()
+	assert.Nil(t, err)
+	assert.Equal(t, "v0.1.0", version)
+	assert.Equal(t, 0, *lookups)
+
+	resolver, _, cleanup = newTestResolver(t, "v0.3.0", nil)
+	defer cleanup()
+
+	resolver.Offline = true
+	resolver.Default = ""
+
+	_, err = resolver.Version()
+	assert.NotNil(t, err)
+}
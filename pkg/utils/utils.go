@@ -7,11 +7,15 @@ import (
 	"github.com/cnf/structhash"
 	"github.com/google/go-github/github"
 	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"os"
 	"strconv"
 	"strings"
@@ -19,6 +23,9 @@ import (
 
 const (
 	FLUX_LABEL          = "flux.codesink.net.flux"
+	PausedAnnotation    = "flux.codesink.net/paused"
+	PlanAnnotation      = "flux.codesink.net/plan"
+	ChecksumAnnotation  = "flux.codesink.net/checksum"
 	FluxcloudImage      = "justinbarrick/fluxcloud"
 	FluxcloudVersion    = "v0.3.4"
 	FluxOperatorImage   = "justinbarrick/flux-operator"
@@ -32,6 +39,19 @@ const (
 	TillerVersion       = "v2.9.1"
 )
 
+// The recommended labels set on every object owned by a Flux.
+const (
+	AppNameLabel      = "app.kubernetes.io/name"
+	AppInstanceLabel  = "app.kubernetes.io/instance"
+	AppComponentLabel = "app.kubernetes.io/component"
+	AppManagedByLabel = "app.kubernetes.io/managed-by"
+	AppVersionLabel   = "app.kubernetes.io/version"
+)
+
+// Labels and annotations with these prefixes belong to flux-operator or kubectl
+// and are never propagated from a Flux to its objects.
+var reservedPrefixes = []string{"flux.codesink.net", "kubectl.kubernetes.io/"}
+
 // Get an environment variable, pass through strconv.ParseBool, return false if there
 // is an error.
 func BoolEnv(name string) bool {
@@ -52,6 +72,24 @@ func FluxNamespace(cr *v1alpha1.Flux) string {
 	}
 }
 
+// Return true if reconciling the Flux is suspended, either with `spec.suspend`
+// or by setting the `flux.codesink.net/paused` annotation to true.
+func FluxSuspended(cr *v1alpha1.Flux) bool {
+	if cr.Spec.Suspend {
+		return true
+	}
+
+	paused, _ := strconv.ParseBool(cr.ObjectMeta.Annotations[PausedAnnotation])
+	return paused
+}
+
+// Return true if the `flux.codesink.net/plan` annotation is set to true and the
+// Flux should only be planned and not applied.
+func FluxPlanMode(cr *v1alpha1.Flux) bool {
+	plan, _ := strconv.ParseBool(cr.ObjectMeta.Annotations[PlanAnnotation])
+	return plan
+}
+
 // Getenv returns an environment variable or `value` if it does not exist.
 func Getenv(name, value string) string {
 	ret := os.Getenv(name)
@@ -139,6 +177,107 @@ func SetObjectOwner(cr *v1alpha1.Flux, obj runtime.Object) {
 	objectMeta.SetLabels(labels)
 }
 
+// Return the app.kubernetes.io labels for an object that is part of component of
+// a Flux, running version. The version label is left out if version is not a
+// valid label value, e.g. if it is a digest.
+func AppLabels(cr *v1alpha1.Flux, component, version string) map[string]string {
+	appLabels := map[string]string{
+		AppNameLabel:      "flux",
+		AppInstanceLabel:  cr.ObjectMeta.Name,
+		AppComponentLabel: component,
+		AppManagedByLabel: "flux-operator",
+	}
+
+	if len(validation.IsValidLabelValue(version)) == 0 {
+		appLabels[AppVersionLabel] = version
+	}
+
+	return appLabels
+}
+
+// Return the entries of values whose keys match one of patterns. A pattern
+// ending in `*` matches every key with that prefix. Reserved keys are never
+// matched.
+func SelectKeys(values map[string]string, patterns []string) map[string]string {
+	selected := map[string]string{}
+
+	for key, value := range values {
+		reserved := false
+		for _, prefix := range reservedPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				reserved = true
+			}
+		}
+
+		if reserved {
+			continue
+		}
+
+		for _, pattern := range patterns {
+			if key == pattern || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(key, strings.TrimSuffix(pattern, "*"))) {
+				selected[key] = value
+				break
+			}
+		}
+	}
+
+	return selected
+}
+
+// Return the pod template of a workload, or nil if the object has none.
+func PodTemplate(obj runtime.Object) *corev1.PodTemplateSpec {
+	switch workload := obj.(type) {
+	case *extensions.Deployment:
+		return &workload.Spec.Template
+	case *appsv1.Deployment:
+		return &workload.Spec.Template
+	case *appsv1.StatefulSet:
+		return &workload.Spec.Template
+	case *appsv1.DaemonSet:
+		return &workload.Spec.Template
+	}
+	return nil
+}
+
+// Return a copy of existing with the entries of added whose keys it does not
+// already have.
+func mergeMissing(existing, added map[string]string) map[string]string {
+	if len(added) == 0 {
+		return existing
+	}
+
+	merged := map[string]string{}
+	for key, value := range added {
+		merged[key] = value
+	}
+	for key, value := range existing {
+		merged[key] = value
+	}
+	return merged
+}
+
+// Add labels and annotations to an object and to its pod template, if it has
+// one, without replacing any that are already set. The maps are copied, so
+// selectors sharing them with the object are not changed.
+func SetObjectMetadata(obj runtime.Object, labels, annotations map[string]string) {
+	objectMeta, err := meta.Accessor(obj)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	objectMeta.SetLabels(mergeMissing(objectMeta.GetLabels(), labels))
+	objectMeta.SetAnnotations(mergeMissing(objectMeta.GetAnnotations(), annotations))
+
+	template := PodTemplate(obj)
+	if template == nil {
+		return
+	}
+
+	template.ObjectMeta.Labels = mergeMissing(template.ObjectMeta.Labels, labels)
+	template.ObjectMeta.Annotations = mergeMissing(template.ObjectMeta.Annotations, annotations)
+}
+
 // Takes a Kubernetes object and returns the hash in its annotations as a string.
 func GetObjectHash(obj runtime.Object) string {
 	objectMeta, _ := meta.Accessor(obj)
@@ -212,6 +351,15 @@ func ReadableObjectName(cr *v1alpha1.Flux, object runtime.Object) string {
 		cr.Name, ObjectName(object), GetObjectHash(object))
 }
 
+// Truncate message to at most length bytes.
+func Truncate(message string, length int) string {
+	if len(message) <= length {
+		return message
+	}
+
+	return message[:length-3] + "..."
+}
+
 // Return true if first and second have the same Name, Namespace, and Kind.
 func ObjectNameMatches(first runtime.Object, second runtime.Object) bool {
 	firstMeta, _ := meta.Accessor(first)
@@ -3,6 +3,8 @@ package utils
 import (
 	"github.com/justinbarrick/flux-operator/pkg/utils/test"
 	"github.com/stretchr/testify/assert"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"os"
 	"testing"
@@ -29,6 +31,31 @@ func TestFluxNamespaceFromSpecIfClusterScopeDefault(t *testing.T) {
 	assert.Equal(t, "default", FluxNamespace(cr))
 }
 
+func TestFluxSuspended(t *testing.T) {
+	cr := test_utils.NewFlux()
+	assert.False(t, FluxSuspended(cr))
+
+	cr.Spec.Suspend = true
+	assert.True(t, FluxSuspended(cr))
+}
+
+func TestFluxSuspendedByAnnotation(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.ObjectMeta.Annotations = map[string]string{PausedAnnotation: "true"}
+	assert.True(t, FluxSuspended(cr))
+
+	cr.ObjectMeta.Annotations[PausedAnnotation] = "false"
+	assert.False(t, FluxSuspended(cr))
+}
+
+func TestFluxPlanMode(t *testing.T) {
+	cr := test_utils.NewFlux()
+	assert.False(t, FluxPlanMode(cr))
+
+	cr.ObjectMeta.Annotations = map[string]string{PlanAnnotation: "true"}
+	assert.True(t, FluxPlanMode(cr))
+}
+
 func TestGetenv(t *testing.T) {
 	os.Setenv("MY_VAR", "value")
 	defer os.Setenv("MY_VAR", "")
@@ -123,3 +150,54 @@ func TestGetObject(t *testing.T) {
 	assert.Equal(t, GetObject(cr, []runtime.Object{cr3, cr2, cr4}), cr2)
 	assert.Equal(t, GetObject(cr, []runtime.Object{cr3, cr4}), nil)
 }
+
+func TestAppLabels(t *testing.T) {
+	cr := test_utils.NewFlux()
+
+	labels := AppLabels(cr, "memcached", "1.4.36-alpine")
+	assert.Equal(t, "flux", labels[AppNameLabel])
+	assert.Equal(t, cr.Name, labels[AppInstanceLabel])
+	assert.Equal(t, "memcached", labels[AppComponentLabel])
+	assert.Equal(t, "flux-operator", labels[AppManagedByLabel])
+	assert.Equal(t, "1.4.36-alpine", labels[AppVersionLabel])
+
+	_, ok := AppLabels(cr, "flux", "sha256:abcdef")[AppVersionLabel]
+	assert.False(t, ok)
+}
+
+func TestSelectKeys(t *testing.T) {
+	values := map[string]string{
+		"team":                               "a",
+		"cost-center":                        "b",
+		"example.com/owner":                  "c",
+		"other":                              "d",
+		"flux.codesink.net/paused":           "true",
+		"kubectl.kubernetes.io/last-applied": "{}",
+	}
+
+	assert.Equal(t, map[string]string{
+		"team":              "a",
+		"example.com/owner": "c",
+	}, SelectKeys(values, []string{"team", "example.com/*", "missing"}))
+	assert.Equal(t, 4, len(SelectKeys(values, []string{"*"})))
+	assert.Equal(t, map[string]string{}, SelectKeys(values, []string{}))
+}
+
+func TestSetObjectMetadata(t *testing.T) {
+	selector := map[string]string{"name": "flux"}
+	deployment := &extensions.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Labels: selector},
+		Spec: extensions.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+		},
+	}
+	deployment.Spec.Template.ObjectMeta.Labels = selector
+
+	SetObjectMetadata(deployment, map[string]string{"name": "other", "team": "a"}, map[string]string{"note": "b"})
+
+	assert.Equal(t, map[string]string{"name": "flux", "team": "a"}, deployment.ObjectMeta.Labels)
+	assert.Equal(t, map[string]string{"note": "b"}, deployment.ObjectMeta.Annotations)
+	assert.Equal(t, map[string]string{"name": "flux", "team": "a"}, deployment.Spec.Template.ObjectMeta.Labels)
+	assert.Equal(t, map[string]string{"note": "b"}, deployment.Spec.Template.ObjectMeta.Annotations)
+	assert.Equal(t, map[string]string{"name": "flux"}, deployment.Spec.Selector.MatchLabels)
+}
@@ -0,0 +1,211 @@
+package validation
+
+import (
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/rbac"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The flags accepted by fluxd, which args are passed to.
+var fluxArgs = map[string]bool{
+	"connect":                      true,
+	"docker-config":                true,
+	"git-branch":                   true,
+	"git-ci-skip":                  true,
+	"git-ci-skip-message":          true,
+	"git-email":                    true,
+	"git-label":                    true,
+	"git-notes-ref":                true,
+	"git-path":                     true,
+	"git-poll-interval":            true,
+	"git-set-author":               true,
+	"git-sync-tag":                 true,
+	"git-timeout":                  true,
+	"git-url":                      true,
+	"git-user":                     true,
+	"k8s-namespace-whitelist":      true,
+	"k8s-secret-data-key":          true,
+	"k8s-secret-name":              true,
+	"k8s-secret-volume-mount-path": true,
+	"listen":                       true,
+	"listen-metrics":               true,
+	"log-format":                   true,
+	"memcached-hostname":           true,
+	"memcached-port":               true,
+	"memcached-service":            true,
+	"memcached-timeout":            true,
+	"registry-burst":               true,
+	"registry-cache-expiry":        true,
+	"registry-ecr-exclude-id":      true,
+	"registry-ecr-include-id":      true,
+	"registry-ecr-region":          true,
+	"registry-exclude-image":       true,
+	"registry-insecure-host":       true,
+	"registry-poll-interval":       true,
+	"registry-rps":                 true,
+	"registry-trace":               true,
+	"ssh-keygen-bits":              true,
+	"ssh-keygen-dir":               true,
+	"ssh-keygen-type":              true,
+	"sync-garbage-collection":      true,
+	"sync-interval":                true,
+	"token":                        true,
+}
+
+// Matches scp-like git URLs, e.g. `git@github.com:justinbarrick/flux-operator`.
+var scpURL = regexp.MustCompile(`^[\w.-]+@[\w.-]+:[^/].*$`)
+
+// Return an error if value is not a URL git can clone from.
+func validateGitURL(field, value string) []Error {
+	if value == "" {
+		return []Error{fieldError(field, "required field is missing")}
+	}
+
+	if scpURL.MatchString(value) {
+		return nil
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return []Error{fieldError(field, "invalid git URL %q: %v", value, err)}
+	}
+
+	switch parsed.Scheme {
+	case "ssh", "git", "http", "https":
+		if parsed.Host == "" {
+			return []Error{fieldError(field, "invalid git URL %q: missing host", value)}
+		}
+		return nil
+	}
+
+	return []Error{fieldError(field, "invalid git URL %q: must be ssh://, git://, http(s):// or user@host:path", value)}
+}
+
+// Return an error if value is not an http or https URL.
+func validateHTTPURL(field, value string) []Error {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return []Error{fieldError(field, "invalid URL %q: must be an http:// or https:// URL", value)}
+	}
+	return nil
+}
+
+// Return an error if value is set but is not a valid duration, e.g. `5m`.
+func validateDuration(field, value string) []Error {
+	if value == "" {
+		return nil
+	}
+
+	if _, err := time.ParseDuration(value); err != nil {
+		return []Error{fieldError(field, "invalid duration %q, e.g. 5m or 1h30m", value)}
+	}
+
+	return nil
+}
+
+// Return an error for each required field that is empty.
+func validateRequired(fields map[string]string) []Error {
+	errors := []Error{}
+
+	names := []string{}
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if fields[name] == "" {
+			errors = append(errors, fieldError(name, "required field is missing"))
+		}
+	}
+
+	return errors
+}
+
+// Validate the fluxcloud settings, the fields that are required depend on
+// whether it exports to Matrix (if matrixUrl is set) or Slack.
+func validateFluxCloud(fluxCloud v1alpha1.FluxCloud) []Error {
+	if !fluxCloud.Enabled {
+		return nil
+	}
+
+	errors := []Error{}
+
+	if fluxCloud.GithubURL != "" {
+		errors = append(errors, validateHTTPURL("spec.fluxCloud.githubUrl", fluxCloud.GithubURL)...)
+	}
+
+	if fluxCloud.MatrixURL != "" {
+		errors = append(errors, validateHTTPURL("spec.fluxCloud.matrixUrl", fluxCloud.MatrixURL)...)
+		errors = append(errors, validateRequired(map[string]string{
+			"spec.fluxCloud.matrixRoomId": fluxCloud.MatrixRoomId,
+			"spec.fluxCloud.matrixToken":  fluxCloud.MatrixToken,
+		})...)
+		return errors
+	}
+
+	errors = append(errors, validateRequired(map[string]string{
+		"spec.fluxCloud.slackUrl":     fluxCloud.SlackURL,
+		"spec.fluxCloud.slackChannel": fluxCloud.SlackChannel,
+	})...)
+
+	if fluxCloud.SlackURL != "" {
+		errors = append(errors, validateHTTPURL("spec.fluxCloud.slackUrl", fluxCloud.SlackURL)...)
+	}
+
+	return errors
+}
+
+// Return an error for each arg that fluxd does not accept.
+func validateArgs(args map[string]string) []Error {
+	errors := []Error{}
+
+	names := []string{}
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := fmt.Sprintf("spec.args.%s", name)
+
+		if strings.HasPrefix(name, "-") {
+			errors = append(errors, fieldError(field, "args must not include the leading --"))
+		} else if !fluxArgs[name] {
+			errors = append(errors, fieldError(field, "unknown flux argument"))
+		}
+	}
+
+	return errors
+}
+
+// Validate the fields of a Flux that the schema can not, returning an error
+// for each problem found.
+func ValidateFlux(cr *v1alpha1.Flux) []Error {
+	spec := cr.Spec
+	errors := validateGitURL("spec.gitUrl", spec.GitUrl)
+
+	errors = append(errors, validateDuration("spec.gitPollInterval", spec.GitPollInterval)...)
+	errors = append(errors, validateDuration("spec.syncInterval", spec.SyncInterval)...)
+	errors = append(errors, validateArgs(spec.Args)...)
+
+	for index, ref := range spec.RoleRefs {
+		if err := rbac.ValidateRoleRef(ref); err != nil {
+			errors = append(errors, fieldError(fmt.Sprintf("spec.roleRefs[%d]", index), "%v", err))
+		}
+	}
+
+	if spec.HelmOperator.GitUrl != "" {
+		errors = append(errors, validateGitURL("spec.helmOperator.gitUrl", spec.HelmOperator.GitUrl)...)
+	}
+
+	errors = append(errors, validateDuration("spec.helmOperator.gitPollInterval", spec.HelmOperator.GitPollInterval)...)
+	errors = append(errors, validateDuration("spec.helmOperator.chartsSyncInterval", spec.HelmOperator.ChartsSyncInterval)...)
+
+	return append(errors, validateFluxCloud(spec.FluxCloud)...)
+}
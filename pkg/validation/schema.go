@@ -0,0 +1,155 @@
+package validation
+
+import (
+	"fmt"
+	"github.com/go-openapi/spec"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"math"
+	"sort"
+)
+
+// The package path of the generated definitions of the flux-operator types.
+const definitionPrefix = "github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1."
+
+// The generated OpenAPI definitions by Go type name.
+var definitions = v1alpha1.GetOpenAPIDefinitions(func(path string) spec.Ref {
+	return spec.MustCreateRef(path)
+})
+
+// Return the definition name of a flux-operator kind.
+func definitionName(kind string) string {
+	return definitionPrefix + kind
+}
+
+// Resolve a schema reference, returning false if it is not defined.
+func resolve(schema spec.Schema) (spec.Schema, bool) {
+	ref := schema.Ref.String()
+	if ref == "" {
+		return schema, true
+	}
+
+	definition, ok := definitions[ref]
+	return definition.Schema, ok
+}
+
+// Return the JSON type name of a value for error messages.
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// Return the path of a property of the object at path.
+func joinPath(path, property string) string {
+	if path == "" {
+		return property
+	}
+	return path + "." + property
+}
+
+// Validate a decoded JSON value at path against schema. References to types
+// without a definition, such as quantities and times, are not validated.
+func validateSchema(path string, value interface{}, schema spec.Schema) []Error {
+	schema, ok := resolve(schema)
+	if !ok || value == nil {
+		return nil
+	}
+
+	schemaType := ""
+	if len(schema.Type) > 0 {
+		schemaType = schema.Type[0]
+	} else if len(schema.Properties) > 0 {
+		schemaType = "object"
+	}
+
+	errors := []Error{}
+
+	switch schemaType {
+	case "string":
+		if _, ok := value.(string); !ok {
+			errors = append(errors, fieldError(path, "expected a string, got %s", typeName(value)))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			errors = append(errors, fieldError(path, "expected a boolean, got %s", typeName(value)))
+		}
+	case "integer":
+		if number, ok := value.(float64); !ok || number != math.Trunc(number) {
+			errors = append(errors, fieldError(path, "expected an integer, got %s", typeName(value)))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			errors = append(errors, fieldError(path, "expected a number, got %s", typeName(value)))
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			errors = append(errors, fieldError(path, "expected an array, got %s", typeName(value)))
+			break
+		}
+
+		if schema.Items == nil || schema.Items.Schema == nil {
+			break
+		}
+
+		for index, item := range items {
+			errors = append(errors, validateSchema(fmt.Sprintf("%s[%d]", path, index), item, *schema.Items.Schema)...)
+		}
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			errors = append(errors, fieldError(path, "expected an object, got %s", typeName(value)))
+			break
+		}
+
+		errors = append(errors, validateObject(path, object, schema)...)
+	}
+
+	return errors
+}
+
+// Validate the properties of an object against schema.
+func validateObject(path string, object map[string]interface{}, schema spec.Schema) []Error {
+	errors := []Error{}
+
+	for _, required := range schema.Required {
+		if _, ok := object[required]; !ok {
+			errors = append(errors, fieldError(joinPath(path, required), "required field is missing"))
+		}
+	}
+
+	keys := []string{}
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		property, ok := schema.Properties[key]
+		if !ok && schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+			property, ok = *schema.AdditionalProperties.Schema, true
+		}
+
+		if !ok {
+			if len(schema.Properties) > 0 {
+				errors = append(errors, fieldError(joinPath(path, key), "unknown field"))
+			}
+			continue
+		}
+
+		errors = append(errors, validateSchema(joinPath(path, key), object[key], property)...)
+	}
+
+	return errors
+}
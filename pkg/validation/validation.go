@@ -0,0 +1,121 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"io"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"strings"
+)
+
+// A problem found in a document of a file.
+type Error struct {
+	// The file containing the document.
+	File string `json:"file"`
+	// The position of the document in the file, starting at 1.
+	Document int `json:"document"`
+	// The namespace and name of the resource, if it has one.
+	Name string `json:"name,omitempty"`
+	// The path of the invalid field, e.g. `spec.roleRefs[0].name`.
+	Field string `json:"field,omitempty"`
+	// What is wrong with it.
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string {
+	parts := []string{e.File}
+
+	if e.Name != "" {
+		parts = append(parts, e.Name)
+	} else {
+		parts = append(parts, fmt.Sprintf("document %d", e.Document))
+	}
+
+	if e.Field != "" {
+		parts = append(parts, e.Field)
+	}
+
+	return strings.Join(append(parts, e.Message), ": ")
+}
+
+// Return an error for field.
+func fieldError(field, message string, args ...interface{}) Error {
+	return Error{Field: field, Message: fmt.Sprintf(message, args...)}
+}
+
+// Return the namespace and name of a decoded document.
+func documentName(raw map[string]interface{}) string {
+	metadata, _ := raw["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+
+	if namespace == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+// Validate every flux.codesink.net resource in a stream of YAML or JSON
+// documents from file against the OpenAPI schema, and Fluxes against the
+// rules the operator relies on. Documents of other API groups are ignored.
+func Validate(file string, reader io.Reader) []Error {
+	decoder := k8syaml.NewYAMLOrJSONDecoder(reader, 4096)
+	errors := []Error{}
+
+	for document := 1; ; document++ {
+		raw := map[string]interface{}{}
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return append(errors, Error{File: file, Document: document, Message: err.Error()})
+		}
+
+		documentErrors := validateDocument(raw)
+		for _, documentError := range documentErrors {
+			documentError.File = file
+			documentError.Document = document
+			documentError.Name = documentName(raw)
+			errors = append(errors, documentError)
+		}
+	}
+
+	return errors
+}
+
+// Validate a decoded document.
+func validateDocument(raw map[string]interface{}) []Error {
+	apiVersion, _ := raw["apiVersion"].(string)
+	if len(raw) == 0 || !strings.HasPrefix(apiVersion, v1alpha1.SchemeGroupVersion.Group+"/") {
+		return nil
+	}
+
+	if apiVersion != v1alpha1.SchemeGroupVersion.String() {
+		return []Error{fieldError("apiVersion", "unsupported version %q", apiVersion)}
+	}
+
+	kind, _ := raw["kind"].(string)
+	definition, ok := definitions[definitionName(kind)]
+	if !ok || strings.HasSuffix(kind, "List") {
+		return []Error{fieldError("kind", "unknown kind %q", kind)}
+	}
+
+	errors := validateSchema("", raw, definition.Schema)
+	if len(errors) > 0 || kind != "Flux" {
+		return errors
+	}
+
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return []Error{{Message: err.Error()}}
+	}
+
+	cr := &v1alpha1.Flux{}
+	if err := json.Unmarshal(encoded, cr); err != nil {
+		return []Error{{Message: err.Error()}}
+	}
+
+	return ValidateFlux(cr)
+}
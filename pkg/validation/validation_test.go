@@ -0,0 +1,144 @@
+package validation
+
+import (
+	"github.com/justinbarrick/flux-operator/pkg/utils/test"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func messages(errors []Error) []string {
+	messages := []string{}
+	for _, err := range errors {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+func fieldMessages(errors []Error) []string {
+	messages := []string{}
+	for _, err := range errors {
+		messages = append(messages, err.Field+": "+err.Message)
+	}
+	return messages
+}
+
+func TestValidateValid(t *testing.T) {
+	errors := Validate("flux.yaml", strings.NewReader(`
+apiVersion: flux.codesink.net/v1alpha1
+kind: Flux
+metadata:
+  name: example
+  namespace: default
+  labels:
+    team: a
+spec:
+  gitUrl: git@github.com:justinbarrick/flux-operator
+  gitPollInterval: 0m30s
+  args:
+    k8s-namespace-whitelist: default
+  resources:
+    limits:
+      cpu: 1
+  roleRefs:
+  - name: view
+  fluxCloud:
+    enabled: true
+    githubUrl: https://github.com/justinbarrick/flux-operator
+    slackUrl: https://example.com/
+    slackChannel: "#mychannel"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+data:
+  unknown: 1
+`))
+	assert.Equal(t, []string{}, messages(errors))
+}
+
+func TestValidateSchema(t *testing.T) {
+	errors := Validate("flux.yaml", strings.NewReader(`
+apiVersion: flux.codesink.net/v1alpha1
+kind: Flux
+metadata:
+  name: example
+  namespace: default
+spec:
+  gitURL: ssh://git@github.com/justinbarrick/flux-operator
+  args:
+    registry-rps: 200
+  role:
+    enabled: "yes"
+  roleRefs:
+  - kind: ClusterRole
+---
+apiVersion: flux.codesink.net/v1alpha1
+kind: Fluxx
+metadata:
+  name: typo
+`))
+	assert.Equal(t, []string{
+		"flux.yaml: default/example: spec.gitUrl: required field is missing",
+		"flux.yaml: default/example: spec.args.registry-rps: expected a string, got number",
+		"flux.yaml: default/example: spec.gitURL: unknown field",
+		"flux.yaml: default/example: spec.role.enabled: expected a boolean, got string",
+		"flux.yaml: default/example: spec.roleRefs[0].name: required field is missing",
+		"flux.yaml: typo: kind: unknown kind \"Fluxx\"",
+	}, messages(errors))
+	assert.Equal(t, 2, errors[5].Document)
+}
+
+func TestValidateInvalidYAML(t *testing.T) {
+	errors := Validate("flux.yaml", strings.NewReader("kind: Flux\nspec: [\n"))
+	assert.Equal(t, 1, len(errors))
+	assert.Equal(t, 1, errors[0].Document)
+}
+
+func TestValidateFlux(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.GitUrl = "github.com/justinbarrick/flux-operator"
+	cr.Spec.SyncInterval = "5 minutes"
+	cr.Spec.Args = map[string]string{
+		"--git-label":    "flux",
+		"git-poll-intvl": "1m",
+		"git-label":      "flux",
+	}
+	cr.Spec.HelmOperator.ChartsSyncInterval = "1h"
+	cr.Spec.FluxCloud.Enabled = true
+	cr.Spec.FluxCloud.GithubURL = "github.com/justinbarrick/flux-operator"
+	cr.Spec.FluxCloud.MatrixURL = "https://matrix.org"
+	cr.Spec.FluxCloud.MatrixRoomId = "!room:matrix.org"
+
+	assert.Equal(t, []string{
+		`spec.gitUrl: invalid git URL "github.com/justinbarrick/flux-operator": must be ssh://, git://, http(s):// or user@host:path`,
+		`spec.syncInterval: invalid duration "5 minutes", e.g. 5m or 1h30m`,
+		`spec.args.--git-label: args must not include the leading --`,
+		`spec.args.git-poll-intvl: unknown flux argument`,
+		`spec.fluxCloud.githubUrl: invalid URL "github.com/justinbarrick/flux-operator": must be an http:// or https:// URL`,
+		`spec.fluxCloud.matrixToken: required field is missing`,
+	}, fieldMessages(ValidateFlux(cr)))
+}
+
+func TestValidateFluxCloudSlack(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.GitUrl = "ssh://git@github.com/justinbarrick/flux-operator"
+	cr.Spec.FluxCloud.Enabled = true
+	cr.Spec.FluxCloud.GithubURL = "https://github.com/justinbarrick/flux-operator"
+	cr.Spec.FluxCloud.SlackURL = "https://hooks.slack.com/services/x"
+
+	assert.Equal(t, []string{
+		"spec.fluxCloud.slackChannel: required field is missing",
+	}, fieldMessages(ValidateFlux(cr)))
+}
+
+func TestValidateFluxCloudWithoutGithubURL(t *testing.T) {
+	cr := test_utils.NewFlux()
+	cr.Spec.GitUrl = "ssh://git@github.com/justinbarrick/flux-operator"
+	cr.Spec.FluxCloud.Enabled = true
+	cr.Spec.FluxCloud.SlackURL = "https://hooks.slack.com/services/x"
+	cr.Spec.FluxCloud.SlackChannel = "#flux"
+
+	assert.Equal(t, []string{}, fieldMessages(ValidateFlux(cr)))
+}
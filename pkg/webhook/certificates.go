@@ -0,0 +1,206 @@
+package webhook
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// How long generated certificates are valid for.
+	CertificateValidity = 10 * 365 * 24 * time.Hour
+	// Certificates expiring sooner than this are replaced on startup.
+	RenewBefore = 30 * 24 * time.Hour
+)
+
+// The keys of the certificates in the webhook's Secret.
+const (
+	CAKey   = "ca.crt"
+	CertKey = "tls.crt"
+	KeyKey  = "tls.key"
+)
+
+// The PEM encoded serving certificate of the webhook, its key and the CA that
+// signed it.
+type Certificates struct {
+	CA   []byte
+	Cert []byte
+	Key  []byte
+}
+
+// Return the DNS names the API server may use to reach the webhook's Service.
+func dnsNames(service, namespace string) []string {
+	return []string{
+		service,
+		fmt.Sprintf("%s.%s", service, namespace),
+		fmt.Sprintf("%s.%s.svc", service, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", service, namespace),
+	}
+}
+
+// Return a random certificate serial number.
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// Create a self-signed CA and a certificate signed by it for the webhook's
+// Service.
+func NewCertificates(service, namespace string) (*Certificates, error) {
+	now := time.Now()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	caSerial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          caSerial,
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("%s-ca", service)},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(CertificateValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	names := dnsNames(service, namespace)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: names[2]},
+		DNSNames:     names,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(CertificateValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Certificates{
+		CA:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+// Return the certificates stored in a Secret's data.
+func CertificatesFromData(data map[string][]byte) *Certificates {
+	return &Certificates{
+		CA:   data[CAKey],
+		Cert: data[CertKey],
+		Key:  data[KeyKey],
+	}
+}
+
+// Return the certificates as a Secret's data.
+func (c *Certificates) Data() map[string][]byte {
+	return map[string][]byte{
+		CAKey:   c.CA,
+		CertKey: c.Cert,
+		KeyKey:  c.Key,
+	}
+}
+
+// Return the serving certificate and key for a TLS server.
+func (c *Certificates) TLSCertificate() (tls.Certificate, error) {
+	return tls.X509KeyPair(c.Cert, c.Key)
+}
+
+// Return an error if the certificate does not match its key, is not signed by
+// the CA for the webhook's Service or expires within RenewBefore of now.
+func (c *Certificates) Verify(service, namespace string, now time.Time) error {
+	keyPair, err := c.TLSCertificate()
+	if err != nil {
+		return err
+	}
+
+	cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(c.CA) {
+		return fmt.Errorf("no CA certificate found")
+	}
+
+	_, err = cert.Verify(x509.VerifyOptions{
+		DNSName:     dnsNames(service, namespace)[2],
+		Roots:       roots,
+		CurrentTime: now.Add(RenewBefore),
+	})
+	return err
+}
+
+// Holds the webhook's current serving certificate, so that it can be replaced
+// while the webhook is serving.
+type CertificateStore struct {
+	lock        sync.RWMutex
+	certificate *tls.Certificate
+}
+
+// Serve certificates from now on.
+func (s *CertificateStore) Set(certificates *Certificates) error {
+	keyPair, err := certificates.TLSCertificate()
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.certificate = &keyPair
+	return nil
+}
+
+// Return the current serving certificate, for tls.Config.GetCertificate.
+func (s *CertificateStore) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.certificate == nil {
+		return nil, fmt.Errorf("no certificate loaded")
+	}
+
+	return s.certificate, nil
+}
@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewCertificates(t *testing.T) {
+	certificates, err := NewCertificates("flux-operator-webhook", "flux")
+	assert.Nil(t, err)
+
+	_, err = certificates.TLSCertificate()
+	assert.Nil(t, err)
+
+	assert.Nil(t, certificates.Verify("flux-operator-webhook", "flux", time.Now()))
+	assert.NotNil(t, certificates.Verify("flux-operator-webhook", "other", time.Now()))
+	assert.NotNil(t, certificates.Verify("flux-operator-webhook", "flux", time.Now().Add(CertificateValidity)))
+
+	loaded := CertificatesFromData(certificates.Data())
+	assert.Equal(t, certificates, loaded)
+
+	other, err := NewCertificates("flux-operator-webhook", "flux")
+	assert.Nil(t, err)
+	loaded.CA = other.CA
+	assert.NotNil(t, loaded.Verify("flux-operator-webhook", "flux", time.Now()))
+}
+
+func TestCertificateStore(t *testing.T) {
+	store := &CertificateStore{}
+
+	_, err := store.GetCertificate(nil)
+	assert.NotNil(t, err)
+
+	certificates, err := NewCertificates("flux-operator-webhook", "flux")
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set(certificates))
+
+	served, err := store.GetCertificate(nil)
+	assert.Nil(t, err)
+	keyPair, _ := certificates.TLSCertificate()
+	assert.Equal(t, keyPair.Certificate, served.Certificate)
+
+	assert.NotNil(t, store.Set(&Certificates{}))
+	served, err = store.GetCertificate(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, keyPair.Certificate, served.Certificate)
+}
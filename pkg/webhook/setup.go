@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/utils"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"time"
+)
+
+const (
+	// The default name of the webhook's Service and certificate Secret.
+	DefaultName = "flux-operator-webhook"
+	// The CRD whose conversions the webhook serves.
+	FluxCRDName = "fluxes.flux.codesink.net"
+	// How often the certificates are reloaded from their Secret and the CRD's CA
+	// bundle is checked.
+	RefreshInterval = time.Minute
+)
+
+// Settings for the conversion webhook.
+type Config struct {
+	// The name of the webhook's Service and of the Secret holding its certificates.
+	Name string
+	// The namespace flux-operator runs in.
+	Namespace string
+	// Whether to set the CA bundle of the Flux CRD's conversion webhook, which
+	// requires permission to update the CRD.
+	InjectCABundle bool
+}
+
+// Load the webhook settings from the environment.
+func ConfigFromEnv() Config {
+	return Config{
+		Name:           utils.Getenv("WEBHOOK_NAME", DefaultName),
+		Namespace:      utils.Getenv("POD_NAMESPACE", "default"),
+		InjectCABundle: !utils.BoolEnv("NAMESPACED"),
+	}
+}
+
+// Load the webhook's certificates from its Secret, creating the Secret if it
+// does not exist and replacing the certificates if they are invalid or about to
+// expire. Every replica uses the certificates of whichever replica created them.
+func EnsureCertificates(client kubernetes.Interface, config Config) (*Certificates, error) {
+	secrets := client.CoreV1().Secrets(config.Namespace)
+
+	secret, err := secrets.Get(config.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		certificates, err := NewCertificates(config.Name, config.Namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		logrus.Infof("Creating the conversion webhook certificates in Secret %s", config.Name)
+		_, err = secrets.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      config.Name,
+				Namespace: config.Namespace,
+			},
+			Type: corev1.SecretTypeTLS,
+			Data: certificates.Data(),
+		})
+		if errors.IsAlreadyExists(err) {
+			return EnsureCertificates(client, config)
+		} else if err != nil {
+			return nil, err
+		}
+
+		return certificates, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	certificates := CertificatesFromData(secret.Data)
+	if err := certificates.Verify(config.Name, config.Namespace, time.Now()); err == nil {
+		return certificates, nil
+	} else {
+		logrus.Infof("Replacing the conversion webhook certificates: %v", err)
+	}
+
+	certificates, err = NewCertificates(config.Name, config.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	secret.Data = certificates.Data()
+	_, err = secrets.Update(secret)
+	if errors.IsConflict(err) {
+		return EnsureCertificates(client, config)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return certificates, nil
+}
+
+// Set the CA bundle of the Flux CRD's conversion webhook to ca if it is not
+// already set to it. The CRD is read and written as an unstructured object so
+// that fields this version of flux-operator does not know about are kept.
+func InjectCABundle(client rest.Interface, ca []byte) error {
+	path := "/apis/apiextensions.k8s.io/v1/customresourcedefinitions/" + FluxCRDName
+
+	body, err := client.Get().AbsPath(path).DoRaw()
+	if err != nil {
+		return err
+	}
+
+	definition := map[string]interface{}{}
+	if err := json.Unmarshal(body, &definition); err != nil {
+		return err
+	}
+
+	clientConfig := definition
+	for _, key := range []string{"spec", "conversion", "webhook", "clientConfig"} {
+		clientConfig, _ = clientConfig[key].(map[string]interface{})
+		if clientConfig == nil {
+			return fmt.Errorf("%s has no conversion webhook", FluxCRDName)
+		}
+	}
+
+	caBundle := base64.StdEncoding.EncodeToString(ca)
+	if clientConfig["caBundle"] == caBundle {
+		return nil
+	}
+
+	logrus.Infof("Setting the conversion webhook CA bundle of %s", FluxCRDName)
+	clientConfig["caBundle"] = caBundle
+
+	body, err = json.Marshal(definition)
+	if err != nil {
+		return err
+	}
+
+	return client.Put().AbsPath(path).SetHeader("Content-Type", "application/json").Body(body).Do().Error()
+}
+
+// Load the certificates from their Secret, renewing them if needed, serve them
+// from store and set their CA as the Flux CRD's CA bundle if configured to. The
+// Secret is shared by every replica, so one replica's renewal reaches the others.
+func Refresh(client kubernetes.Interface, config Config, store *CertificateStore) error {
+	certificates, err := EnsureCertificates(client, config)
+	if err != nil {
+		return fmt.Errorf("failed to load certificates: %v", err)
+	}
+
+	if err := store.Set(certificates); err != nil {
+		return fmt.Errorf("failed to load certificates: %v", err)
+	}
+
+	if config.InjectCABundle {
+		if err := InjectCABundle(client.Discovery().RESTClient(), certificates.CA); err != nil {
+			logrus.Errorf("Failed to set the conversion webhook CA bundle: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Serve the conversion webhook until ctx is cancelled. The certificates are
+// refreshed periodically since they may be renewed, and applying the Flux CRD
+// again may clear its CA bundle.
+func Run(ctx context.Context, client kubernetes.Interface, config Config) error {
+	store := &CertificateStore{}
+	if err := Refresh(client, config, store); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(RefreshInterval):
+			}
+
+			if err := Refresh(client, config, store); err != nil {
+				logrus.Errorf("Failed to refresh the conversion webhook certificates: %v", err)
+			}
+		}
+	}()
+
+	return ListenAndServeTLS(ctx, fmt.Sprintf(":%d", Port), store)
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEnsureCertificates(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	config := Config{Name: "flux-operator-webhook", Namespace: "flux"}
+
+	certificates, err := EnsureCertificates(client, config)
+	assert.Nil(t, err)
+
+	again, err := EnsureCertificates(client, config)
+	assert.Nil(t, err)
+	assert.Equal(t, certificates, again)
+
+	secret, err := client.CoreV1().Secrets("flux").Get("flux-operator-webhook", metav1.GetOptions{})
+	assert.Nil(t, err)
+	secret.Data[CAKey] = []byte("invalid")
+	_, err = client.CoreV1().Secrets("flux").Update(secret)
+	assert.Nil(t, err)
+
+	renewed, err := EnsureCertificates(client, config)
+	assert.Nil(t, err)
+	assert.NotEqual(t, certificates, renewed)
+	assert.Nil(t, renewed.Verify(config.Name, config.Namespace, time.Now()))
+}
+
+func TestRefresh(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	config := Config{Name: "flux-operator-webhook", Namespace: "flux"}
+	store := &CertificateStore{}
+
+	assert.Nil(t, Refresh(client, config, store))
+	first, err := store.GetCertificate(nil)
+	assert.Nil(t, err)
+
+	renewed, err := NewCertificates(config.Name, config.Namespace)
+	assert.Nil(t, err)
+
+	secret, err := client.CoreV1().Secrets("flux").Get("flux-operator-webhook", metav1.GetOptions{})
+	assert.Nil(t, err)
+	secret.Data = renewed.Data()
+	_, err = client.CoreV1().Secrets("flux").Update(secret)
+	assert.Nil(t, err)
+
+	assert.Nil(t, Refresh(client, config, store))
+	second, err := store.GetCertificate(nil)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.Certificate, second.Certificate)
+
+	keyPair, _ := renewed.TLSCertificate()
+	assert.Equal(t, keyPair.Certificate, second.Certificate)
+}
+
+func TestInjectCABundle(t *testing.T) {
+	definition := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": FluxCRDName},
+		"spec": map[string]interface{}{
+			"conversion": map[string]interface{}{
+				"strategy": "Webhook",
+				"webhook": map[string]interface{}{
+					"clientConfig": map[string]interface{}{
+						"service": map[string]interface{}{"name": "flux-operator-webhook", "namespace": "flux"},
+					},
+				},
+			},
+			"preserveUnknownFields": false,
+		},
+	}
+
+	puts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apis/apiextensions.k8s.io/v1/customresourcedefinitions/"+FluxCRDName, r.URL.Path)
+		if r.Method == "PUT" {
+			puts++
+			body, _ := ioutil.ReadAll(r.Body)
+			definition = map[string]interface{}{}
+			assert.Nil(t, json.Unmarshal(body, &definition))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(definition)
+	}))
+	defer server.Close()
+
+	client, err := rest.UnversionedRESTClientFor(&rest.Config{
+		Host: server.URL,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &schema.GroupVersion{},
+			NegotiatedSerializer: serializer.DirectCodecFactory{CodecFactory: scheme.Codecs},
+		},
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, InjectCABundle(client, []byte("ca")))
+	assert.Nil(t, InjectCABundle(client, []byte("ca")))
+	assert.Equal(t, 1, puts)
+
+	spec := definition["spec"].(map[string]interface{})
+	assert.Equal(t, false, spec["preserveUnknownFields"])
+	clientConfig := spec["conversion"].(map[string]interface{})["webhook"].(map[string]interface{})["clientConfig"].(map[string]interface{})
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("ca")), clientConfig["caBundle"])
+
+	delete(spec, "conversion")
+	assert.NotNil(t, InjectCABundle(client, []byte("ca")))
+}
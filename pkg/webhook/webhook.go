@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha1"
+	"github.com/justinbarrick/flux-operator/pkg/apis/flux/v1alpha2"
+	"github.com/justinbarrick/flux-operator/pkg/crd"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"net/http"
+)
+
+const (
+	// The port that the conversion webhook is served on.
+	Port = 9443
+	// The path of the conversion webhook.
+	ConvertPath = "/convert"
+)
+
+// Convert a Flux encoded as JSON to apiVersion.
+func Convert(object []byte, apiVersion string) ([]byte, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(object, &typeMeta); err != nil {
+		return nil, err
+	}
+
+	if typeMeta.Kind != "Flux" {
+		return nil, fmt.Errorf("can not convert kind %q", typeMeta.Kind)
+	}
+
+	if typeMeta.APIVersion == apiVersion {
+		return object, nil
+	}
+
+	var converted interface{}
+
+	switch {
+	case typeMeta.APIVersion == v1alpha1.SchemeGroupVersion.String() && apiVersion == v1alpha2.SchemeGroupVersion.String():
+		cr := &v1alpha1.Flux{}
+		if err := json.Unmarshal(object, cr); err != nil {
+			return nil, err
+		}
+		converted = v1alpha2.ConvertFromV1alpha1(cr)
+	case typeMeta.APIVersion == v1alpha2.SchemeGroupVersion.String() && apiVersion == v1alpha1.SchemeGroupVersion.String():
+		cr := &v1alpha2.Flux{}
+		if err := json.Unmarshal(object, cr); err != nil {
+			return nil, err
+		}
+		converted = v1alpha2.ConvertToV1alpha1(cr)
+	default:
+		return nil, fmt.Errorf("can not convert Flux from %q to %q", typeMeta.APIVersion, apiVersion)
+	}
+
+	return json.Marshal(converted)
+}
+
+// Answer a ConversionReview, failing the whole review if any object can not be
+// converted.
+func Review(review *crd.ConversionReview) *crd.ConversionReview {
+	request := review.Request
+
+	response := &crd.ConversionResponse{
+		UID:              request.UID,
+		ConvertedObjects: []runtime.RawExtension{},
+		Result:           metav1.Status{Status: metav1.StatusSuccess},
+	}
+
+	for _, object := range request.Objects {
+		converted, err := Convert(object.Raw, request.DesiredAPIVersion)
+		if err != nil {
+			response.ConvertedObjects = nil
+			response.Result = metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: err.Error(),
+			}
+			break
+		}
+
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	return &crd.ConversionReview{
+		TypeMeta: review.TypeMeta,
+		Response: response,
+	}
+}
+
+// Return an http.Handler serving the conversion webhook.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(ConvertPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		review := &crd.ConversionReview{}
+		if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+			http.Error(w, fmt.Sprintf("invalid ConversionReview: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if review.Request == nil {
+			http.Error(w, "ConversionReview has no request", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(Review(review)); err != nil {
+			logrus.Errorf("Failed to write ConversionReview response: %v", err)
+		}
+	})
+
+	return mux
+}
+
+// Serve the conversion webhook over TLS on address until ctx is cancelled, with
+// whichever certificate is in the store at each handshake.
+func ListenAndServeTLS(ctx context.Context, address string, store *CertificateStore) error {
+	server := &http.Server{
+		Addr:    address,
+		Handler: Handler(),
+		TLSConfig: &tls.Config{
+			GetCertificate: store.GetCertificate,
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	logrus.Infof("Serving the conversion webhook on %s", address)
+	err := server.ListenAndServeTLS("", "")
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
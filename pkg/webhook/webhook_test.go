@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/justinbarrick/flux-operator/pkg/crd"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testV1alpha1 = `{"apiVersion":"flux.codesink.net/v1alpha1","kind":"Flux","metadata":{"name":"example","namespace":"default"},"spec":{"gitUrl":"git@github.com:example/repo","gitBranch":"main","fluxCloud":{"slackUser":"bot"}}}`
+
+func decode(t *testing.T, encoded []byte) map[string]interface{} {
+	decoded := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(encoded, &decoded))
+	return decoded
+}
+
+func TestConvert(t *testing.T) {
+	converted, err := Convert([]byte(testV1alpha1), "flux.codesink.net/v1alpha2")
+	assert.Nil(t, err)
+
+	decoded := decode(t, converted)
+	assert.Equal(t, "flux.codesink.net/v1alpha2", decoded["apiVersion"])
+	spec := decoded["spec"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"url": "git@github.com:example/repo", "branch": "main"}, spec["git"])
+	assert.Equal(t, "bot", spec["fluxCloud"].(map[string]interface{})["slack"].(map[string]interface{})["username"])
+
+	back, err := Convert(converted, "flux.codesink.net/v1alpha1")
+	assert.Nil(t, err)
+
+	original, err := Convert([]byte(testV1alpha1), "flux.codesink.net/v1alpha1")
+	assert.Nil(t, err)
+	assert.Equal(t, testV1alpha1, string(original))
+
+	decoded = decode(t, back)
+	spec = decoded["spec"].(map[string]interface{})
+	assert.Equal(t, "main", spec["gitBranch"])
+	assert.Equal(t, "bot", spec["fluxCloud"].(map[string]interface{})["slackUser"])
+}
+
+func TestConvertInvalid(t *testing.T) {
+	_, err := Convert([]byte(`{"apiVersion":"flux.codesink.net/v1alpha1","kind":"FluxPolicy"}`), "flux.codesink.net/v1alpha2")
+	assert.NotNil(t, err)
+
+	_, err = Convert([]byte(testV1alpha1), "flux.codesink.net/v1beta1")
+	assert.NotNil(t, err)
+}
+
+func review(t *testing.T, body []byte) (int, *crd.ConversionReview) {
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("POST", ConvertPath, bytes.NewReader(body)))
+
+	response := &crd.ConversionReview{}
+	if recorder.Code == http.StatusOK {
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), response))
+	}
+	return recorder.Code, response
+}
+
+func TestHandler(t *testing.T) {
+	request, err := json.Marshal(&crd.ConversionReview{
+		TypeMeta: metav1.TypeMeta{Kind: "ConversionReview", APIVersion: "apiextensions.k8s.io/v1"},
+		Request: &crd.ConversionRequest{
+			UID:               "1234",
+			DesiredAPIVersion: "flux.codesink.net/v1alpha2",
+			Objects:           []runtime.RawExtension{{Raw: []byte(testV1alpha1)}},
+		},
+	})
+	assert.Nil(t, err)
+
+	code, response := review(t, request)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ConversionReview", response.Kind)
+	assert.Equal(t, "1234", string(response.Response.UID))
+	assert.Equal(t, metav1.StatusSuccess, response.Response.Result.Status)
+	assert.Equal(t, 1, len(response.Response.ConvertedObjects))
+	assert.Equal(t, "flux.codesink.net/v1alpha2", decode(t, response.Response.ConvertedObjects[0].Raw)["apiVersion"])
+}
+
+func TestHandlerFailure(t *testing.T) {
+	request, err := json.Marshal(&crd.ConversionReview{
+		Request: &crd.ConversionRequest{
+			UID:               "1234",
+			DesiredAPIVersion: "flux.codesink.net/v1beta1",
+			Objects:           []runtime.RawExtension{{Raw: []byte(testV1alpha1)}},
+		},
+	})
+	assert.Nil(t, err)
+
+	code, response := review(t, request)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, metav1.StatusFailure, response.Response.Result.Status)
+	assert.Nil(t, response.Response.ConvertedObjects)
+
+	code, _ = review(t, []byte(`{}`))
+	assert.Equal(t, http.StatusBadRequest, code)
+}